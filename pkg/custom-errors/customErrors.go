@@ -58,6 +58,15 @@ func (e InternalServerError) Error() string {
 	return e.Msg + " Error Code: " + strconv.Itoa(e.InternalErrorCode)
 }
 
+//ServiceUnavailableError - used when a downstream service is down and we are not calling it (e.g. an open circuit breaker)
+type ServiceUnavailableError struct {
+	Msg string
+}
+
+func (e ServiceUnavailableError) Error() string {
+	return e.Msg
+}
+
 //Client Connection Error - Custom error for when we are unable to create a client connection
 type ClientConstructionError struct {
 	Msg string
@@ -44,6 +44,58 @@ func ConstructClient(location string) (*GrpcServiceClient, error) {
 	return constructedClient, nil
 }
 
+//RequestOption - optional settings for a request (e.g. the time zone)
+type RequestOption func(request *sledgconf_demo_proto_v1.GetDataFromStationsRequest)
+
+//WithTimeZone - times come back in the time zone (GMT, LST or LST_LDT).  Defaults to GMT
+func WithTimeZone(timeZone sledgconf_demo_proto_v1.TimeZone) RequestOption {
+	return func(request *sledgconf_demo_proto_v1.GetDataFromStationsRequest) {
+		request.TimeZone = timeZone
+	}
+}
+
+//WithFailureMode - BestEffort sends back the products that worked along with the status of the ones that didn't.  Defaults to FailFast
+func WithFailureMode(failureMode sledgconf_demo_proto_v1.FailureMode) RequestOption {
+	return func(request *sledgconf_demo_proto_v1.GetDataFromStationsRequest) {
+		request.FailureMode = failureMode
+	}
+}
+
+//WithProducts - only get these products.  Defaults to all of them
+func WithProducts(products ...sledgconf_demo_proto_v1.DataType) RequestOption {
+	return func(request *sledgconf_demo_proto_v1.GetDataFromStationsRequest) {
+		request.Products = products
+	}
+}
+
+//WithUnit - sends the product back in the unit (e.g. kn, ft, degF or mph) instead of the metric preference.  Use it once for each product
+func WithUnit(product sledgconf_demo_proto_v1.DataType, unit string) RequestOption {
+	return func(request *sledgconf_demo_proto_v1.GetDataFromStationsRequest) {
+		request.ProductUnits = append(request.ProductUnits, &sledgconf_demo_proto_v1.ProductUnit{Product: product, Unit: unit})
+	}
+}
+
+//WithExcludeFlagged - leaves out the points that Noaa flagged (e.g. rate of change or max/min exceeded)
+func WithExcludeFlagged() RequestOption {
+	return func(request *sledgconf_demo_proto_v1.GetDataFromStationsRequest) {
+		request.ExcludeFlagged = true
+	}
+}
+
+//WithExcludePreliminary - leaves out the points that haven't been verified yet
+func WithExcludePreliminary() RequestOption {
+	return func(request *sledgconf_demo_proto_v1.GetDataFromStationsRequest) {
+		request.ExcludePreliminary = true
+	}
+}
+
+//WithBypassCache - skip the service's cache and go to Noaa
+func WithBypassCache() RequestOption {
+	return func(request *sledgconf_demo_proto_v1.GetDataFromStationsRequest) {
+		request.BypassCache = true
+	}
+}
+
 //GrpcServiceClient - methods
 
 //GetDataFromStations - main client method that will get all available data from the station that is passed in.
@@ -55,17 +107,13 @@ func ConstructClient(location string) (*GrpcServiceClient, error) {
 //	Precondition: missing mandatory data
 //	Invalid Data: Data is invalid and won't work (e.g. start date after end date)
 //  Internal Server: Catch all for the remaining errors
-func (client *GrpcServiceClient) GetDataFromStations(stationIDs *[]string, startTime, endTime *time.Time, datum string, metricPreference sledgconf_demo_proto_v1.MetricPreference) (*map[string]*sledgconf_demo_proto_v1.Station, error) {
+func (client *GrpcServiceClient) GetDataFromStations(stationIDs *[]string, startTime, endTime *time.Time, datum string, metricPreference sledgconf_demo_proto_v1.MetricPreference, options ...RequestOption) (*map[string]*sledgconf_demo_proto_v1.Station, error) {
 	//Precondition Check - I do a precondition check in the client to avoid making a call to the server for anything that isn't well constructed
 	//Pattern that I follow here is that I typically check for the existance of mandatory data in the client but check for quality of data in the server
 	if stationIDs == nil || len(*stationIDs) == 0 || startTime == nil || endTime == nil || datum == "" {
 		return nil, customerrors.PreconditionError{Msg: "Missing Mandatory Data"}
 	}
 
-	//sets up the cancel function and the context
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
-	defer cancel()
-
 	//Construct the protobuf params
 	//We don't let the protobuf structs leak out of the client or the server layer
 	request := &sledgconf_demo_proto_v1.GetDataFromStationsRequest{
@@ -75,23 +123,117 @@ func (client *GrpcServiceClient) GetDataFromStations(stationIDs *[]string, start
 		Datum:                   datum,
 		MetricPreference:        metricPreference,
 	}
+	return client.getDataFromStations(request, options)
+}
+
+//GetDataFromStationsByQueryMode - same as GetDataFromStations but addresses the window with one of Noaa's query modes instead of a start and end time
+//
+//The range in hours is only used for the Range query mode and is the number of hours before now
+//
+//Errors:
+//	Precondition: missing mandatory data
+//	Invalid Data: Data is invalid and won't work (e.g. a range of 0 hours)
+//  Internal Server: Catch all for the remaining errors
+func (client *GrpcServiceClient) GetDataFromStationsByQueryMode(stationIDs *[]string, queryMode sledgconf_demo_proto_v1.QueryMode, rangeInHours int32, datum string, metricPreference sledgconf_demo_proto_v1.MetricPreference, options ...RequestOption) (*map[string]*sledgconf_demo_proto_v1.Station, error) {
+	//Precondition Check
+	if stationIDs == nil || len(*stationIDs) == 0 || datum == "" {
+		return nil, customerrors.PreconditionError{Msg: "Missing Mandatory Data"}
+	}
+	request := &sledgconf_demo_proto_v1.GetDataFromStationsRequest{
+		ArrayOfStationIDs: *stationIDs,
+		QueryMode:         queryMode,
+		RangeInHours:      rangeInHours,
+		Datum:             datum,
+		MetricPreference:  metricPreference,
+	}
+	return client.getDataFromStations(request, options)
+}
+
+//getDataFromStations - makes the call and maps the GRPC errors back over to our custom errors
+func (client *GrpcServiceClient) getDataFromStations(request *sledgconf_demo_proto_v1.GetDataFromStationsRequest, options []RequestOption) (*map[string]*sledgconf_demo_proto_v1.Station, error) {
+	//Apply any of the optional settings
+	for _, option := range options {
+		option(request)
+	}
+
+	//sets up the cancel function and the context
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
 	//Make the call to the server
 	response, err := client.userConn.GetDataFromStations(ctx, request)
 	if err != nil {
-		//Handle error Cases - thse could be GRPC, Connection, or custom
-		statusCode, ok := status.FromError(err)
-		if !ok {
-			//This happens if we cannot get the status - will throw the generic
-			return nil, customerrors.InternalServerError{Msg: err.Error()}
-		}
-		switch statusCode.Code() {
-		case codes.FailedPrecondition:
-			return nil, customerrors.PreconditionError{Msg: err.Error()}
-		case codes.InvalidArgument:
-			return nil, customerrors.InvalidData{Msg: err.Error()}
-		default:
-			return nil, customerrors.InternalServerError{Msg: err.Error()}
-		}
+		return nil, convertFromStatusError(err)
 	}
 	return &response.MapOfStationData, nil
 }
+
+//GetNearestStations - the stations closest to the point that have every one of the products.  Anything less than 1 for the limit gets 10
+//
+//Errors:
+//	Invalid Data: the point isn't a real latitude and longitude
+//  Internal Server: Catch all for the remaining errors
+func (client *GrpcServiceClient) GetNearestStations(latitude, longitude float64, limit int32, products ...sledgconf_demo_proto_v1.DataType) ([]*sledgconf_demo_proto_v1.CatalogStation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	response, err := client.userConn.GetNearestStations(ctx, &sledgconf_demo_proto_v1.GetNearestStationsRequest{Latitude: latitude, Longitude: longitude, Limit: limit, Products: products})
+	if err != nil {
+		return nil, convertFromStatusError(err)
+	}
+	return response.Stations, nil
+}
+
+//GetStationsInBox - the stations inside the box that have every one of the products.  A minimum longitude bigger than the maximum is a box that crosses the antimeridian
+//
+//Errors:
+//	Invalid Data: the corners aren't real latitudes and longitudes
+//  Internal Server: Catch all for the remaining errors
+func (client *GrpcServiceClient) GetStationsInBox(minLatitude, minLongitude, maxLatitude, maxLongitude float64, products ...sledgconf_demo_proto_v1.DataType) ([]*sledgconf_demo_proto_v1.CatalogStation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	response, err := client.userConn.GetStationsInBox(ctx, &sledgconf_demo_proto_v1.GetStationsInBoxRequest{MinLatitude: minLatitude, MinLongitude: minLongitude, MaxLatitude: maxLatitude, MaxLongitude: maxLongitude, Products: products})
+	if err != nil {
+		return nil, convertFromStatusError(err)
+	}
+	return response.Stations, nil
+}
+
+//SearchStations - the stations with a name (or ID) close to the query that have every one of the products.  Anything less than 1 for the limit gets 10
+//
+//Errors:
+//	Precondition: missing mandatory data
+//  Internal Server: Catch all for the remaining errors
+func (client *GrpcServiceClient) SearchStations(query string, limit int32, products ...sledgconf_demo_proto_v1.DataType) ([]*sledgconf_demo_proto_v1.CatalogStation, error) {
+	//Precondition Check
+	if query == "" {
+		return nil, customerrors.PreconditionError{Msg: "Missing Mandatory Data"}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	response, err := client.userConn.SearchStations(ctx, &sledgconf_demo_proto_v1.SearchStationsRequest{Query: query, Limit: limit, Products: products})
+	if err != nil {
+		return nil, convertFromStatusError(err)
+	}
+	return response.Stations, nil
+}
+
+//convertFromStatusError - maps the GRPC errors back over to our custom errors.  These could be GRPC, Connection, or custom
+func convertFromStatusError(err error) error {
+	statusCode, ok := status.FromError(err)
+	if !ok {
+		//This happens if we cannot get the status - will throw the generic
+		return customerrors.InternalServerError{Msg: err.Error()}
+	}
+	switch statusCode.Code() {
+	case codes.FailedPrecondition:
+		return customerrors.PreconditionError{Msg: err.Error()}
+	case codes.InvalidArgument:
+		return customerrors.InvalidData{Msg: err.Error()}
+	case codes.NotFound:
+		return customerrors.NotFoundError{Msg: err.Error()}
+	case codes.Unavailable:
+		return customerrors.ServiceUnavailableError{Msg: err.Error()}
+	default:
+		return customerrors.InternalServerError{Msg: err.Error()}
+	}
+}
@@ -95,7 +95,7 @@ func (x DataType) String() string {
 	return proto.EnumName(DataType_name, int32(x))
 }
 func (DataType) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_demo_744868870d596b95, []int{0}
+	return fileDescriptor_demo_f17968dd747845b7, []int{0}
 }
 
 type MetricPreference int32
@@ -118,7 +118,146 @@ func (x MetricPreference) String() string {
 	return proto.EnumName(MetricPreference_name, int32(x))
 }
 func (MetricPreference) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_demo_744868870d596b95, []int{1}
+	return fileDescriptor_demo_f17968dd747845b7, []int{1}
+}
+
+type TimeZone int32
+
+const (
+	TimeZone_GMT     TimeZone = 0
+	TimeZone_LST     TimeZone = 1
+	TimeZone_LST_LDT TimeZone = 2
+)
+
+var TimeZone_name = map[int32]string{
+	0: "GMT",
+	1: "LST",
+	2: "LST_LDT",
+}
+var TimeZone_value = map[string]int32{
+	"GMT":     0,
+	"LST":     1,
+	"LST_LDT": 2,
+}
+
+func (x TimeZone) String() string {
+	return proto.EnumName(TimeZone_name, int32(x))
+}
+func (TimeZone) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_demo_f17968dd747845b7, []int{2}
+}
+
+type ProductStatusCode int32
+
+const (
+	ProductStatusCode_OK ProductStatusCode = 0
+	// Noaa doesn't have the product for the station and window
+	ProductStatusCode_NoData ProductStatusCode = 1
+	ProductStatusCode_Failed ProductStatusCode = 2
+	// The station's metadata says it doesn't have the product so Noaa wasn't asked
+	ProductStatusCode_NotAvailable ProductStatusCode = 3
+)
+
+var ProductStatusCode_name = map[int32]string{
+	0: "OK",
+	1: "NoData",
+	2: "Failed",
+	3: "NotAvailable",
+}
+var ProductStatusCode_value = map[string]int32{
+	"OK":           0,
+	"NoData":       1,
+	"Failed":       2,
+	"NotAvailable": 3,
+}
+
+func (x ProductStatusCode) String() string {
+	return proto.EnumName(ProductStatusCode_name, int32(x))
+}
+func (ProductStatusCode) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_demo_f17968dd747845b7, []int{3}
+}
+
+// QualityLevel - Noaa's QA/QC level.  Products without one (e.g. the met products and predictions) are unknown
+type QualityLevel int32
+
+const (
+	QualityLevel_UnknownQuality QualityLevel = 0
+	QualityLevel_Preliminary    QualityLevel = 1
+	QualityLevel_Verified       QualityLevel = 2
+)
+
+var QualityLevel_name = map[int32]string{
+	0: "UnknownQuality",
+	1: "Preliminary",
+	2: "Verified",
+}
+var QualityLevel_value = map[string]int32{
+	"UnknownQuality": 0,
+	"Preliminary":    1,
+	"Verified":       2,
+}
+
+func (x QualityLevel) String() string {
+	return proto.EnumName(QualityLevel_name, int32(x))
+}
+func (QualityLevel) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_demo_f17968dd747845b7, []int{4}
+}
+
+type FailureMode int32
+
+const (
+	FailureMode_FailFast   FailureMode = 0
+	FailureMode_BestEffort FailureMode = 1
+)
+
+var FailureMode_name = map[int32]string{
+	0: "FailFast",
+	1: "BestEffort",
+}
+var FailureMode_value = map[string]int32{
+	"FailFast":   0,
+	"BestEffort": 1,
+}
+
+func (x FailureMode) String() string {
+	return proto.EnumName(FailureMode_name, int32(x))
+}
+func (FailureMode) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_demo_f17968dd747845b7, []int{5}
+}
+
+type QueryMode int32
+
+const (
+	QueryMode_DateRange QueryMode = 0
+	QueryMode_Latest    QueryMode = 1
+	QueryMode_Today     QueryMode = 2
+	QueryMode_Recent    QueryMode = 3
+	QueryMode_Range     QueryMode = 4
+)
+
+var QueryMode_name = map[int32]string{
+	0: "DateRange",
+	1: "Latest",
+	2: "Today",
+	3: "Recent",
+	4: "Range",
+}
+var QueryMode_value = map[string]int32{
+	"DateRange": 0,
+	"Latest":    1,
+	"Today":     2,
+	"Recent":    3,
+	"Range":     4,
+}
+
+func (x QueryMode) String() string {
+	return proto.EnumName(QueryMode_name, int32(x))
+}
+func (QueryMode) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_demo_f17968dd747845b7, []int{6}
 }
 
 // Message Definitions
@@ -128,16 +267,34 @@ type GetDataFromStationsRequest struct {
 	EndTimeEpochInSeconds   int64            `protobuf:"varint,3,opt,name=endTimeEpochInSeconds,proto3" json:"endTimeEpochInSeconds,omitempty"`
 	Datum                   string           `protobuf:"bytes,4,opt,name=datum,proto3" json:"datum,omitempty"`
 	MetricPreference        MetricPreference `protobuf:"varint,5,opt,name=MetricPreference,proto3,enum=MetricPreference" json:"MetricPreference,omitempty"`
-	XXX_NoUnkeyedLiteral    struct{}         `json:"-"`
-	XXX_unrecognized        []byte           `json:"-"`
-	XXX_sizecache           int32            `json:"-"`
+	// How the window is addressed.  The start and end times are only used for DateRange
+	QueryMode QueryMode `protobuf:"varint,6,opt,name=queryMode,proto3,enum=QueryMode" json:"queryMode,omitempty"`
+	// Number of hours before the end time (or now if there isn't one) for the Range query mode
+	RangeInHours int32 `protobuf:"varint,7,opt,name=rangeInHours,proto3" json:"rangeInHours,omitempty"`
+	// Time zone for the times that come back.  Defaults to GMT
+	TimeZone TimeZone `protobuf:"varint,8,opt,name=timeZone,proto3,enum=TimeZone" json:"timeZone,omitempty"`
+	// FailFast errors out on the first product that fails.  BestEffort sends back what worked and says what didn't in the product status
+	FailureMode FailureMode `protobuf:"varint,9,opt,name=failureMode,proto3,enum=FailureMode" json:"failureMode,omitempty"`
+	// Only get these products.  Empty gets all of them
+	Products []DataType `protobuf:"varint,10,rep,packed,name=products,proto3,enum=DataType" json:"products,omitempty"`
+	// Skip the cache and go to Noaa.  What comes back still gets cached
+	BypassCache bool `protobuf:"varint,11,opt,name=bypassCache,proto3" json:"bypassCache,omitempty"`
+	// Units for specific products (e.g. wind in kn and water level in ft).  Everything else is in the metric preference
+	ProductUnits []*ProductUnit `protobuf:"bytes,12,rep,name=productUnits,proto3" json:"productUnits,omitempty"`
+	// Leave out the points that Noaa flagged (e.g. rate of change or max/min exceeded)
+	ExcludeFlagged bool `protobuf:"varint,13,opt,name=excludeFlagged,proto3" json:"excludeFlagged,omitempty"`
+	// Leave out the points that haven't been verified yet
+	ExcludePreliminary   bool     `protobuf:"varint,14,opt,name=excludePreliminary,proto3" json:"excludePreliminary,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *GetDataFromStationsRequest) Reset()         { *m = GetDataFromStationsRequest{} }
 func (m *GetDataFromStationsRequest) String() string { return proto.CompactTextString(m) }
 func (*GetDataFromStationsRequest) ProtoMessage()    {}
 func (*GetDataFromStationsRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_demo_744868870d596b95, []int{0}
+	return fileDescriptor_demo_f17968dd747845b7, []int{0}
 }
 func (m *GetDataFromStationsRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_GetDataFromStationsRequest.Unmarshal(m, b)
@@ -164,255 +321,1070 @@ func (m *GetDataFromStationsRequest) GetArrayOfStationIDs() []string {
 	return nil
 }
 
-func (m *GetDataFromStationsRequest) GetStartTimeEpochInSeconds() int64 {
+func (m *GetDataFromStationsRequest) GetStartTimeEpochInSeconds() int64 {
+	if m != nil {
+		return m.StartTimeEpochInSeconds
+	}
+	return 0
+}
+
+func (m *GetDataFromStationsRequest) GetEndTimeEpochInSeconds() int64 {
+	if m != nil {
+		return m.EndTimeEpochInSeconds
+	}
+	return 0
+}
+
+func (m *GetDataFromStationsRequest) GetDatum() string {
+	if m != nil {
+		return m.Datum
+	}
+	return ""
+}
+
+func (m *GetDataFromStationsRequest) GetMetricPreference() MetricPreference {
+	if m != nil {
+		return m.MetricPreference
+	}
+	return MetricPreference_English
+}
+
+func (m *GetDataFromStationsRequest) GetQueryMode() QueryMode {
+	if m != nil {
+		return m.QueryMode
+	}
+	return QueryMode_DateRange
+}
+
+func (m *GetDataFromStationsRequest) GetRangeInHours() int32 {
+	if m != nil {
+		return m.RangeInHours
+	}
+	return 0
+}
+
+func (m *GetDataFromStationsRequest) GetTimeZone() TimeZone {
+	if m != nil {
+		return m.TimeZone
+	}
+	return TimeZone_GMT
+}
+
+func (m *GetDataFromStationsRequest) GetFailureMode() FailureMode {
+	if m != nil {
+		return m.FailureMode
+	}
+	return FailureMode_FailFast
+}
+
+func (m *GetDataFromStationsRequest) GetProducts() []DataType {
+	if m != nil {
+		return m.Products
+	}
+	return nil
+}
+
+func (m *GetDataFromStationsRequest) GetBypassCache() bool {
+	if m != nil {
+		return m.BypassCache
+	}
+	return false
+}
+
+func (m *GetDataFromStationsRequest) GetProductUnits() []*ProductUnit {
+	if m != nil {
+		return m.ProductUnits
+	}
+	return nil
+}
+
+func (m *GetDataFromStationsRequest) GetExcludeFlagged() bool {
+	if m != nil {
+		return m.ExcludeFlagged
+	}
+	return false
+}
+
+func (m *GetDataFromStationsRequest) GetExcludePreliminary() bool {
+	if m != nil {
+		return m.ExcludePreliminary
+	}
+	return false
+}
+
+// ProductUnit - the unit to send the product back in.  Takes the symbol or name (e.g. kn, knots, ft, degF or mph)
+type ProductUnit struct {
+	Product              DataType `protobuf:"varint,1,opt,name=product,proto3,enum=DataType" json:"product,omitempty"`
+	Unit                 string   `protobuf:"bytes,2,opt,name=unit,proto3" json:"unit,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ProductUnit) Reset()         { *m = ProductUnit{} }
+func (m *ProductUnit) String() string { return proto.CompactTextString(m) }
+func (*ProductUnit) ProtoMessage()    {}
+func (*ProductUnit) Descriptor() ([]byte, []int) {
+	return fileDescriptor_demo_f17968dd747845b7, []int{1}
+}
+func (m *ProductUnit) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ProductUnit.Unmarshal(m, b)
+}
+func (m *ProductUnit) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ProductUnit.Marshal(b, m, deterministic)
+}
+func (dst *ProductUnit) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProductUnit.Merge(dst, src)
+}
+func (m *ProductUnit) XXX_Size() int {
+	return xxx_messageInfo_ProductUnit.Size(m)
+}
+func (m *ProductUnit) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProductUnit.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ProductUnit proto.InternalMessageInfo
+
+func (m *ProductUnit) GetProduct() DataType {
+	if m != nil {
+		return m.Product
+	}
+	return DataType_WaterLevel
+}
+
+func (m *ProductUnit) GetUnit() string {
+	if m != nil {
+		return m.Unit
+	}
+	return ""
+}
+
+type GetDataFromStationsResponse struct {
+	MapOfStationData     map[string]*Station `protobuf:"bytes,1,rep,name=mapOfStationData,proto3" json:"mapOfStationData,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
+	XXX_unrecognized     []byte              `json:"-"`
+	XXX_sizecache        int32               `json:"-"`
+}
+
+func (m *GetDataFromStationsResponse) Reset()         { *m = GetDataFromStationsResponse{} }
+func (m *GetDataFromStationsResponse) String() string { return proto.CompactTextString(m) }
+func (*GetDataFromStationsResponse) ProtoMessage()    {}
+func (*GetDataFromStationsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_demo_f17968dd747845b7, []int{2}
+}
+func (m *GetDataFromStationsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetDataFromStationsResponse.Unmarshal(m, b)
+}
+func (m *GetDataFromStationsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetDataFromStationsResponse.Marshal(b, m, deterministic)
+}
+func (dst *GetDataFromStationsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetDataFromStationsResponse.Merge(dst, src)
+}
+func (m *GetDataFromStationsResponse) XXX_Size() int {
+	return xxx_messageInfo_GetDataFromStationsResponse.Size(m)
+}
+func (m *GetDataFromStationsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetDataFromStationsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetDataFromStationsResponse proto.InternalMessageInfo
+
+func (m *GetDataFromStationsResponse) GetMapOfStationData() map[string]*Station {
+	if m != nil {
+		return m.MapOfStationData
+	}
+	return nil
+}
+
+type GetNearestStationsRequest struct {
+	Latitude  float64 `protobuf:"fixed64,1,opt,name=latitude,proto3" json:"latitude,omitempty"`
+	Longitude float64 `protobuf:"fixed64,2,opt,name=longitude,proto3" json:"longitude,omitempty"`
+	// Defaults to 10
+	Limit int32 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	// Only stations that have every one of these products
+	Products             []DataType `protobuf:"varint,4,rep,packed,name=products,proto3,enum=DataType" json:"products,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *GetNearestStationsRequest) Reset()         { *m = GetNearestStationsRequest{} }
+func (m *GetNearestStationsRequest) String() string { return proto.CompactTextString(m) }
+func (*GetNearestStationsRequest) ProtoMessage()    {}
+func (*GetNearestStationsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_demo_f17968dd747845b7, []int{3}
+}
+func (m *GetNearestStationsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetNearestStationsRequest.Unmarshal(m, b)
+}
+func (m *GetNearestStationsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetNearestStationsRequest.Marshal(b, m, deterministic)
+}
+func (dst *GetNearestStationsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetNearestStationsRequest.Merge(dst, src)
+}
+func (m *GetNearestStationsRequest) XXX_Size() int {
+	return xxx_messageInfo_GetNearestStationsRequest.Size(m)
+}
+func (m *GetNearestStationsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetNearestStationsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetNearestStationsRequest proto.InternalMessageInfo
+
+func (m *GetNearestStationsRequest) GetLatitude() float64 {
+	if m != nil {
+		return m.Latitude
+	}
+	return 0
+}
+
+func (m *GetNearestStationsRequest) GetLongitude() float64 {
+	if m != nil {
+		return m.Longitude
+	}
+	return 0
+}
+
+func (m *GetNearestStationsRequest) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+func (m *GetNearestStationsRequest) GetProducts() []DataType {
+	if m != nil {
+		return m.Products
+	}
+	return nil
+}
+
+// GetStationsInBoxRequest - a minimum longitude bigger than the maximum is a box that crosses the antimeridian
+type GetStationsInBoxRequest struct {
+	MinLatitude  float64 `protobuf:"fixed64,1,opt,name=minLatitude,proto3" json:"minLatitude,omitempty"`
+	MinLongitude float64 `protobuf:"fixed64,2,opt,name=minLongitude,proto3" json:"minLongitude,omitempty"`
+	MaxLatitude  float64 `protobuf:"fixed64,3,opt,name=maxLatitude,proto3" json:"maxLatitude,omitempty"`
+	MaxLongitude float64 `protobuf:"fixed64,4,opt,name=maxLongitude,proto3" json:"maxLongitude,omitempty"`
+	// Only stations that have every one of these products
+	Products             []DataType `protobuf:"varint,5,rep,packed,name=products,proto3,enum=DataType" json:"products,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *GetStationsInBoxRequest) Reset()         { *m = GetStationsInBoxRequest{} }
+func (m *GetStationsInBoxRequest) String() string { return proto.CompactTextString(m) }
+func (*GetStationsInBoxRequest) ProtoMessage()    {}
+func (*GetStationsInBoxRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_demo_f17968dd747845b7, []int{4}
+}
+func (m *GetStationsInBoxRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetStationsInBoxRequest.Unmarshal(m, b)
+}
+func (m *GetStationsInBoxRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetStationsInBoxRequest.Marshal(b, m, deterministic)
+}
+func (dst *GetStationsInBoxRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetStationsInBoxRequest.Merge(dst, src)
+}
+func (m *GetStationsInBoxRequest) XXX_Size() int {
+	return xxx_messageInfo_GetStationsInBoxRequest.Size(m)
+}
+func (m *GetStationsInBoxRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetStationsInBoxRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetStationsInBoxRequest proto.InternalMessageInfo
+
+func (m *GetStationsInBoxRequest) GetMinLatitude() float64 {
+	if m != nil {
+		return m.MinLatitude
+	}
+	return 0
+}
+
+func (m *GetStationsInBoxRequest) GetMinLongitude() float64 {
+	if m != nil {
+		return m.MinLongitude
+	}
+	return 0
+}
+
+func (m *GetStationsInBoxRequest) GetMaxLatitude() float64 {
+	if m != nil {
+		return m.MaxLatitude
+	}
+	return 0
+}
+
+func (m *GetStationsInBoxRequest) GetMaxLongitude() float64 {
+	if m != nil {
+		return m.MaxLongitude
+	}
+	return 0
+}
+
+func (m *GetStationsInBoxRequest) GetProducts() []DataType {
+	if m != nil {
+		return m.Products
+	}
+	return nil
+}
+
+type SearchStationsRequest struct {
+	// Station name (typos are OK) or ID
+	Query string `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	// Defaults to 10
+	Limit int32 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	// Only stations that have every one of these products
+	Products             []DataType `protobuf:"varint,3,rep,packed,name=products,proto3,enum=DataType" json:"products,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *SearchStationsRequest) Reset()         { *m = SearchStationsRequest{} }
+func (m *SearchStationsRequest) String() string { return proto.CompactTextString(m) }
+func (*SearchStationsRequest) ProtoMessage()    {}
+func (*SearchStationsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_demo_f17968dd747845b7, []int{5}
+}
+func (m *SearchStationsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SearchStationsRequest.Unmarshal(m, b)
+}
+func (m *SearchStationsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SearchStationsRequest.Marshal(b, m, deterministic)
+}
+func (dst *SearchStationsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SearchStationsRequest.Merge(dst, src)
+}
+func (m *SearchStationsRequest) XXX_Size() int {
+	return xxx_messageInfo_SearchStationsRequest.Size(m)
+}
+func (m *SearchStationsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SearchStationsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SearchStationsRequest proto.InternalMessageInfo
+
+func (m *SearchStationsRequest) GetQuery() string {
+	if m != nil {
+		return m.Query
+	}
+	return ""
+}
+
+func (m *SearchStationsRequest) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+func (m *SearchStationsRequest) GetProducts() []DataType {
+	if m != nil {
+		return m.Products
+	}
+	return nil
+}
+
+type StationCatalogResponse struct {
+	Stations             []*CatalogStation `protobuf:"bytes,1,rep,name=stations,proto3" json:"stations,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *StationCatalogResponse) Reset()         { *m = StationCatalogResponse{} }
+func (m *StationCatalogResponse) String() string { return proto.CompactTextString(m) }
+func (*StationCatalogResponse) ProtoMessage()    {}
+func (*StationCatalogResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_demo_f17968dd747845b7, []int{6}
+}
+func (m *StationCatalogResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StationCatalogResponse.Unmarshal(m, b)
+}
+func (m *StationCatalogResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StationCatalogResponse.Marshal(b, m, deterministic)
+}
+func (dst *StationCatalogResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StationCatalogResponse.Merge(dst, src)
+}
+func (m *StationCatalogResponse) XXX_Size() int {
+	return xxx_messageInfo_StationCatalogResponse.Size(m)
+}
+func (m *StationCatalogResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_StationCatalogResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StationCatalogResponse proto.InternalMessageInfo
+
+func (m *StationCatalogResponse) GetStations() []*CatalogStation {
+	if m != nil {
+		return m.Stations
+	}
+	return nil
+}
+
+// CatalogStation - a station from the catalog and the products it has
+type CatalogStation struct {
+	StationID string     `protobuf:"bytes,1,opt,name=stationID,proto3" json:"stationID,omitempty"`
+	Name      string     `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	State     string     `protobuf:"bytes,3,opt,name=state,proto3" json:"state,omitempty"`
+	Latitude  float64    `protobuf:"fixed64,4,opt,name=latitude,proto3" json:"latitude,omitempty"`
+	Longitude float64    `protobuf:"fixed64,5,opt,name=longitude,proto3" json:"longitude,omitempty"`
+	Products  []DataType `protobuf:"varint,6,rep,packed,name=products,proto3,enum=DataType" json:"products,omitempty"`
+	// Only set for the nearest stations
+	DistanceInKm float64 `protobuf:"fixed64,7,opt,name=distanceInKm,proto3" json:"distanceInKm,omitempty"`
+	// How well the name matched from 0 to 1.  Only set for searches
+	Score                float64  `protobuf:"fixed64,8,opt,name=score,proto3" json:"score,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CatalogStation) Reset()         { *m = CatalogStation{} }
+func (m *CatalogStation) String() string { return proto.CompactTextString(m) }
+func (*CatalogStation) ProtoMessage()    {}
+func (*CatalogStation) Descriptor() ([]byte, []int) {
+	return fileDescriptor_demo_f17968dd747845b7, []int{7}
+}
+func (m *CatalogStation) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CatalogStation.Unmarshal(m, b)
+}
+func (m *CatalogStation) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CatalogStation.Marshal(b, m, deterministic)
+}
+func (dst *CatalogStation) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CatalogStation.Merge(dst, src)
+}
+func (m *CatalogStation) XXX_Size() int {
+	return xxx_messageInfo_CatalogStation.Size(m)
+}
+func (m *CatalogStation) XXX_DiscardUnknown() {
+	xxx_messageInfo_CatalogStation.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CatalogStation proto.InternalMessageInfo
+
+func (m *CatalogStation) GetStationID() string {
+	if m != nil {
+		return m.StationID
+	}
+	return ""
+}
+
+func (m *CatalogStation) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *CatalogStation) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
+}
+
+func (m *CatalogStation) GetLatitude() float64 {
+	if m != nil {
+		return m.Latitude
+	}
+	return 0
+}
+
+func (m *CatalogStation) GetLongitude() float64 {
+	if m != nil {
+		return m.Longitude
+	}
+	return 0
+}
+
+func (m *CatalogStation) GetProducts() []DataType {
+	if m != nil {
+		return m.Products
+	}
+	return nil
+}
+
+func (m *CatalogStation) GetDistanceInKm() float64 {
+	if m != nil {
+		return m.DistanceInKm
+	}
+	return 0
+}
+
+func (m *CatalogStation) GetScore() float64 {
+	if m != nil {
+		return m.Score
+	}
+	return 0
+}
+
+type ProductDataValues struct {
+	Metadata *Metadata `protobuf:"bytes,1,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Data     []*Data   `protobuf:"bytes,2,rep,name=data,proto3" json:"data,omitempty"`
+	DataType DataType  `protobuf:"varint,3,opt,name=dataType,proto3,enum=DataType" json:"dataType,omitempty"`
+	// Same points as data but already parsed (times, numbers and flags)
+	TypedData []*TypedData `protobuf:"bytes,4,rep,name=typedData,proto3" json:"typedData,omitempty"`
+	// Noaa couldn't be reached (or the service is offline) so this is the data that was on hand
+	Stale bool `protobuf:"varint,5,opt,name=stale,proto3" json:"stale,omitempty"`
+	// How long ago the stale data came from Noaa
+	AgeInSeconds int64 `protobuf:"varint,6,opt,name=ageInSeconds,proto3" json:"ageInSeconds,omitempty"`
+	// Unit the values are in (e.g. ft, kn or degC)
+	Unit string `protobuf:"bytes,7,opt,name=unit,proto3" json:"unit,omitempty"`
+	// Noaa's message when it turned the request down and sent nothing back
+	NoDataReason         string   `protobuf:"bytes,8,opt,name=noDataReason,proto3" json:"noDataReason,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ProductDataValues) Reset()         { *m = ProductDataValues{} }
+func (m *ProductDataValues) String() string { return proto.CompactTextString(m) }
+func (*ProductDataValues) ProtoMessage()    {}
+func (*ProductDataValues) Descriptor() ([]byte, []int) {
+	return fileDescriptor_demo_f17968dd747845b7, []int{8}
+}
+func (m *ProductDataValues) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ProductDataValues.Unmarshal(m, b)
+}
+func (m *ProductDataValues) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ProductDataValues.Marshal(b, m, deterministic)
+}
+func (dst *ProductDataValues) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProductDataValues.Merge(dst, src)
+}
+func (m *ProductDataValues) XXX_Size() int {
+	return xxx_messageInfo_ProductDataValues.Size(m)
+}
+func (m *ProductDataValues) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProductDataValues.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ProductDataValues proto.InternalMessageInfo
+
+func (m *ProductDataValues) GetMetadata() *Metadata {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+func (m *ProductDataValues) GetData() []*Data {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *ProductDataValues) GetDataType() DataType {
+	if m != nil {
+		return m.DataType
+	}
+	return DataType_WaterLevel
+}
+
+func (m *ProductDataValues) GetTypedData() []*TypedData {
+	if m != nil {
+		return m.TypedData
+	}
+	return nil
+}
+
+func (m *ProductDataValues) GetStale() bool {
+	if m != nil {
+		return m.Stale
+	}
+	return false
+}
+
+func (m *ProductDataValues) GetAgeInSeconds() int64 {
+	if m != nil {
+		return m.AgeInSeconds
+	}
+	return 0
+}
+
+func (m *ProductDataValues) GetUnit() string {
+	if m != nil {
+		return m.Unit
+	}
+	return ""
+}
+
+func (m *ProductDataValues) GetNoDataReason() string {
+	if m != nil {
+		return m.NoDataReason
+	}
+	return ""
+}
+
+type Metadata struct {
+	Id   string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Lon  string `protobuf:"bytes,3,opt,name=lon,proto3" json:"lon,omitempty"`
+	Lat  string `protobuf:"bytes,4,opt,name=lat,proto3" json:"lat,omitempty"`
+	// Time zone the times are in (gmt, lst or lst_ldt)
+	TimeZone string `protobuf:"bytes,5,opt,name=timeZone,proto3" json:"timeZone,omitempty"`
+	// Resolved zone for the station (e.g. UTC, America/New_York or EST)
+	TimeZoneName         string   `protobuf:"bytes,6,opt,name=timeZoneName,proto3" json:"timeZoneName,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Metadata) Reset()         { *m = Metadata{} }
+func (m *Metadata) String() string { return proto.CompactTextString(m) }
+func (*Metadata) ProtoMessage()    {}
+func (*Metadata) Descriptor() ([]byte, []int) {
+	return fileDescriptor_demo_f17968dd747845b7, []int{9}
+}
+func (m *Metadata) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Metadata.Unmarshal(m, b)
+}
+func (m *Metadata) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Metadata.Marshal(b, m, deterministic)
+}
+func (dst *Metadata) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Metadata.Merge(dst, src)
+}
+func (m *Metadata) XXX_Size() int {
+	return xxx_messageInfo_Metadata.Size(m)
+}
+func (m *Metadata) XXX_DiscardUnknown() {
+	xxx_messageInfo_Metadata.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Metadata proto.InternalMessageInfo
+
+func (m *Metadata) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *Metadata) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Metadata) GetLon() string {
+	if m != nil {
+		return m.Lon
+	}
+	return ""
+}
+
+func (m *Metadata) GetLat() string {
+	if m != nil {
+		return m.Lat
+	}
+	return ""
+}
+
+func (m *Metadata) GetTimeZone() string {
+	if m != nil {
+		return m.TimeZone
+	}
+	return ""
+}
+
+func (m *Metadata) GetTimeZoneName() string {
+	if m != nil {
+		return m.TimeZoneName
+	}
+	return ""
+}
+
+type Data struct {
+	T string `protobuf:"bytes,1,opt,name=t,proto3" json:"t,omitempty"`
+	V string `protobuf:"bytes,2,opt,name=v,proto3" json:"v,omitempty"`
+	F string `protobuf:"bytes,3,opt,name=f,proto3" json:"f,omitempty"`
+	// Sigma (water levels), speed (wind and currents) or salinity
+	S string `protobuf:"bytes,4,opt,name=s,proto3" json:"s,omitempty"`
+	// Direction in degrees true (wind and currents)
+	D string `protobuf:"bytes,5,opt,name=d,proto3" json:"d,omitempty"`
+	// Compass direction (wind)
+	Dr string `protobuf:"bytes,6,opt,name=dr,proto3" json:"dr,omitempty"`
+	// Gust (wind) or specific gravity (salinity)
+	G string `protobuf:"bytes,7,opt,name=g,proto3" json:"g,omitempty"`
+	// Quality - p for preliminary and v for verified
+	Q string `protobuf:"bytes,8,opt,name=q,proto3" json:"q,omitempty"`
+	// Tide type for high/low and hilo predictions (HH, H, L, LL) or flood/ebb/slack for current predictions
+	Ty string `protobuf:"bytes,9,opt,name=ty,proto3" json:"ty,omitempty"`
+	// Bin number (currents)
+	B string `protobuf:"bytes,10,opt,name=b,proto3" json:"b,omitempty"`
+	// Datum name (datums)
+	N string `protobuf:"bytes,11,opt,name=n,proto3" json:"n,omitempty"`
+	// Any remaining named values that don't fit above (e.g. every datum on a monthly mean)
+	Values               map[string]string `protobuf:"bytes,12,rep,name=values,proto3" json:"values,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *Data) Reset()         { *m = Data{} }
+func (m *Data) String() string { return proto.CompactTextString(m) }
+func (*Data) ProtoMessage()    {}
+func (*Data) Descriptor() ([]byte, []int) {
+	return fileDescriptor_demo_f17968dd747845b7, []int{10}
+}
+func (m *Data) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Data.Unmarshal(m, b)
+}
+func (m *Data) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Data.Marshal(b, m, deterministic)
+}
+func (dst *Data) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Data.Merge(dst, src)
+}
+func (m *Data) XXX_Size() int {
+	return xxx_messageInfo_Data.Size(m)
+}
+func (m *Data) XXX_DiscardUnknown() {
+	xxx_messageInfo_Data.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Data proto.InternalMessageInfo
+
+func (m *Data) GetT() string {
+	if m != nil {
+		return m.T
+	}
+	return ""
+}
+
+func (m *Data) GetV() string {
+	if m != nil {
+		return m.V
+	}
+	return ""
+}
+
+func (m *Data) GetF() string {
+	if m != nil {
+		return m.F
+	}
+	return ""
+}
+
+func (m *Data) GetS() string {
+	if m != nil {
+		return m.S
+	}
+	return ""
+}
+
+func (m *Data) GetD() string {
+	if m != nil {
+		return m.D
+	}
+	return ""
+}
+
+func (m *Data) GetDr() string {
+	if m != nil {
+		return m.Dr
+	}
+	return ""
+}
+
+func (m *Data) GetG() string {
+	if m != nil {
+		return m.G
+	}
+	return ""
+}
+
+func (m *Data) GetQ() string {
+	if m != nil {
+		return m.Q
+	}
+	return ""
+}
+
+func (m *Data) GetTy() string {
 	if m != nil {
-		return m.StartTimeEpochInSeconds
+		return m.Ty
 	}
-	return 0
+	return ""
 }
 
-func (m *GetDataFromStationsRequest) GetEndTimeEpochInSeconds() int64 {
+func (m *Data) GetB() string {
 	if m != nil {
-		return m.EndTimeEpochInSeconds
+		return m.B
 	}
-	return 0
+	return ""
 }
 
-func (m *GetDataFromStationsRequest) GetDatum() string {
+func (m *Data) GetN() string {
 	if m != nil {
-		return m.Datum
+		return m.N
 	}
 	return ""
 }
 
-func (m *GetDataFromStationsRequest) GetMetricPreference() MetricPreference {
+func (m *Data) GetValues() map[string]string {
 	if m != nil {
-		return m.MetricPreference
+		return m.Values
 	}
-	return MetricPreference_English
+	return nil
 }
 
-type GetDataFromStationsResponse struct {
-	MapOfStationData     map[string]*Station `protobuf:"bytes,1,rep,name=mapOfStationData,proto3" json:"mapOfStationData,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
-	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
-	XXX_unrecognized     []byte              `json:"-"`
-	XXX_sizecache        int32               `json:"-"`
+// TypedData - parsed version of Data so callers don't have to parse strings
+type TypedData struct {
+	TimeEpochInSeconds int64 `protobuf:"varint,1,opt,name=timeEpochInSeconds,proto3" json:"timeEpochInSeconds,omitempty"`
+	// Primary value for the product (e.g. water level, wind speed, salinity)
+	Value float64 `protobuf:"fixed64,2,opt,name=value,proto3" json:"value,omitempty"`
+	// True when Noaa didn't send a value - value will be 0
+	Missing bool   `protobuf:"varint,3,opt,name=missing,proto3" json:"missing,omitempty"`
+	Flags   []bool `protobuf:"varint,4,rep,packed,name=flags,proto3" json:"flags,omitempty"`
+	// Secondary numeric values by name (e.g. sigma, direction, gust, bin)
+	Values map[string]float64 `protobuf:"bytes,5,rep,name=values,proto3" json:"values,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"fixed64,2,opt,name=value,proto3"`
+	// Tide or current type (e.g. HH, L, flood)
+	Type string `protobuf:"bytes,6,opt,name=type,proto3" json:"type,omitempty"`
+	// Compass direction (wind)
+	CompassDirection string `protobuf:"bytes,7,opt,name=compassDirection,proto3" json:"compassDirection,omitempty"`
+	// Datum name (datums)
+	Name string `protobuf:"bytes,8,opt,name=name,proto3" json:"name,omitempty"`
+	// Quality - p for preliminary and v for verified
+	Quality string `protobuf:"bytes,9,opt,name=quality,proto3" json:"quality,omitempty"`
+	// Offset from UTC at this time for the requested time zone.  This changes across DST transitions for lst_ldt
+	UtcOffsetInSeconds int32 `protobuf:"varint,10,opt,name=utcOffsetInSeconds,proto3" json:"utcOffsetInSeconds,omitempty"`
+	// The flags by name.  Which ones are used depends on the product
+	QualityFlags *QualityFlags `protobuf:"bytes,11,opt,name=qualityFlags,proto3" json:"qualityFlags,omitempty"`
+	// Decoded from the quality
+	QualityLevel         QualityLevel `protobuf:"varint,12,opt,name=qualityLevel,proto3,enum=QualityLevel" json:"qualityLevel,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
 }
 
-func (m *GetDataFromStationsResponse) Reset()         { *m = GetDataFromStationsResponse{} }
-func (m *GetDataFromStationsResponse) String() string { return proto.CompactTextString(m) }
-func (*GetDataFromStationsResponse) ProtoMessage()    {}
-func (*GetDataFromStationsResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_demo_744868870d596b95, []int{1}
+func (m *TypedData) Reset()         { *m = TypedData{} }
+func (m *TypedData) String() string { return proto.CompactTextString(m) }
+func (*TypedData) ProtoMessage()    {}
+func (*TypedData) Descriptor() ([]byte, []int) {
+	return fileDescriptor_demo_f17968dd747845b7, []int{11}
 }
-func (m *GetDataFromStationsResponse) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_GetDataFromStationsResponse.Unmarshal(m, b)
+func (m *TypedData) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TypedData.Unmarshal(m, b)
 }
-func (m *GetDataFromStationsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_GetDataFromStationsResponse.Marshal(b, m, deterministic)
+func (m *TypedData) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TypedData.Marshal(b, m, deterministic)
 }
-func (dst *GetDataFromStationsResponse) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_GetDataFromStationsResponse.Merge(dst, src)
+func (dst *TypedData) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TypedData.Merge(dst, src)
 }
-func (m *GetDataFromStationsResponse) XXX_Size() int {
-	return xxx_messageInfo_GetDataFromStationsResponse.Size(m)
+func (m *TypedData) XXX_Size() int {
+	return xxx_messageInfo_TypedData.Size(m)
 }
-func (m *GetDataFromStationsResponse) XXX_DiscardUnknown() {
-	xxx_messageInfo_GetDataFromStationsResponse.DiscardUnknown(m)
+func (m *TypedData) XXX_DiscardUnknown() {
+	xxx_messageInfo_TypedData.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_GetDataFromStationsResponse proto.InternalMessageInfo
+var xxx_messageInfo_TypedData proto.InternalMessageInfo
 
-func (m *GetDataFromStationsResponse) GetMapOfStationData() map[string]*Station {
+func (m *TypedData) GetTimeEpochInSeconds() int64 {
 	if m != nil {
-		return m.MapOfStationData
+		return m.TimeEpochInSeconds
 	}
-	return nil
+	return 0
 }
 
-type ProductDataValues struct {
-	Metadata             *Metadata `protobuf:"bytes,1,opt,name=metadata,proto3" json:"metadata,omitempty"`
-	Data                 []*Data   `protobuf:"bytes,2,rep,name=data,proto3" json:"data,omitempty"`
-	DataType             DataType  `protobuf:"varint,3,opt,name=dataType,proto3,enum=DataType" json:"dataType,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
-	XXX_unrecognized     []byte    `json:"-"`
-	XXX_sizecache        int32     `json:"-"`
+func (m *TypedData) GetValue() float64 {
+	if m != nil {
+		return m.Value
+	}
+	return 0
 }
 
-func (m *ProductDataValues) Reset()         { *m = ProductDataValues{} }
-func (m *ProductDataValues) String() string { return proto.CompactTextString(m) }
-func (*ProductDataValues) ProtoMessage()    {}
-func (*ProductDataValues) Descriptor() ([]byte, []int) {
-	return fileDescriptor_demo_744868870d596b95, []int{2}
-}
-func (m *ProductDataValues) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_ProductDataValues.Unmarshal(m, b)
-}
-func (m *ProductDataValues) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_ProductDataValues.Marshal(b, m, deterministic)
-}
-func (dst *ProductDataValues) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ProductDataValues.Merge(dst, src)
-}
-func (m *ProductDataValues) XXX_Size() int {
-	return xxx_messageInfo_ProductDataValues.Size(m)
-}
-func (m *ProductDataValues) XXX_DiscardUnknown() {
-	xxx_messageInfo_ProductDataValues.DiscardUnknown(m)
+func (m *TypedData) GetMissing() bool {
+	if m != nil {
+		return m.Missing
+	}
+	return false
 }
 
-var xxx_messageInfo_ProductDataValues proto.InternalMessageInfo
-
-func (m *ProductDataValues) GetMetadata() *Metadata {
+func (m *TypedData) GetFlags() []bool {
 	if m != nil {
-		return m.Metadata
+		return m.Flags
 	}
 	return nil
 }
 
-func (m *ProductDataValues) GetData() []*Data {
+func (m *TypedData) GetValues() map[string]float64 {
 	if m != nil {
-		return m.Data
+		return m.Values
 	}
 	return nil
 }
 
-func (m *ProductDataValues) GetDataType() DataType {
+func (m *TypedData) GetType() string {
 	if m != nil {
-		return m.DataType
+		return m.Type
 	}
-	return DataType_WaterLevel
+	return ""
 }
 
-type Metadata struct {
-	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Name                 string   `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	Lon                  string   `protobuf:"bytes,3,opt,name=lon,proto3" json:"lon,omitempty"`
-	Lat                  string   `protobuf:"bytes,4,opt,name=lat,proto3" json:"lat,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+func (m *TypedData) GetCompassDirection() string {
+	if m != nil {
+		return m.CompassDirection
+	}
+	return ""
 }
 
-func (m *Metadata) Reset()         { *m = Metadata{} }
-func (m *Metadata) String() string { return proto.CompactTextString(m) }
-func (*Metadata) ProtoMessage()    {}
-func (*Metadata) Descriptor() ([]byte, []int) {
-	return fileDescriptor_demo_744868870d596b95, []int{3}
-}
-func (m *Metadata) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_Metadata.Unmarshal(m, b)
-}
-func (m *Metadata) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_Metadata.Marshal(b, m, deterministic)
-}
-func (dst *Metadata) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_Metadata.Merge(dst, src)
-}
-func (m *Metadata) XXX_Size() int {
-	return xxx_messageInfo_Metadata.Size(m)
-}
-func (m *Metadata) XXX_DiscardUnknown() {
-	xxx_messageInfo_Metadata.DiscardUnknown(m)
+func (m *TypedData) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
 }
 
-var xxx_messageInfo_Metadata proto.InternalMessageInfo
-
-func (m *Metadata) GetId() string {
+func (m *TypedData) GetQuality() string {
 	if m != nil {
-		return m.Id
+		return m.Quality
 	}
 	return ""
 }
 
-func (m *Metadata) GetName() string {
+func (m *TypedData) GetUtcOffsetInSeconds() int32 {
 	if m != nil {
-		return m.Name
+		return m.UtcOffsetInSeconds
 	}
-	return ""
+	return 0
 }
 
-func (m *Metadata) GetLon() string {
+func (m *TypedData) GetQualityFlags() *QualityFlags {
 	if m != nil {
-		return m.Lon
+		return m.QualityFlags
 	}
-	return ""
+	return nil
 }
 
-func (m *Metadata) GetLat() string {
+func (m *TypedData) GetQualityLevel() QualityLevel {
 	if m != nil {
-		return m.Lat
+		return m.QualityLevel
 	}
-	return ""
+	return QualityLevel_UnknownQuality
 }
 
-type Data struct {
-	T                    string   `protobuf:"bytes,1,opt,name=t,proto3" json:"t,omitempty"`
-	V                    string   `protobuf:"bytes,2,opt,name=v,proto3" json:"v,omitempty"`
-	F                    string   `protobuf:"bytes,3,opt,name=f,proto3" json:"f,omitempty"`
+// QualityFlags - what Noaa's data flags mean.  Water levels and air gap have outliers (inferred once verified), flat tolerance, rate of change and limit exceeded.  Hourly heights and high/lows have inferred and limit exceeded.  The met products have max exceeded, min exceeded (not wind) and rate of change
+type QualityFlags struct {
+	// Some of the 1 second samples were outside a 3 sigma band about the mean
+	Outliers bool `protobuf:"varint,1,opt,name=outliers,proto3" json:"outliers,omitempty"`
+	// The value was inferred instead of measured
+	Inferred bool `protobuf:"varint,2,opt,name=inferred,proto3" json:"inferred,omitempty"`
+	// Flat tolerance limit exceeded
+	FlatTolerance bool `protobuf:"varint,3,opt,name=flatTolerance,proto3" json:"flatTolerance,omitempty"`
+	// Rate of change tolerance limit exceeded
+	RateOfChange bool `protobuf:"varint,4,opt,name=rateOfChange,proto3" json:"rateOfChange,omitempty"`
+	// Maximum or minimum expected water level exceeded
+	LimitExceeded bool `protobuf:"varint,5,opt,name=limitExceeded,proto3" json:"limitExceeded,omitempty"`
+	// Maximum expected value exceeded
+	MaxExceeded bool `protobuf:"varint,6,opt,name=maxExceeded,proto3" json:"maxExceeded,omitempty"`
+	// Minimum expected value exceeded
+	MinExceeded          bool     `protobuf:"varint,7,opt,name=minExceeded,proto3" json:"minExceeded,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *Data) Reset()         { *m = Data{} }
-func (m *Data) String() string { return proto.CompactTextString(m) }
-func (*Data) ProtoMessage()    {}
-func (*Data) Descriptor() ([]byte, []int) {
-	return fileDescriptor_demo_744868870d596b95, []int{4}
+func (m *QualityFlags) Reset()         { *m = QualityFlags{} }
+func (m *QualityFlags) String() string { return proto.CompactTextString(m) }
+func (*QualityFlags) ProtoMessage()    {}
+func (*QualityFlags) Descriptor() ([]byte, []int) {
+	return fileDescriptor_demo_f17968dd747845b7, []int{12}
 }
-func (m *Data) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_Data.Unmarshal(m, b)
+func (m *QualityFlags) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_QualityFlags.Unmarshal(m, b)
 }
-func (m *Data) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_Data.Marshal(b, m, deterministic)
+func (m *QualityFlags) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_QualityFlags.Marshal(b, m, deterministic)
 }
-func (dst *Data) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_Data.Merge(dst, src)
+func (dst *QualityFlags) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QualityFlags.Merge(dst, src)
 }
-func (m *Data) XXX_Size() int {
-	return xxx_messageInfo_Data.Size(m)
+func (m *QualityFlags) XXX_Size() int {
+	return xxx_messageInfo_QualityFlags.Size(m)
 }
-func (m *Data) XXX_DiscardUnknown() {
-	xxx_messageInfo_Data.DiscardUnknown(m)
+func (m *QualityFlags) XXX_DiscardUnknown() {
+	xxx_messageInfo_QualityFlags.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_Data proto.InternalMessageInfo
+var xxx_messageInfo_QualityFlags proto.InternalMessageInfo
 
-func (m *Data) GetT() string {
+func (m *QualityFlags) GetOutliers() bool {
 	if m != nil {
-		return m.T
+		return m.Outliers
 	}
-	return ""
+	return false
 }
 
-func (m *Data) GetV() string {
+func (m *QualityFlags) GetInferred() bool {
 	if m != nil {
-		return m.V
+		return m.Inferred
 	}
-	return ""
+	return false
 }
 
-func (m *Data) GetF() string {
+func (m *QualityFlags) GetFlatTolerance() bool {
 	if m != nil {
-		return m.F
+		return m.FlatTolerance
 	}
-	return ""
+	return false
+}
+
+func (m *QualityFlags) GetRateOfChange() bool {
+	if m != nil {
+		return m.RateOfChange
+	}
+	return false
+}
+
+func (m *QualityFlags) GetLimitExceeded() bool {
+	if m != nil {
+		return m.LimitExceeded
+	}
+	return false
+}
+
+func (m *QualityFlags) GetMaxExceeded() bool {
+	if m != nil {
+		return m.MaxExceeded
+	}
+	return false
+}
+
+func (m *QualityFlags) GetMinExceeded() bool {
+	if m != nil {
+		return m.MinExceeded
+	}
+	return false
 }
 
 type Station struct {
-	StationID            string                        `protobuf:"bytes,1,opt,name=stationID,proto3" json:"stationID,omitempty"`
-	ProductData          map[string]*ProductDataValues `protobuf:"bytes,2,rep,name=productData,proto3" json:"productData,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
-	XXX_NoUnkeyedLiteral struct{}                      `json:"-"`
-	XXX_unrecognized     []byte                        `json:"-"`
-	XXX_sizecache        int32                         `json:"-"`
+	StationID   string                        `protobuf:"bytes,1,opt,name=stationID,proto3" json:"stationID,omitempty"`
+	ProductData map[string]*ProductDataValues `protobuf:"bytes,2,rep,name=productData,proto3" json:"productData,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// Status of every product - keyed the same as productData.  Failed products won't be in productData
+	ProductStatus        map[string]*ProductStatus `protobuf:"bytes,3,rep,name=productStatus,proto3" json:"productStatus,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}                  `json:"-"`
+	XXX_unrecognized     []byte                    `json:"-"`
+	XXX_sizecache        int32                     `json:"-"`
 }
 
 func (m *Station) Reset()         { *m = Station{} }
 func (m *Station) String() string { return proto.CompactTextString(m) }
 func (*Station) ProtoMessage()    {}
 func (*Station) Descriptor() ([]byte, []int) {
-	return fileDescriptor_demo_744868870d596b95, []int{5}
+	return fileDescriptor_demo_f17968dd747845b7, []int{13}
 }
 func (m *Station) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Station.Unmarshal(m, b)
@@ -446,17 +1418,97 @@ func (m *Station) GetProductData() map[string]*ProductDataValues {
 	return nil
 }
 
+func (m *Station) GetProductStatus() map[string]*ProductStatus {
+	if m != nil {
+		return m.ProductStatus
+	}
+	return nil
+}
+
+// ProductStatus - how the call for a product went
+type ProductStatus struct {
+	DataType DataType          `protobuf:"varint,1,opt,name=dataType,proto3,enum=DataType" json:"dataType,omitempty"`
+	Status   ProductStatusCode `protobuf:"varint,2,opt,name=status,proto3,enum=ProductStatusCode" json:"status,omitempty"`
+	// Why it failed (or why the data is stale)
+	Reason               string   `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ProductStatus) Reset()         { *m = ProductStatus{} }
+func (m *ProductStatus) String() string { return proto.CompactTextString(m) }
+func (*ProductStatus) ProtoMessage()    {}
+func (*ProductStatus) Descriptor() ([]byte, []int) {
+	return fileDescriptor_demo_f17968dd747845b7, []int{14}
+}
+func (m *ProductStatus) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ProductStatus.Unmarshal(m, b)
+}
+func (m *ProductStatus) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ProductStatus.Marshal(b, m, deterministic)
+}
+func (dst *ProductStatus) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProductStatus.Merge(dst, src)
+}
+func (m *ProductStatus) XXX_Size() int {
+	return xxx_messageInfo_ProductStatus.Size(m)
+}
+func (m *ProductStatus) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProductStatus.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ProductStatus proto.InternalMessageInfo
+
+func (m *ProductStatus) GetDataType() DataType {
+	if m != nil {
+		return m.DataType
+	}
+	return DataType_WaterLevel
+}
+
+func (m *ProductStatus) GetStatus() ProductStatusCode {
+	if m != nil {
+		return m.Status
+	}
+	return ProductStatusCode_OK
+}
+
+func (m *ProductStatus) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*GetDataFromStationsRequest)(nil), "GetDataFromStationsRequest")
+	proto.RegisterType((*ProductUnit)(nil), "ProductUnit")
 	proto.RegisterType((*GetDataFromStationsResponse)(nil), "GetDataFromStationsResponse")
 	proto.RegisterMapType((map[string]*Station)(nil), "GetDataFromStationsResponse.MapOfStationDataEntry")
+	proto.RegisterType((*GetNearestStationsRequest)(nil), "GetNearestStationsRequest")
+	proto.RegisterType((*GetStationsInBoxRequest)(nil), "GetStationsInBoxRequest")
+	proto.RegisterType((*SearchStationsRequest)(nil), "SearchStationsRequest")
+	proto.RegisterType((*StationCatalogResponse)(nil), "StationCatalogResponse")
+	proto.RegisterType((*CatalogStation)(nil), "CatalogStation")
 	proto.RegisterType((*ProductDataValues)(nil), "ProductDataValues")
 	proto.RegisterType((*Metadata)(nil), "Metadata")
 	proto.RegisterType((*Data)(nil), "Data")
+	proto.RegisterMapType((map[string]string)(nil), "Data.ValuesEntry")
+	proto.RegisterType((*TypedData)(nil), "TypedData")
+	proto.RegisterMapType((map[string]float64)(nil), "TypedData.ValuesEntry")
+	proto.RegisterType((*QualityFlags)(nil), "QualityFlags")
 	proto.RegisterType((*Station)(nil), "Station")
 	proto.RegisterMapType((map[string]*ProductDataValues)(nil), "Station.ProductDataEntry")
+	proto.RegisterMapType((map[string]*ProductStatus)(nil), "Station.ProductStatusEntry")
+	proto.RegisterType((*ProductStatus)(nil), "ProductStatus")
 	proto.RegisterEnum("DataType", DataType_name, DataType_value)
 	proto.RegisterEnum("MetricPreference", MetricPreference_name, MetricPreference_value)
+	proto.RegisterEnum("TimeZone", TimeZone_name, TimeZone_value)
+	proto.RegisterEnum("ProductStatusCode", ProductStatusCode_name, ProductStatusCode_value)
+	proto.RegisterEnum("QualityLevel", QualityLevel_name, QualityLevel_value)
+	proto.RegisterEnum("FailureMode", FailureMode_name, FailureMode_value)
+	proto.RegisterEnum("QueryMode", QueryMode_name, QueryMode_value)
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -473,6 +1525,12 @@ const _ = grpc.SupportPackageIsVersion4
 type ExampleReddiyoGRPCServiceClient interface {
 	// Single Function that will get the data
 	GetDataFromStations(ctx context.Context, in *GetDataFromStationsRequest, opts ...grpc.CallOption) (*GetDataFromStationsResponse, error)
+	// Stations closest to a point
+	GetNearestStations(ctx context.Context, in *GetNearestStationsRequest, opts ...grpc.CallOption) (*StationCatalogResponse, error)
+	// Stations inside a bounding box
+	GetStationsInBox(ctx context.Context, in *GetStationsInBoxRequest, opts ...grpc.CallOption) (*StationCatalogResponse, error)
+	// Stations with a name close to the query
+	SearchStations(ctx context.Context, in *SearchStationsRequest, opts ...grpc.CallOption) (*StationCatalogResponse, error)
 }
 
 type exampleReddiyoGRPCServiceClient struct {
@@ -492,10 +1550,43 @@ func (c *exampleReddiyoGRPCServiceClient) GetDataFromStations(ctx context.Contex
 	return out, nil
 }
 
+func (c *exampleReddiyoGRPCServiceClient) GetNearestStations(ctx context.Context, in *GetNearestStationsRequest, opts ...grpc.CallOption) (*StationCatalogResponse, error) {
+	out := new(StationCatalogResponse)
+	err := c.cc.Invoke(ctx, "/ExampleReddiyoGRPCService/GetNearestStations", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *exampleReddiyoGRPCServiceClient) GetStationsInBox(ctx context.Context, in *GetStationsInBoxRequest, opts ...grpc.CallOption) (*StationCatalogResponse, error) {
+	out := new(StationCatalogResponse)
+	err := c.cc.Invoke(ctx, "/ExampleReddiyoGRPCService/GetStationsInBox", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *exampleReddiyoGRPCServiceClient) SearchStations(ctx context.Context, in *SearchStationsRequest, opts ...grpc.CallOption) (*StationCatalogResponse, error) {
+	out := new(StationCatalogResponse)
+	err := c.cc.Invoke(ctx, "/ExampleReddiyoGRPCService/SearchStations", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ExampleReddiyoGRPCServiceServer is the server API for ExampleReddiyoGRPCService service.
 type ExampleReddiyoGRPCServiceServer interface {
 	// Single Function that will get the data
 	GetDataFromStations(context.Context, *GetDataFromStationsRequest) (*GetDataFromStationsResponse, error)
+	// Stations closest to a point
+	GetNearestStations(context.Context, *GetNearestStationsRequest) (*StationCatalogResponse, error)
+	// Stations inside a bounding box
+	GetStationsInBox(context.Context, *GetStationsInBoxRequest) (*StationCatalogResponse, error)
+	// Stations with a name close to the query
+	SearchStations(context.Context, *SearchStationsRequest) (*StationCatalogResponse, error)
 }
 
 func RegisterExampleReddiyoGRPCServiceServer(s *grpc.Server, srv ExampleReddiyoGRPCServiceServer) {
@@ -520,6 +1611,60 @@ func _ExampleReddiyoGRPCService_GetDataFromStations_Handler(srv interface{}, ctx
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ExampleReddiyoGRPCService_GetNearestStations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetNearestStationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExampleReddiyoGRPCServiceServer).GetNearestStations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ExampleReddiyoGRPCService/GetNearestStations",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExampleReddiyoGRPCServiceServer).GetNearestStations(ctx, req.(*GetNearestStationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExampleReddiyoGRPCService_GetStationsInBox_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStationsInBoxRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExampleReddiyoGRPCServiceServer).GetStationsInBox(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ExampleReddiyoGRPCService/GetStationsInBox",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExampleReddiyoGRPCServiceServer).GetStationsInBox(ctx, req.(*GetStationsInBoxRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExampleReddiyoGRPCService_SearchStations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchStationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExampleReddiyoGRPCServiceServer).SearchStations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ExampleReddiyoGRPCService/SearchStations",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExampleReddiyoGRPCServiceServer).SearchStations(ctx, req.(*SearchStationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _ExampleReddiyoGRPCService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "ExampleReddiyoGRPCService",
 	HandlerType: (*ExampleReddiyoGRPCServiceServer)(nil),
@@ -528,60 +1673,142 @@ var _ExampleReddiyoGRPCService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetDataFromStations",
 			Handler:    _ExampleReddiyoGRPCService_GetDataFromStations_Handler,
 		},
+		{
+			MethodName: "GetNearestStations",
+			Handler:    _ExampleReddiyoGRPCService_GetNearestStations_Handler,
+		},
+		{
+			MethodName: "GetStationsInBox",
+			Handler:    _ExampleReddiyoGRPCService_GetStationsInBox_Handler,
+		},
+		{
+			MethodName: "SearchStations",
+			Handler:    _ExampleReddiyoGRPCService_SearchStations_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "demo.proto",
 }
 
-func init() { proto.RegisterFile("demo.proto", fileDescriptor_demo_744868870d596b95) }
-
-var fileDescriptor_demo_744868870d596b95 = []byte{
-	// 748 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x54, 0xcd, 0x6e, 0xdb, 0x46,
-	0x10, 0x0e, 0xf5, 0x63, 0x8b, 0x23, 0x47, 0x59, 0x4f, 0x12, 0x58, 0x76, 0x82, 0xc2, 0x10, 0x50,
-	0x40, 0x48, 0x6b, 0xa2, 0x55, 0x7b, 0x08, 0x5a, 0xf4, 0xe0, 0xda, 0xae, 0x1d, 0x20, 0x42, 0x04,
-	0xda, 0x48, 0x80, 0x1e, 0x0a, 0x6c, 0xc4, 0x91, 0xb5, 0x28, 0xb9, 0x64, 0x77, 0x97, 0x6a, 0x09,
-	0xf4, 0x1d, 0xfa, 0x3a, 0x7d, 0x80, 0x1e, 0xfb, 0x50, 0xc5, 0xac, 0x28, 0x47, 0xb5, 0x94, 0x9c,
-	0x34, 0x7f, 0xdf, 0xc7, 0x6f, 0x66, 0xb4, 0x03, 0x90, 0x50, 0x96, 0x47, 0x85, 0xc9, 0x5d, 0x3e,
-	0xf8, 0xab, 0x01, 0x47, 0x97, 0xe4, 0xce, 0xa5, 0x93, 0x3f, 0x99, 0x3c, 0xbb, 0x76, 0xd2, 0xa9,
-	0x5c, 0xdb, 0x98, 0x7e, 0x2b, 0xc9, 0x3a, 0xfc, 0x12, 0xf6, 0xa5, 0x31, 0xb2, 0x7a, 0x33, 0xab,
-	0x33, 0xaf, 0xce, 0x6d, 0x3f, 0x38, 0x6e, 0x0e, 0xc3, 0x78, 0x33, 0x81, 0x2f, 0xe1, 0xc0, 0x3a,
-	0x69, 0xdc, 0x8d, 0xca, 0xe8, 0xa2, 0xc8, 0xa7, 0xf3, 0x57, 0xfa, 0x9a, 0xa6, 0xb9, 0x4e, 0x6c,
-	0xbf, 0x71, 0x1c, 0x0c, 0x9b, 0xf1, 0xc7, 0xd2, 0xf8, 0x2d, 0x3c, 0x25, 0x9d, 0x6c, 0xc1, 0x35,
-	0x3d, 0x6e, 0x7b, 0x12, 0x9f, 0x40, 0x3b, 0x91, 0xae, 0xcc, 0xfa, 0xad, 0xe3, 0x60, 0x18, 0xc6,
-	0x4b, 0x07, 0x7f, 0x00, 0x31, 0x26, 0x67, 0xd4, 0x74, 0x62, 0x68, 0x46, 0x86, 0xf4, 0x94, 0xfa,
-	0xed, 0xe3, 0x60, 0xd8, 0x1b, 0xed, 0x47, 0xf7, 0x13, 0xf1, 0x46, 0xe9, 0xe0, 0xdf, 0x00, 0x9e,
-	0x6d, 0x9d, 0x88, 0x2d, 0x72, 0x6d, 0x09, 0x7f, 0x01, 0x91, 0xc9, 0xe2, 0xae, 0x6f, 0xae, 0xf3,
-	0x13, 0xe9, 0x8e, 0x46, 0xd1, 0x27, 0x70, 0xd1, 0xf8, 0x1e, 0xe8, 0x42, 0x3b, 0x53, 0xc5, 0x1b,
-	0x5c, 0x47, 0x63, 0x78, 0xba, 0xb5, 0x14, 0x05, 0x34, 0x7f, 0xa5, 0xaa, 0x1f, 0xf8, 0x5e, 0xd9,
-	0xc4, 0xcf, 0xa0, 0xbd, 0x90, 0x69, 0x49, 0x7e, 0xba, 0xdd, 0x51, 0x27, 0xaa, 0x31, 0xf1, 0x32,
-	0xfc, 0x5d, 0xe3, 0x65, 0x30, 0xf8, 0x13, 0xf6, 0x27, 0x26, 0x4f, 0xca, 0xa9, 0x57, 0xf6, 0x96,
-	0xe3, 0x16, 0x3f, 0x87, 0x4e, 0x46, 0x4e, 0x26, 0x4b, 0xed, 0x8c, 0x0d, 0x79, 0x34, 0x3e, 0x10,
-	0xdf, 0xa5, 0xf0, 0x10, 0x5a, 0xbe, 0xa4, 0xe1, 0xdb, 0x6b, 0x47, 0xcc, 0x10, 0xfb, 0x10, 0x33,
-	0xf0, 0xef, 0x4d, 0x55, 0x90, 0xdf, 0x51, 0x6f, 0x14, 0xfa, 0x34, 0x07, 0xe2, 0xbb, 0xd4, 0x20,
-	0x86, 0xce, 0x8a, 0x17, 0x7b, 0xd0, 0x50, 0x49, 0x2d, 0xbf, 0xa1, 0x12, 0x44, 0x68, 0x69, 0x99,
-	0x2d, 0xc5, 0x87, 0xb1, 0xb7, 0xb9, 0xc7, 0x34, 0xd7, 0x9e, 0x31, 0x8c, 0xd9, 0xf4, 0x11, 0xe9,
-	0xea, 0x0d, 0xb3, 0x39, 0xf8, 0x0a, 0x5a, 0xfc, 0x25, 0xdc, 0x83, 0xc0, 0xd5, 0x74, 0x81, 0x63,
-	0x6f, 0x51, 0x53, 0x05, 0x0b, 0xf6, 0x66, 0x35, 0x4b, 0x30, 0x1b, 0xfc, 0x1d, 0xc0, 0x6e, 0x3d,
-	0x1a, 0x7c, 0x0e, 0xa1, 0x5d, 0xfd, 0x63, 0x6b, 0xf4, 0x87, 0x00, 0x7e, 0x0f, 0xdd, 0xe2, 0xc3,
-	0xb4, 0xea, 0xc6, 0x0f, 0x57, 0x73, 0x8d, 0xd6, 0x26, 0xb9, 0x5c, 0xdf, 0x7a, 0xf5, 0x51, 0x0c,
-	0xe2, 0x7e, 0xc1, 0x96, 0xa5, 0x0d, 0xff, 0xbf, 0x34, 0x8c, 0x36, 0xd6, 0xb3, 0xb6, 0xbe, 0x17,
-	0xff, 0x34, 0xa0, 0xb3, 0x9a, 0x2b, 0xf6, 0x00, 0xde, 0x49, 0x47, 0xe6, 0x35, 0x2d, 0x28, 0x15,
-	0x0f, 0x10, 0xa1, 0x77, 0xaa, 0xcc, 0x0d, 0x65, 0x05, 0x19, 0xe9, 0x4a, 0x43, 0x22, 0xc0, 0x27,
-	0x20, 0x7c, 0xcd, 0x7a, 0xb4, 0x81, 0x1d, 0x68, 0xbd, 0x53, 0x3a, 0x11, 0x4d, 0x7c, 0x04, 0xdd,
-	0x53, 0x65, 0x26, 0x86, 0xac, 0xe5, 0x54, 0x0b, 0x01, 0x76, 0x4e, 0x95, 0xb9, 0x94, 0x85, 0x68,
-	0xa3, 0x80, 0xbd, 0xb3, 0x5c, 0xb3, 0x1a, 0xb5, 0x50, 0xae, 0x12, 0x3b, 0xfc, 0xc9, 0xb7, 0xca,
-	0xaa, 0xf7, 0x2a, 0x65, 0x7f, 0x17, 0xf7, 0xa0, 0x73, 0x55, 0x66, 0x2a, 0x61, 0xaf, 0xc3, 0xde,
-	0xb5, 0x4c, 0x95, 0x66, 0x2f, 0x64, 0xf4, 0x55, 0x5e, 0x9a, 0xb4, 0xba, 0x22, 0x75, 0x3b, 0x77,
-	0x02, 0xb0, 0x0b, 0xbb, 0x57, 0xea, 0x76, 0xfe, 0x3a, 0xff, 0x5d, 0x74, 0xf1, 0x21, 0x84, 0xe7,
-	0x52, 0xa5, 0xd5, 0x98, 0xa4, 0x16, 0x7b, 0x2c, 0x64, 0x9c, 0x6b, 0x37, 0xaf, 0x03, 0x0f, 0xf1,
-	0x00, 0x1e, 0xbf, 0xd1, 0x34, 0x56, 0xba, 0x74, 0xb4, 0xd6, 0x66, 0x8f, 0x35, 0x4c, 0x0c, 0x25,
-	0xca, 0xbf, 0x27, 0xf1, 0x88, 0x15, 0x9f, 0xf3, 0x4b, 0xb7, 0x42, 0xb0, 0x82, 0xb3, 0xd2, 0x18,
-	0xd2, 0xce, 0x8a, 0x7d, 0xa6, 0x58, 0x79, 0x1e, 0x31, 0x5d, 0x42, 0xf0, 0xc5, 0x17, 0x9b, 0x37,
-	0x81, 0xc5, 0x5d, 0xe8, 0xdb, 0x54, 0xd9, 0xb9, 0x78, 0xc0, 0x9c, 0xcb, 0x02, 0x11, 0x8c, 0x32,
-	0x38, 0xbc, 0xf8, 0x43, 0x66, 0x45, 0x4a, 0x31, 0x25, 0x89, 0xaa, 0xf2, 0xcb, 0x78, 0x72, 0x76,
-	0x4d, 0x66, 0xa1, 0xa6, 0x84, 0x13, 0x78, 0xbc, 0xe5, 0x95, 0xe3, 0xb3, 0xe8, 0xe3, 0x57, 0xf4,
-	0xe8, 0xf9, 0xa7, 0x0e, 0xc3, 0x8f, 0x87, 0x3f, 0x1f, 0xd8, 0x94, 0x92, 0xdb, 0x69, 0xae, 0x67,
-	0x27, 0x7c, 0x9a, 0x4f, 0xfc, 0x69, 0x3e, 0x59, 0x7c, 0xfd, 0x7e, 0xc7, 0x5b, 0xdf, 0xfc, 0x17,
-	0x00, 0x00, 0xff, 0xff, 0x6c, 0x5c, 0xf0, 0x3d, 0xb2, 0x05, 0x00, 0x00,
+func init() { proto.RegisterFile("demo.proto", fileDescriptor_demo_f17968dd747845b7) }
+
+var fileDescriptor_demo_f17968dd747845b7 = []byte{
+	// 1871 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x58, 0x4b, 0x6f, 0xe3, 0xc8,
+	0x11, 0x1e, 0x52, 0x2f, 0xb2, 0x24, 0x6b, 0xe8, 0xde, 0x79, 0x68, 0x3c, 0x8b, 0x40, 0x50, 0x36,
+	0x81, 0xd7, 0x93, 0x21, 0xb2, 0x4e, 0x0e, 0x9b, 0x04, 0x39, 0x78, 0xfc, 0xc6, 0xda, 0x63, 0x6f,
+	0x5b, 0x3b, 0x0b, 0xec, 0x21, 0x41, 0x5b, 0x6c, 0xc9, 0x9d, 0xa5, 0x9a, 0x72, 0xb3, 0xe5, 0xb5,
+	0xf2, 0x07, 0x02, 0xe4, 0x98, 0x53, 0x7e, 0x4f, 0x90, 0x63, 0xf2, 0x5f, 0x02, 0xe4, 0x96, 0x53,
+	0x50, 0xcd, 0x16, 0x45, 0xea, 0x31, 0x48, 0x6e, 0xac, 0xaf, 0xaa, 0xab, 0xab, 0xab, 0xeb, 0xd1,
+	0x45, 0x80, 0x88, 0x8f, 0x93, 0x70, 0xa2, 0x12, 0x9d, 0xf4, 0xfe, 0x54, 0x83, 0x9d, 0x53, 0xae,
+	0x8f, 0x98, 0x66, 0x27, 0x2a, 0x19, 0xdf, 0x68, 0xa6, 0x45, 0x22, 0x53, 0xca, 0xef, 0xa7, 0x3c,
+	0xd5, 0xe4, 0x67, 0xb0, 0xcd, 0x94, 0x62, 0xb3, 0xab, 0xa1, 0xe5, 0x9c, 0x1f, 0xa5, 0x1d, 0xa7,
+	0x5b, 0xd9, 0xf5, 0xe9, 0x2a, 0x83, 0x7c, 0x09, 0x2f, 0x53, 0xcd, 0x94, 0xee, 0x8b, 0x31, 0x3f,
+	0x9e, 0x24, 0x83, 0xbb, 0x73, 0x79, 0xc3, 0x07, 0x89, 0x8c, 0xd2, 0x8e, 0xdb, 0x75, 0x76, 0x2b,
+	0x74, 0x13, 0x9b, 0xfc, 0x12, 0x9e, 0x73, 0x19, 0xad, 0x59, 0x57, 0x31, 0xeb, 0xd6, 0x33, 0xc9,
+	0x33, 0xa8, 0x45, 0x4c, 0x4f, 0xc7, 0x9d, 0x6a, 0xd7, 0xd9, 0xf5, 0x69, 0x46, 0x90, 0xdf, 0x42,
+	0x70, 0xc9, 0xb5, 0x12, 0x83, 0x6b, 0xc5, 0x87, 0x5c, 0x71, 0x39, 0xe0, 0x9d, 0x5a, 0xd7, 0xd9,
+	0x6d, 0xef, 0x6f, 0x87, 0xcb, 0x0c, 0xba, 0x22, 0x4a, 0x76, 0xc1, 0xbf, 0x9f, 0x72, 0x35, 0xbb,
+	0x4c, 0x22, 0xde, 0xa9, 0x9b, 0x75, 0x10, 0x7e, 0x3d, 0x47, 0xe8, 0x82, 0x49, 0x7a, 0xd0, 0x52,
+	0x4c, 0x8e, 0xf8, 0xb9, 0x3c, 0x4b, 0xa6, 0x2a, 0xed, 0x34, 0xba, 0xce, 0x6e, 0x8d, 0x96, 0x30,
+	0xf2, 0x13, 0xf0, 0xb4, 0x18, 0xf3, 0xef, 0x12, 0xc9, 0x3b, 0x9e, 0x51, 0xe6, 0x87, 0x7d, 0x0b,
+	0xd0, 0x9c, 0x45, 0x42, 0x68, 0x0e, 0x99, 0x88, 0xa7, 0x8a, 0x9b, 0x6d, 0x7d, 0x23, 0xd9, 0x0a,
+	0x4f, 0x16, 0x18, 0x2d, 0x0a, 0xa0, 0xda, 0x89, 0x4a, 0xa2, 0xe9, 0x40, 0xa7, 0x1d, 0xe8, 0x56,
+	0x8c, 0x5a, 0xbc, 0xc3, 0xfe, 0x6c, 0xc2, 0x69, 0xce, 0x22, 0x5d, 0x68, 0xde, 0xce, 0x26, 0x2c,
+	0x4d, 0x0f, 0xd9, 0xe0, 0x8e, 0x77, 0x9a, 0x5d, 0x67, 0xd7, 0xa3, 0x45, 0x88, 0xfc, 0x1c, 0x5a,
+	0x56, 0xfa, 0x1b, 0x29, 0x74, 0xda, 0x69, 0x75, 0x2b, 0xbb, 0xcd, 0xfd, 0x56, 0x78, 0xbd, 0x00,
+	0x69, 0x49, 0x82, 0xfc, 0x14, 0xda, 0xfc, 0x71, 0x10, 0x4f, 0x23, 0x7e, 0x12, 0xb3, 0xd1, 0x88,
+	0x47, 0x9d, 0x2d, 0xa3, 0x76, 0x09, 0x25, 0x21, 0x10, 0x8b, 0x5c, 0x2b, 0x1e, 0x8b, 0xb1, 0x90,
+	0x4c, 0xcd, 0x3a, 0x6d, 0x23, 0xbb, 0x86, 0xd3, 0x3b, 0x81, 0x66, 0x61, 0x53, 0xf2, 0x63, 0x68,
+	0xd8, 0x6d, 0x3b, 0x4e, 0xd7, 0x29, 0x1f, 0x70, 0xce, 0x21, 0x04, 0xaa, 0x53, 0x29, 0xb4, 0x89,
+	0x2e, 0x9f, 0x9a, 0xef, 0xde, 0x3f, 0x1c, 0x78, 0xbd, 0x36, 0xa2, 0xd3, 0x49, 0x22, 0x53, 0x4e,
+	0x7e, 0x07, 0xc1, 0x98, 0x4d, 0xf2, 0xb8, 0x45, 0x39, 0x13, 0xd1, 0xcd, 0xfd, 0xfd, 0xf0, 0x23,
+	0xeb, 0xc2, 0xcb, 0xa5, 0x45, 0xc7, 0x52, 0xab, 0x19, 0x5d, 0xd1, 0xb5, 0x73, 0x09, 0xcf, 0xd7,
+	0x8a, 0x92, 0x00, 0x2a, 0xdf, 0xf3, 0x99, 0x39, 0x8d, 0x4f, 0xf1, 0x93, 0xfc, 0x08, 0x6a, 0x0f,
+	0x2c, 0x9e, 0x72, 0x63, 0x7f, 0x73, 0xdf, 0x0b, 0xed, 0x1a, 0x9a, 0xc1, 0xbf, 0x76, 0xbf, 0x74,
+	0x7a, 0x7f, 0x71, 0xe0, 0xd5, 0x29, 0xd7, 0xef, 0x39, 0x53, 0x3c, 0xd5, 0xcb, 0xf9, 0xb9, 0x03,
+	0x5e, 0xcc, 0xb4, 0xd0, 0xd3, 0x88, 0x1b, 0xc5, 0x0e, 0xcd, 0x69, 0xf2, 0x29, 0xf8, 0x71, 0x22,
+	0x47, 0x19, 0xd3, 0x35, 0xcc, 0x05, 0x80, 0xb9, 0x83, 0xae, 0xd7, 0x26, 0xc3, 0x6a, 0x34, 0x23,
+	0x4a, 0x71, 0x55, 0xdd, 0x18, 0x57, 0xe8, 0xe3, 0x97, 0xa7, 0x3c, 0xb7, 0xe6, 0x5c, 0xbe, 0x4b,
+	0x1e, 0xe7, 0x26, 0x75, 0xa1, 0x39, 0x16, 0xf2, 0xa2, 0x6c, 0x55, 0x11, 0xc2, 0xbc, 0x41, 0x72,
+	0xc9, 0xb6, 0x12, 0x66, 0xb4, 0xb0, 0xc7, 0x5c, 0x4b, 0xc5, 0x6a, 0x61, 0x8f, 0x25, 0x2d, 0xec,
+	0x71, 0xa1, 0xa5, 0x6a, 0xb5, 0x14, 0xb0, 0xd2, 0x71, 0x6a, 0x9b, 0x8f, 0xf3, 0x07, 0x78, 0x7e,
+	0xc3, 0x99, 0x1a, 0xdc, 0x2d, 0xbb, 0xf7, 0x19, 0xd4, 0x4c, 0xba, 0xdb, 0x4b, 0xcb, 0x88, 0x85,
+	0xeb, 0xdc, 0x4d, 0xae, 0xab, 0x6c, 0xde, 0xeb, 0x18, 0x5e, 0xd8, 0x5d, 0x0e, 0x99, 0x66, 0x71,
+	0x32, 0xca, 0x03, 0xf3, 0x0d, 0x78, 0xa9, 0xdd, 0xdf, 0x06, 0xe4, 0xd3, 0xd0, 0xca, 0xcc, 0xe3,
+	0x22, 0x17, 0xe8, 0xfd, 0xdb, 0x81, 0x76, 0x99, 0x89, 0xf7, 0x9d, 0xce, 0x6b, 0xb1, 0x35, 0x78,
+	0x01, 0x60, 0xaa, 0x48, 0x36, 0xe6, 0xf3, 0x54, 0xc1, 0x6f, 0x3c, 0x08, 0x0a, 0x64, 0xee, 0xf5,
+	0x69, 0x46, 0x94, 0x62, 0xaa, 0xfa, 0xb1, 0x98, 0xaa, 0x2d, 0xc7, 0x54, 0xd1, 0x05, 0xf5, 0xcd,
+	0x55, 0xa9, 0x07, 0xad, 0x48, 0xa4, 0x9a, 0xc9, 0x01, 0x3f, 0x97, 0x5f, 0x8d, 0x4d, 0xdd, 0x74,
+	0x68, 0x09, 0x33, 0xa6, 0x0d, 0x12, 0x95, 0x15, 0x4d, 0x87, 0x66, 0x44, 0xef, 0xaf, 0x2e, 0x6c,
+	0xdb, 0x22, 0x81, 0x7a, 0x3f, 0x60, 0x96, 0x98, 0x1a, 0x3b, 0xe6, 0x9a, 0x45, 0x59, 0x26, 0x63,
+	0x26, 0xf9, 0xe1, 0xa5, 0x05, 0x68, 0xce, 0x22, 0xaf, 0xa0, 0x6a, 0x44, 0x5c, 0xe3, 0xdb, 0x9a,
+	0xb1, 0x8c, 0x1a, 0x08, 0x35, 0x44, 0xd6, 0x4e, 0xe3, 0x8b, 0xb2, 0xe1, 0x73, 0x16, 0xb6, 0x06,
+	0x3d, 0x9b, 0xf0, 0x08, 0x59, 0x26, 0x3d, 0x9a, 0xfb, 0x10, 0xf6, 0xe7, 0x08, 0x5d, 0x30, 0xad,
+	0x67, 0xe3, 0xcc, 0x47, 0x1e, 0xcd, 0x08, 0x3c, 0x38, 0x1b, 0xf1, 0xbc, 0x7f, 0x99, 0xee, 0x52,
+	0xa1, 0x25, 0x2c, 0x2f, 0x69, 0x8d, 0x45, 0x49, 0xc3, 0x75, 0x32, 0x31, 0x5b, 0x70, 0x96, 0x26,
+	0xd2, 0xf8, 0xc4, 0xa7, 0x25, 0x0c, 0xeb, 0x84, 0x37, 0x3f, 0x34, 0x69, 0x83, 0x2b, 0x22, 0x1b,
+	0x03, 0xae, 0x88, 0xd6, 0x5e, 0x7e, 0x00, 0x95, 0x38, 0x91, 0xf6, 0xea, 0xf1, 0xd3, 0x20, 0x4c,
+	0xdb, 0x66, 0x8a, 0x9f, 0x18, 0x0a, 0x79, 0xf7, 0xaa, 0x19, 0x38, 0xa7, 0xd1, 0xa8, 0xf9, 0xf7,
+	0x7b, 0xd4, 0x5d, 0xcf, 0x8c, 0x2a, 0x62, 0xbd, 0x3f, 0xbb, 0x50, 0x35, 0xfe, 0x68, 0x81, 0xa3,
+	0xad, 0x3d, 0x8e, 0x46, 0xea, 0xc1, 0xda, 0xe2, 0x3c, 0x20, 0x35, 0xb4, 0x66, 0x38, 0x43, 0xa4,
+	0x52, 0x6b, 0x82, 0x93, 0x22, 0x15, 0xd9, 0x9d, 0x9d, 0x08, 0x8f, 0x15, 0x29, 0xbb, 0x91, 0x1b,
+	0x29, 0xe4, 0x8e, 0xac, 0xa3, 0x9c, 0x11, 0x52, 0xf7, 0xd6, 0x35, 0xce, 0x3d, 0xca, 0xea, 0x99,
+	0x69, 0xa4, 0x3e, 0x75, 0xf5, 0x0c, 0xb9, 0xb7, 0x1d, 0xc8, 0xb8, 0xb7, 0x48, 0x49, 0xd3, 0x0e,
+	0x7d, 0xea, 0x48, 0xf2, 0x39, 0xd4, 0x4d, 0xc1, 0x9d, 0xb7, 0xbf, 0x6d, 0x73, 0xf9, 0x61, 0x16,
+	0x5e, 0x59, 0x9d, 0xb7, 0x02, 0x3b, 0xbf, 0x82, 0x66, 0x01, 0x5e, 0x53, 0xd3, 0x9f, 0x15, 0x6b,
+	0xba, 0x5f, 0xac, 0xe4, 0xff, 0xaa, 0x80, 0x9f, 0x07, 0x0b, 0xb6, 0x47, 0xbd, 0xfa, 0xdc, 0x71,
+	0x4c, 0x44, 0xac, 0xe1, 0x94, 0xf5, 0x3a, 0x56, 0x2f, 0xe9, 0x40, 0x63, 0x2c, 0xd2, 0x54, 0xc8,
+	0x91, 0xf1, 0xa0, 0x47, 0xe7, 0x24, 0xca, 0x0f, 0x63, 0x36, 0xca, 0xca, 0xb8, 0x47, 0x33, 0x82,
+	0x84, 0xf9, 0x49, 0x6b, 0xe6, 0xa4, 0x2f, 0x16, 0xe1, 0xbb, 0xee, 0xb8, 0x18, 0x38, 0x18, 0xd4,
+	0xd6, 0xe7, 0xe6, 0x9b, 0xec, 0x41, 0x30, 0x48, 0xc6, 0xf8, 0x84, 0x38, 0x12, 0x8a, 0x0f, 0xb0,
+	0xbe, 0xd8, 0x4b, 0x58, 0xc1, 0xf3, 0xc0, 0xf3, 0x0a, 0x81, 0xd7, 0x81, 0xc6, 0xfd, 0x94, 0xc5,
+	0x22, 0xbf, 0x9e, 0x39, 0x89, 0x3e, 0x99, 0xea, 0xc1, 0xd5, 0x70, 0x98, 0x72, 0xbd, 0xf0, 0x09,
+	0x98, 0x2a, 0xbb, 0x86, 0x43, 0xbe, 0x80, 0x96, 0x5d, 0x7a, 0x62, 0x8e, 0xda, 0x34, 0xc9, 0xbf,
+	0x15, 0x7e, 0x5d, 0x00, 0x69, 0x49, 0xa4, 0xb0, 0xe4, 0x82, 0x3f, 0xf0, 0xb8, 0xd3, 0x32, 0xd9,
+	0x9e, 0x2f, 0x31, 0x20, 0x2d, 0x89, 0xfc, 0x9f, 0x57, 0xee, 0x14, 0xaf, 0xfc, 0x3f, 0x0e, 0xb4,
+	0x8a, 0xc6, 0x60, 0x42, 0x25, 0x53, 0x1d, 0x0b, 0xae, 0xb2, 0xbb, 0xf6, 0x68, 0x4e, 0x23, 0x4f,
+	0xc8, 0x21, 0x57, 0x8a, 0x47, 0x46, 0x93, 0x47, 0x73, 0x9a, 0x7c, 0x06, 0x5b, 0xc3, 0x98, 0xe9,
+	0x7e, 0x12, 0x73, 0x85, 0x35, 0xd2, 0xde, 0x76, 0x19, 0xcc, 0x1e, 0xa4, 0x9a, 0x5f, 0x0d, 0x0f,
+	0xef, 0xf0, 0x0d, 0x6a, 0xd2, 0xc8, 0xa3, 0x25, 0x0c, 0x35, 0x99, 0x7e, 0x75, 0xfc, 0x38, 0xe0,
+	0x3c, 0xe2, 0x91, 0xad, 0x50, 0x65, 0xd0, 0xb6, 0xdf, 0x5c, 0xa6, 0x9e, 0x3d, 0x1c, 0x0b, 0x90,
+	0x6d, 0xf3, 0xb9, 0x44, 0xc3, 0x4a, 0x2c, 0xa0, 0xde, 0x3f, 0x5d, 0x68, 0xfc, 0x6f, 0xbd, 0xe9,
+	0x37, 0xd0, 0x9c, 0x2c, 0xaa, 0xba, 0x2d, 0xd0, 0xaf, 0xe6, 0xaf, 0xa1, 0xb0, 0x50, 0xf1, 0xb3,
+	0xe8, 0x2c, 0x4a, 0x93, 0x03, 0xd8, 0xb2, 0x24, 0xca, 0x4f, 0xb3, 0xe6, 0xdb, 0xdc, 0x7f, 0xbd,
+	0xbc, 0x3c, 0xe3, 0x66, 0x0a, 0xca, 0x2b, 0x76, 0x28, 0x04, 0xcb, 0x7b, 0xac, 0xb9, 0xe6, 0xdd,
+	0xf2, 0x6b, 0x8d, 0x84, 0x2b, 0x9d, 0xa8, 0x70, 0xf5, 0x3b, 0xd7, 0x40, 0x56, 0x37, 0x5e, 0xa3,
+	0xf5, 0xb3, 0xb2, 0xd6, 0x76, 0xd9, 0xdc, 0x62, 0x30, 0xfd, 0x11, 0xb6, 0x4a, 0xbc, 0x52, 0xd7,
+	0x72, 0x36, 0x77, 0xad, 0x3d, 0xa8, 0xa7, 0x99, 0x67, 0x5c, 0x23, 0x44, 0xca, 0x5b, 0x1c, 0xe2,
+	0x70, 0x61, 0x25, 0xc8, 0x0b, 0xa8, 0xab, 0xac, 0xc7, 0x64, 0x05, 0xd9, 0x52, 0x7b, 0x7f, 0x77,
+	0xc1, 0x9b, 0xab, 0x26, 0x6d, 0x80, 0x6f, 0x99, 0xe6, 0xca, 0xa4, 0x47, 0xf0, 0x84, 0x10, 0x68,
+	0x1f, 0x08, 0xd5, 0xe7, 0xe3, 0x09, 0x57, 0x4c, 0x4f, 0x15, 0x0f, 0x1c, 0xf2, 0x0c, 0x02, 0x23,
+	0x53, 0x44, 0x5d, 0xe2, 0x41, 0xf5, 0x5b, 0x21, 0xa3, 0xa0, 0x42, 0x9e, 0x42, 0xf3, 0x40, 0xa8,
+	0x6b, 0xc5, 0xd3, 0x14, 0x59, 0x55, 0x02, 0x50, 0x3f, 0x10, 0xea, 0x94, 0x4d, 0x82, 0x1a, 0x09,
+	0xa0, 0x75, 0x98, 0x48, 0x34, 0x51, 0x3c, 0x08, 0x3d, 0x0b, 0xea, 0xb8, 0xe5, 0x07, 0x91, 0x8a,
+	0x5b, 0x81, 0xa9, 0x14, 0x34, 0x48, 0x0b, 0xbc, 0xb3, 0xe9, 0x58, 0x44, 0x48, 0x79, 0x48, 0xdd,
+	0xb0, 0x58, 0x48, 0xa4, 0x7c, 0x5c, 0x8d, 0xb3, 0x57, 0x3c, 0x3b, 0xe3, 0x62, 0x74, 0xa7, 0x03,
+	0x20, 0x4d, 0x68, 0x9c, 0x89, 0xd1, 0xdd, 0x45, 0xf2, 0x43, 0xd0, 0x24, 0x5b, 0xe0, 0x1f, 0x31,
+	0x11, 0xcf, 0x2e, 0x39, 0x93, 0x41, 0x0b, 0x0d, 0xb9, 0x4c, 0xa4, 0xbe, 0xb3, 0xc0, 0x16, 0x79,
+	0x09, 0x9f, 0x5c, 0x49, 0x7e, 0x29, 0xe4, 0x54, 0xf3, 0xc2, 0x31, 0xdb, 0x68, 0xc3, 0xb5, 0xe2,
+	0x91, 0x30, 0x0f, 0xb0, 0xe0, 0x29, 0x5a, 0x7c, 0x84, 0x03, 0x67, 0x1a, 0x04, 0x68, 0xc1, 0xe1,
+	0x54, 0x29, 0x2e, 0x75, 0x1a, 0x6c, 0xa3, 0x8a, 0x39, 0x65, 0x56, 0x0c, 0xb2, 0x25, 0x64, 0xef,
+	0xcd, 0xea, 0x68, 0x8a, 0xc6, 0x1d, 0xcb, 0x51, 0x2c, 0xd2, 0xbb, 0xe0, 0x09, 0xea, 0xcc, 0x04,
+	0x02, 0x67, 0xef, 0x73, 0xf0, 0xe6, 0x93, 0x22, 0x69, 0x40, 0xe5, 0xf4, 0xb2, 0x1f, 0x3c, 0xc1,
+	0x8f, 0x8b, 0x9b, 0x7e, 0xe0, 0xe0, 0xb2, 0x8b, 0x9b, 0xfe, 0xef, 0x2f, 0x8e, 0xfa, 0x81, 0xbb,
+	0x77, 0x0c, 0xdb, 0x2b, 0x77, 0x4a, 0xea, 0xe0, 0x5e, 0x7d, 0x95, 0xe9, 0x7c, 0x6f, 0x5e, 0x0a,
+	0x81, 0x83, 0xdf, 0x38, 0x53, 0xf2, 0x28, 0x70, 0xd1, 0x4f, 0xef, 0x13, 0x7d, 0xf0, 0xc0, 0x44,
+	0xcc, 0x6e, 0x63, 0x1e, 0x54, 0xf6, 0x0e, 0xf2, 0x6a, 0x65, 0xce, 0x8c, 0x17, 0xfb, 0x8d, 0xfc,
+	0x5e, 0x26, 0x3f, 0x48, 0x0b, 0x07, 0x4f, 0xd0, 0x5f, 0x85, 0xa9, 0x2d, 0x70, 0xf0, 0xe8, 0x1f,
+	0xb8, 0x12, 0x43, 0x81, 0x4a, 0xf7, 0xde, 0x40, 0xb3, 0x30, 0xb4, 0x22, 0x13, 0xc9, 0x13, 0x96,
+	0xea, 0xe0, 0x09, 0x7a, 0xf0, 0x1d, 0x4f, 0xf5, 0xf1, 0x70, 0x98, 0x28, 0x1d, 0x38, 0x7b, 0x67,
+	0xe0, 0xe7, 0x83, 0x75, 0x76, 0x2f, 0x9a, 0x53, 0xac, 0x52, 0x99, 0xd5, 0x17, 0x4c, 0xf3, 0x54,
+	0x07, 0x0e, 0xf1, 0xa1, 0xd6, 0x4f, 0x22, 0x36, 0x0b, 0x5c, 0x84, 0x29, 0x1f, 0x70, 0xa9, 0x83,
+	0x0a, 0xc2, 0x99, 0x74, 0x75, 0xff, 0x6f, 0x2e, 0xbc, 0x3a, 0x7e, 0x64, 0xe3, 0x49, 0xcc, 0x29,
+	0x8f, 0x22, 0x31, 0x4b, 0x4e, 0xe9, 0xf5, 0xe1, 0x0d, 0x57, 0x0f, 0x62, 0xc0, 0xc9, 0x35, 0x7c,
+	0xb2, 0x66, 0xb2, 0x23, 0xaf, 0xc3, 0xcd, 0x7f, 0x3e, 0x76, 0x3e, 0xfd, 0xd8, 0x30, 0x48, 0xce,
+	0x81, 0xac, 0x0e, 0x65, 0x64, 0x27, 0xdc, 0x38, 0xa9, 0xed, 0xbc, 0x0c, 0x37, 0x3c, 0xfb, 0x8f,
+	0x21, 0x58, 0x1e, 0xa5, 0x48, 0x27, 0xdc, 0x30, 0x5d, 0x6d, 0x56, 0x73, 0x00, 0xed, 0xf2, 0x0c,
+	0x43, 0x5e, 0x84, 0x6b, 0x87, 0x9a, 0x8d, 0x2a, 0xde, 0xbd, 0xfa, 0xee, 0x65, 0x1a, 0xf3, 0x68,
+	0x34, 0x48, 0xe4, 0xf0, 0x2d, 0xfe, 0x23, 0x7a, 0x6b, 0xfe, 0x11, 0xbd, 0x7d, 0xf8, 0xe2, 0xb6,
+	0x6e, 0xbe, 0x7e, 0xf1, 0xdf, 0x01, 0x00, 0x2b, 0x62, 0x62, 0x8f, 0x3b, 0x12, 0x00, 0x00,
 }
@@ -5,19 +5,28 @@ import (
 	"context"
 	"log"
 	"net"
+	"os"
 	"time"
+	//Scratch containers don't have zone info so it is embedded for the local time zones
+	_ "time/tzdata"
 
 	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
 	sledgconf_demo_proto_v1 "github.com/mornindew/sledgeconf2021/pkg/grpc-service/genProto"
 	noaaclient "github.com/mornindew/sledgeconf2021/pkg/noaa-client"
 	"github.com/mornindew/sledgeconf2021/pkg/station"
+	stationcatalog "github.com/mornindew/sledgeconf2021/pkg/station-catalog"
+	stationstore "github.com/mornindew/sledgeconf2021/pkg/station-store"
+	"github.com/mornindew/sledgeconf2021/pkg/units"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
 // server is used to implement the GRPC Service
-type server struct{}
+type server struct {
+	//catalog - where the station lookups come from
+	catalog *stationcatalog.Catalog
+}
 
 //GetDataFromStations - Server side method to handle getting data from teh stations
 //
@@ -27,6 +36,10 @@ type server struct{}
 //	Failed Precondition
 //	Invalid Argument
 //  Invalid Data
+//	Not Found
+//	Unavailable
+//	Deadline Exceeded
+//	Canceled
 //	Internal
 func (s *server) GetDataFromStations(ctx context.Context, in *sledgconf_demo_proto_v1.GetDataFromStationsRequest) (*sledgconf_demo_proto_v1.GetDataFromStationsResponse, error) {
 
@@ -34,38 +47,208 @@ func (s *server) GetDataFromStations(ctx context.Context, in *sledgconf_demo_pro
 	if len(in.ArrayOfStationIDs) == 0 {
 		return nil, status.Errorf(codes.FailedPrecondition, "empty inputs")
 	}
-	//Check that enddate is after the start date
-	if in.EndTimeEpochInSeconds <= in.StartTimeEpochInSeconds {
-		return nil, status.Errorf(codes.InvalidArgument, "The End Date is after the start date")
+	//Build the time window from the query mode
+	window, err := convertToQueryWindow(in)
+	if err != nil {
+		return nil, err
 	}
-	//Convert the epoch times to times
-	startTime := time.Unix(in.StartTimeEpochInSeconds, 0)
-	endTime := time.Unix(in.EndTimeEpochInSeconds, 0)
 
 	//Convert the datum to a valid enum
 	datum, err := noaaclient.ConvertStringDatumToEnum(in.Datum)
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "The Datum Is Not a valid datum")
 	}
+	//Make sure the time zone is one we know about
+	if _, ok := sledgconf_demo_proto_v1.TimeZone_name[int32(in.TimeZone)]; !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "The Time Zone Is Not a valid time zone")
+	}
+	if _, ok := sledgconf_demo_proto_v1.FailureMode_name[int32(in.FailureMode)]; !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "The Failure Mode Is Not a valid failure mode")
+	}
+	//Only get the products that were asked for
+	products, err := convertToProducts(in.Products)
+	if err != nil {
+		return nil, err
+	}
+	//Units for specific products - everything else is in the metric preference
+	productUnits, err := convertToUnits(in.ProductUnits)
+	if err != nil {
+		return nil, err
+	}
 	//Get the station data
-	mapOfStationData, err := station.RetrieveAllStationDataConcurrently(in.ArrayOfStationIDs, &startTime, &endTime, datum, in.MetricPreference.String())
+	request := &station.Request{StationIDs: in.ArrayOfStationIDs, Window: window, Datum: datum, PreferredMetric: in.MetricPreference.String(), TimeZone: noaaclient.ConvertGrpcEnumToTimeZone(in.TimeZone), FailureMode: station.ConvertGrpcEnumToFailureMode(in.FailureMode), Products: products, BypassCache: in.BypassCache, Units: productUnits, QualityFilter: noaaclient.QualityFilter{ExcludeFlagged: in.ExcludeFlagged, ExcludePreliminary: in.ExcludePreliminary}}
+	mapOfStationData, err := station.RetrieveStationData(ctx, request)
 	//Handle errors
 	if err != nil {
-		switch err.(type) {
-		case customerrors.PreconditionError:
-			return nil, status.Errorf(codes.FailedPrecondition, err.Error())
-		case customerrors.InvalidData:
-			return nil, status.Errorf(codes.InvalidArgument, err.Error())
-		default:
-			return nil, status.Errorf(codes.Internal, err.Error())
-		}
+		return nil, convertToStatusError(err)
 	}
 	//Create the response object
 	response := &sledgconf_demo_proto_v1.GetDataFromStationsResponse{MapOfStationData: *mapOfStationData}
 	return response, nil
 }
 
+//GetNearestStations - the stations in the catalog closest to the point
+//
+//ERROR:  GRPC Error Codes
+//	Invalid Argument
+func (s *server) GetNearestStations(ctx context.Context, in *sledgconf_demo_proto_v1.GetNearestStationsRequest) (*sledgconf_demo_proto_v1.StationCatalogResponse, error) {
+	products, err := convertToProducts(in.Products)
+	if err != nil {
+		return nil, err
+	}
+	results, err := s.catalog.Nearest(in.Latitude, in.Longitude, int(in.Limit), products...)
+	if err != nil {
+		return nil, convertToStatusError(err)
+	}
+	return stationcatalog.ConvertResultsToGrpc(results), nil
+}
+
+//GetStationsInBox - the stations in the catalog inside the bounding box
+//
+//ERROR:  GRPC Error Codes
+//	Invalid Argument
+func (s *server) GetStationsInBox(ctx context.Context, in *sledgconf_demo_proto_v1.GetStationsInBoxRequest) (*sledgconf_demo_proto_v1.StationCatalogResponse, error) {
+	products, err := convertToProducts(in.Products)
+	if err != nil {
+		return nil, err
+	}
+	results, err := s.catalog.WithinBox(in.MinLatitude, in.MinLongitude, in.MaxLatitude, in.MaxLongitude, products...)
+	if err != nil {
+		return nil, convertToStatusError(err)
+	}
+	return stationcatalog.ConvertResultsToGrpc(results), nil
+}
+
+//SearchStations - the stations in the catalog with a name (or ID) close to the query
+//
+//ERROR:  GRPC Error Codes
+//	Failed Precondition
+//	Invalid Argument
+func (s *server) SearchStations(ctx context.Context, in *sledgconf_demo_proto_v1.SearchStationsRequest) (*sledgconf_demo_proto_v1.StationCatalogResponse, error) {
+	products, err := convertToProducts(in.Products)
+	if err != nil {
+		return nil, err
+	}
+	results, err := s.catalog.Search(in.Query, int(in.Limit), products...)
+	if err != nil {
+		return nil, convertToStatusError(err)
+	}
+	return stationcatalog.ConvertResultsToGrpc(results), nil
+}
+
+//convertToStatusError - maps our custom errors (and the context ones) over to the GRPC codes
+func convertToStatusError(err error) error {
+	//The caller gave up (or ran out of time) so the calls to Noaa were stopped
+	switch err {
+	case context.DeadlineExceeded:
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case context.Canceled:
+		return status.Error(codes.Canceled, err.Error())
+	}
+	switch err.(type) {
+	case customerrors.PreconditionError:
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case customerrors.InvalidData:
+		return status.Error(codes.InvalidArgument, err.Error())
+	case customerrors.NotFoundError:
+		return status.Error(codes.NotFound, err.Error())
+	case customerrors.ServiceUnavailableError:
+		return status.Error(codes.Unavailable, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+//convertToProducts - checks the products are ones we know about
+func convertToProducts(dataTypes []sledgconf_demo_proto_v1.DataType) ([]noaaclient.DataProduct, error) {
+	products := make([]noaaclient.DataProduct, 0, len(dataTypes))
+	for _, product := range dataTypes {
+		if _, ok := sledgconf_demo_proto_v1.DataType_name[int32(product)]; !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "The Product Is Not a valid data type")
+		}
+		products = append(products, noaaclient.ConvertGrpcEnumToDataProduct(product))
+	}
+	return products, nil
+}
+
+//convertToUnits - checks the products and units are ones we know about
+func convertToUnits(productUnits []*sledgconf_demo_proto_v1.ProductUnit) (map[noaaclient.DataProduct]units.Unit, error) {
+	unitsToReturn := make(map[noaaclient.DataProduct]units.Unit, len(productUnits))
+	for _, productUnit := range productUnits {
+		if _, ok := sledgconf_demo_proto_v1.DataType_name[int32(productUnit.Product)]; !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "The Product Is Not a valid data type")
+		}
+		unit, err := units.ConvertStringUnitToEnum(productUnit.Unit)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "The Unit Is Not a valid unit")
+		}
+		unitsToReturn[noaaclient.ConvertGrpcEnumToDataProduct(productUnit.Product)] = unit
+	}
+	return unitsToReturn, nil
+}
+
+//convertToQueryWindow - builds the Noaa time window from the request.  The start and end times are only mandatory for a date range
+func convertToQueryWindow(in *sledgconf_demo_proto_v1.GetDataFromStationsRequest) (*noaaclient.QueryWindow, error) {
+	queryMode := noaaclient.ConvertGrpcEnumToQueryMode(in.QueryMode)
+	switch queryMode {
+	case noaaclient.DateRangeQuery:
+		//Check that enddate is after the start date
+		if in.EndTimeEpochInSeconds <= in.StartTimeEpochInSeconds {
+			return nil, status.Errorf(codes.InvalidArgument, "The End Date is after the start date")
+		}
+		//Convert the epoch times to times
+		startTime := time.Unix(in.StartTimeEpochInSeconds, 0)
+		endTime := time.Unix(in.EndTimeEpochInSeconds, 0)
+		return noaaclient.NewDateRangeWindow(&startTime, &endTime), nil
+	case noaaclient.RangeQuery:
+		if in.RangeInHours <= 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "The Range In Hours must be positive")
+		}
+		window := noaaclient.NewRangeWindow(int(in.RangeInHours))
+		//The end time is optional - Noaa uses now if it isn't there
+		if in.EndTimeEpochInSeconds > 0 {
+			endTime := time.Unix(in.EndTimeEpochInSeconds, 0)
+			window.EndDate = &endTime
+		}
+		return window, nil
+	case noaaclient.LatestQuery, noaaclient.TodayQuery, noaaclient.RecentQuery:
+		return noaaclient.NewQueryModeWindow(queryMode), nil
+	}
+	return nil, status.Errorf(codes.InvalidArgument, "The Query Mode Is Not a valid query mode")
+}
+
 func main() {
+	//Keep the data on disk if there is somewhere to put it so only what is missing comes from Noaa
+	if storePath := os.Getenv("STATION_STORE_PATH"); storePath != "" {
+		store, err := stationstore.Open(storePath)
+		if err != nil {
+			log.Fatal("Error Opening the Station Store: " + err.Error())
+		}
+		defer store.Close()
+		station.UseStore(store)
+	}
+	//Serve what is on hand when Noaa is down (or never call it when offline)
+	servingMode, err := station.ConvertStringServingModeToEnum(os.Getenv("SERVING_MODE"))
+	if err != nil {
+		log.Fatal("Error Reading the Serving Mode: " + err.Error())
+	}
+	station.UseServingMode(servingMode)
+	//Limit how many products are fetched at once across every request and for each request
+	pool, err := station.ParseWorkerPoolLimits(os.Getenv("WORKER_POOL_GLOBAL_LIMIT"), os.Getenv("WORKER_POOL_REQUEST_LIMIT"))
+	if err != nil {
+		log.Fatal("Error Reading the Worker Pool Limits: " + err.Error())
+	}
+	station.UseWorkerPool(pool)
+	//Keep the station metadata that the calls are planned with up to date
+	go station.RefreshCapabilities(context.Background())
+	//Station lookups start with the snapshot that ships with the service and are kept up to date unless offline
+	catalog, err := stationcatalog.New()
+	if err != nil {
+		log.Fatal("Error Loading the Station Catalog: " + err.Error())
+	}
+	if servingMode != station.Offline {
+		go catalog.Run(context.Background(), noaaclient.NewNoaaClient(noaaclient.MLLW, noaaclient.Metric.String()), stationcatalog.DefaultRefreshInterval)
+	}
 	//Set up the server to listen - Puke if it cannot
 	lis, err := net.Listen("tcp", "0.0.0.0:50051")
 	if err != nil {
@@ -74,7 +257,7 @@ func main() {
 	s := grpc.NewServer()
 	//Load the protobuf definition
 	//It won't compile if the server is missing the required methods
-	sledgconf_demo_proto_v1.RegisterExampleReddiyoGRPCServiceServer(s, &server{})
+	sledgconf_demo_proto_v1.RegisterExampleReddiyoGRPCServiceServer(s, &server{catalog: catalog})
 
 	err = s.Serve(lis)
 	if err != nil {
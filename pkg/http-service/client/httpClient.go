@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
@@ -15,6 +16,62 @@ type StationDataHttpClient struct {
 	serviceName string
 }
 
+//RequestOption - optional settings for a request (e.g. the time zone).  They are added as query params
+type RequestOption func(params url.Values)
+
+//WithTimeZone - times come back in the time zone (gmt, lst or lst_ldt).  Defaults to gmt
+func WithTimeZone(timeZone string) RequestOption {
+	return func(params url.Values) {
+		params.Set("timeZone", timeZone)
+	}
+}
+
+//WithFailureMode - bestEffort sends back the products that worked along with the status of the ones that didn't.  Defaults to failFast
+func WithFailureMode(failureMode string) RequestOption {
+	return func(params url.Values) {
+		params.Set("failureMode", failureMode)
+	}
+}
+
+//WithProducts - only get these products (Noaa's names e.g. water_level).  Defaults to all of them
+func WithProducts(products ...string) RequestOption {
+	return func(params url.Values) {
+		params.Set("products", strings.Join(products, ","))
+	}
+}
+
+//WithUnit - sends the product (e.g. wind) back in the unit (e.g. kn, ft, degF or mph) instead of the metric preference.  Use it once for each product
+func WithUnit(product, unit string) RequestOption {
+	return func(params url.Values) {
+		if productUnits := params.Get("units"); productUnits != "" {
+			params.Set("units", productUnits+","+product+":"+unit)
+			return
+		}
+		params.Set("units", product+":"+unit)
+	}
+}
+
+//WithExcludeFlagged - leaves out the points that Noaa flagged (e.g. rate of change or max/min exceeded)
+func WithExcludeFlagged() RequestOption {
+	return func(params url.Values) {
+		params.Set("excludeFlagged", "true")
+	}
+}
+
+//WithExcludePreliminary - leaves out the points that haven't been verified yet
+func WithExcludePreliminary() RequestOption {
+	return func(params url.Values) {
+		params.Set("excludePreliminary", "true")
+	}
+}
+
+//WithBypassCache - skip the service's cache and go to Noaa
+func WithBypassCache() RequestOption {
+	return func(params url.Values) {
+		params.Set("bypassCache", "true")
+	}
+}
+
 //CreateClient constructs that will create the client.  It will return the http client to use
 func CreateClient(serviceName string) (*StationDataHttpClient, error) {
 	if serviceName == "" {
@@ -25,53 +82,146 @@ func CreateClient(serviceName string) (*StationDataHttpClient, error) {
 }
 
 //GetDataFromStations - Simple http call to get all the data from a specific station ID.  It will return the Station Struct with any data that was found
-func (v *StationDataHttpClient) GetDataFromStation(stationID string, startTime, endTime *time.Time, datum string, metricPreference sledgconf_demo_proto_v1.MetricPreference) (*sledgconf_demo_proto_v1.Station, error) {
+func (v *StationDataHttpClient) GetDataFromStation(stationID string, startTime, endTime *time.Time, datum string, metricPreference sledgconf_demo_proto_v1.MetricPreference, options ...RequestOption) (*sledgconf_demo_proto_v1.Station, error) {
 
 	// Preconidtion
 	if stationID == "" || startTime == nil || endTime == nil || datum == "" {
 		return nil, customerrors.PreconditionError{Msg: "Missing Mandatory Values"}
 	}
+	// Query params
+	params := url.Values{}
+	startTimeString := strconv.FormatInt(startTime.Unix(), 10)
+	params.Add("startTime", startTimeString)
+	endTimeString := strconv.FormatInt(endTime.Unix(), 10)
+	params.Add("endTime", endTimeString)
+	params.Add("preferredMetric", metricPreference.String())
+	return v.getDataFromStation(stationID, datum, params, options)
+}
+
+//GetDataFromStationByQueryMode - same as GetDataFromStation but addresses the window with one of Noaa's query modes (latest, today, recent or range) instead of a start and end time
+//
+//The range in hours is only used for the range query mode and is the number of hours before now
+func (v *StationDataHttpClient) GetDataFromStationByQueryMode(stationID string, queryMode string, rangeInHours int, datum string, metricPreference sledgconf_demo_proto_v1.MetricPreference, options ...RequestOption) (*sledgconf_demo_proto_v1.Station, error) {
+
+	// Preconidtion
+	if stationID == "" || queryMode == "" || datum == "" {
+		return nil, customerrors.PreconditionError{Msg: "Missing Mandatory Values"}
+	}
+	// Query params
+	params := url.Values{}
+	params.Add("queryMode", queryMode)
+	if rangeInHours > 0 {
+		params.Add("rangeInHours", strconv.Itoa(rangeInHours))
+	}
+	params.Add("preferredMetric", metricPreference.String())
+	return v.getDataFromStation(stationID, datum, params, options)
+}
+
+//getDataFromStation - makes the call with the query params and maps the status codes back over to our custom errors
+func (v *StationDataHttpClient) getDataFromStation(stationID string, datum string, params url.Values, options []RequestOption) (*sledgconf_demo_proto_v1.Station, error) {
+	//Apply any of the optional settings
+	for _, option := range options {
+		option(params)
+	}
 	//Build the URL
 	fullPath := "http://" + v.serviceName + "/station/" + stationID + "/" + datum
 	base, err := url.Parse(fullPath)
 	if err != nil {
 		return nil, customerrors.InternalServerError{Msg: "Error Calling Service: " + err.Error()}
 	}
+	base.RawQuery = params.Encode()
+
+	response, err := v.get(base)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
 
-	// Query params
+	//Marshal it over to our object
+	objectToParse := make(map[string]*sledgconf_demo_proto_v1.Station, 0)
+	json.NewDecoder(response.Body).Decode(&objectToParse)
+	//Get the station ID that was requested
+	val, ok := objectToParse[stationID]
+	if !ok {
+		//This is weird since we didn't get an error so we will return an empty struct
+		return &sledgconf_demo_proto_v1.Station{}, nil
+	}
+	return val, nil
+}
+
+//GetNearestStations - the stations closest to the point that have every one of the products (Noaa's names e.g. water_level).  Anything less than 1 for the limit gets 10
+func (v *StationDataHttpClient) GetNearestStations(latitude, longitude float64, limit int, products ...string) ([]*sledgconf_demo_proto_v1.CatalogStation, error) {
 	params := url.Values{}
-	startTimeString := strconv.FormatInt(startTime.Unix(), 10)
-	params.Add("startTime", startTimeString)
-	endTimeString := strconv.FormatInt(endTime.Unix(), 10)
-	params.Add("endTime", endTimeString)
-	params.Add("preferredMetric", metricPreference.String())
+	params.Add("lat", strconv.FormatFloat(latitude, 'f', -1, 64))
+	params.Add("lon", strconv.FormatFloat(longitude, 'f', -1, 64))
+	return v.getStations("nearest", limit, params, products)
+}
+
+//GetStationsInBox - the stations inside the box that have every one of the products.  A minimum longitude bigger than the maximum is a box that crosses the antimeridian
+func (v *StationDataHttpClient) GetStationsInBox(minLatitude, minLongitude, maxLatitude, maxLongitude float64, products ...string) ([]*sledgconf_demo_proto_v1.CatalogStation, error) {
+	params := url.Values{}
+	params.Add("minLat", strconv.FormatFloat(minLatitude, 'f', -1, 64))
+	params.Add("minLon", strconv.FormatFloat(minLongitude, 'f', -1, 64))
+	params.Add("maxLat", strconv.FormatFloat(maxLatitude, 'f', -1, 64))
+	params.Add("maxLon", strconv.FormatFloat(maxLongitude, 'f', -1, 64))
+	return v.getStations("box", 0, params, products)
+}
+
+//SearchStations - the stations with a name (or ID) close to the query that have every one of the products.  Anything less than 1 for the limit gets 10
+func (v *StationDataHttpClient) SearchStations(query string, limit int, products ...string) ([]*sledgconf_demo_proto_v1.CatalogStation, error) {
+	// Preconidtion
+	if query == "" {
+		return nil, customerrors.PreconditionError{Msg: "Missing Mandatory Values"}
+	}
+	params := url.Values{}
+	params.Add("q", query)
+	return v.getStations("search", limit, params, products)
+}
+
+//getStations - makes the catalog call with the query params
+func (v *StationDataHttpClient) getStations(lookup string, limit int, params url.Values, products []string) ([]*sledgconf_demo_proto_v1.CatalogStation, error) {
+	if limit > 0 {
+		params.Add("limit", strconv.Itoa(limit))
+	}
+	if len(products) > 0 {
+		params.Add("products", strings.Join(products, ","))
+	}
+	base, err := url.Parse("http://" + v.serviceName + "/stations/" + lookup)
+	if err != nil {
+		return nil, customerrors.InternalServerError{Msg: "Error Calling Service: " + err.Error()}
+	}
 	base.RawQuery = params.Encode()
+	response, err := v.get(base)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	catalogResponse := &sledgconf_demo_proto_v1.StationCatalogResponse{}
+	if err := json.NewDecoder(response.Body).Decode(catalogResponse); err != nil {
+		return nil, customerrors.BadFormat{Msg: "Unable to decode the stations: " + err.Error()}
+	}
+	return catalogResponse.Stations, nil
+}
 
+//get - makes the call and maps the status codes back over to our custom errors.  The caller closes the body
+func (v *StationDataHttpClient) get(base *url.URL) (*http.Response, error) {
 	response, err := http.Get(base.String())
 	if err != nil {
 		return nil, customerrors.InternalServerError{Msg: "Error Calling Service: " + err.Error()}
 	}
-	defer response.Body.Close()
 	//Handle non 200
 	if response.StatusCode > 299 {
+		response.Body.Close()
 		//Switch on the big ones that this code handles
 		switch response.StatusCode {
 		case 400:
 			return nil, customerrors.BadRequest{Msg: "Bad Request"}
 		case 404:
 			return nil, customerrors.NotFoundError{Msg: "Entity Not Found"}
+		case 503:
+			return nil, customerrors.ServiceUnavailableError{Msg: "Service Unavailable"}
 		}
 		return nil, customerrors.HTTPError{Msg: "Non-200 Error", Code: response.StatusCode}
 	}
-
-	//Marshal it over to our object
-	objectToParse := make(map[string]*sledgconf_demo_proto_v1.Station, 0)
-	json.NewDecoder(response.Body).Decode(&objectToParse)
-	//Get the station ID that was requested
-	val, ok := objectToParse[stationID]
-	if !ok {
-		//This is weird since we didn't get an error so we will return an empty struct
-		return &sledgconf_demo_proto_v1.Station{}, nil
-	}
-	return val, nil
+	return response, nil
 }
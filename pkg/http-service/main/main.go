@@ -1,22 +1,74 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"expvar"
 	"fmt"
 	"net/http"
+	"net/url"
+	"os"
 	"strconv"
 	"strings"
 	"time"
+	//Scratch containers don't have zone info so it is embedded for the local time zones
+	_ "time/tzdata"
 
 	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
 	noaaclient "github.com/mornindew/sledgeconf2021/pkg/noaa-client"
 	"github.com/mornindew/sledgeconf2021/pkg/station"
+	stationcatalog "github.com/mornindew/sledgeconf2021/pkg/station-catalog"
+	stationstore "github.com/mornindew/sledgeconf2021/pkg/station-store"
+	"github.com/mornindew/sledgeconf2021/pkg/units"
 )
 
 func main() {
+	//Keep the data on disk if there is somewhere to put it so only what is missing comes from Noaa
+	if storePath := os.Getenv("STATION_STORE_PATH"); storePath != "" {
+		store, err := stationstore.Open(storePath)
+		if err != nil {
+			fmt.Println("Error Opening the Station Store: " + err.Error())
+			return
+		}
+		defer store.Close()
+		station.UseStore(store)
+	}
+	//Serve what is on hand when Noaa is down (or never call it when offline)
+	servingMode, err := station.ConvertStringServingModeToEnum(os.Getenv("SERVING_MODE"))
+	if err != nil {
+		fmt.Println("Error Reading the Serving Mode: " + err.Error())
+		return
+	}
+	station.UseServingMode(servingMode)
+	//Limit how many products are fetched at once across every request and for each request
+	pool, err := station.ParseWorkerPoolLimits(os.Getenv("WORKER_POOL_GLOBAL_LIMIT"), os.Getenv("WORKER_POOL_REQUEST_LIMIT"))
+	if err != nil {
+		fmt.Println("Error Reading the Worker Pool Limits: " + err.Error())
+		return
+	}
+	station.UseWorkerPool(pool)
+	//Keep the station metadata that the calls are planned with up to date
+	go station.RefreshCapabilities(context.Background())
+	//Station lookups start with the snapshot that ships with the service and are kept up to date unless offline
+	catalog, err := stationcatalog.New()
+	if err != nil {
+		fmt.Println("Error Loading the Station Catalog: " + err.Error())
+		return
+	}
+	if servingMode != station.Offline {
+		go catalog.Run(context.Background(), noaaclient.NewNoaaClient(noaaclient.MLLW, noaaclient.Metric.String()), stationcatalog.DefaultRefreshInterval)
+	}
 	//Setup the handler function
 	http.HandleFunc("/station/", stationRequestHandler)
-	err := http.ListenAndServe(":8888", nil)
+	http.HandleFunc("/stations/", catalogRequestHandler(catalog))
+	//The counts show up on /debug/vars
+	expvar.Publish("noaaCoalescing", expvar.Func(func() interface{} {
+		return station.CoalescingStats()
+	}))
+	expvar.Publish("noaaCache", expvar.Func(func() interface{} {
+		return station.CacheStats()
+	}))
+	err = http.ListenAndServe(":8888", nil)
 	if err != nil {
 		fmt.Println("Error Starting Server: " + err.Error())
 	}
@@ -33,21 +85,12 @@ func stationRequestHandler(w http.ResponseWriter, req *http.Request) {
 	arrayOfStationIDs := make([]string, 0)
 	arrayOfStationIDs = append(arrayOfStationIDs, stationID)
 	values := req.URL.Query()
-	startTimeEpoch := values.Get("startTime")
-	startTimeEpochInt, err := strconv.ParseInt(startTimeEpoch, 10, 64)
-	if err != nil {
-		http.Error(w, "Unable to convert the startTime to a valid time", http.StatusBadRequest)
-		return
-	}
-	startTime := time.Unix(startTimeEpochInt, 0)
-
-	endTimeEpoch := values.Get("endTime")
-	endTimeEpochInt, err := strconv.ParseInt(endTimeEpoch, 10, 64)
+	//Build the time window - startTime and endTime are only needed for a date range
+	window, err := convertToQueryWindow(values)
 	if err != nil {
-		http.Error(w, "Unable to convert the end time to a valid time", http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	endTime := time.Unix(endTimeEpochInt, 0)
 	//Convert the Datum
 	//Datum is in the third slot
 	datum := urlPathSplits[3]
@@ -59,16 +102,63 @@ func stationRequestHandler(w http.ResponseWriter, req *http.Request) {
 	//Get the preferred Metric - I don't even bother checking as it defaults to metric and nils are impossible
 	preferredMetric := values.Get("preferredMetric")
 
-	stations, err := station.RetrieveAllStationDataConcurrently(arrayOfStationIDs, &startTime, &endTime, datumEnum, preferredMetric)
+	//Time zone is optional and defaults to gmt
+	timeZone, err := noaaclient.ConvertStringTimeZoneToEnum(values.Get("timeZone"))
 	if err != nil {
-		switch err.(type) {
-		case customerrors.PreconditionError:
-			http.Error(w, "Invalid Data", http.StatusBadRequest)
-		case customerrors.InvalidData:
-			http.Error(w, "Invalid Data", http.StatusBadRequest)
-		default:
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Unable to convert the timeZone to a valid time zone", http.StatusBadRequest)
+		return
+	}
+	//Failure mode is optional and defaults to fail fast
+	failureMode, err := station.ConvertStringFailureModeToEnum(values.Get("failureMode"))
+	if err != nil {
+		http.Error(w, "Unable to convert the failureMode to a valid failure mode", http.StatusBadRequest)
+		return
+	}
+	//Products are optional - a comma separated list of Noaa product names (e.g. water_level,wind).  All of them if it is empty
+	products, err := convertToProducts(values.Get("products"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	//Units are optional - a comma separated list of product:unit (e.g. wind:kn,water_level:ft).  Everything else is in the preferred metric
+	productUnits, err := convertToUnits(values.Get("units"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	//Leaving out flagged or preliminary points is optional and everything is kept by default
+	qualityFilter := noaaclient.QualityFilter{}
+	if values.Get("excludeFlagged") != "" {
+		qualityFilter.ExcludeFlagged, err = strconv.ParseBool(values.Get("excludeFlagged"))
+		if err != nil {
+			http.Error(w, "Unable to convert the excludeFlagged to a bool", http.StatusBadRequest)
+			return
 		}
+	}
+	if values.Get("excludePreliminary") != "" {
+		qualityFilter.ExcludePreliminary, err = strconv.ParseBool(values.Get("excludePreliminary"))
+		if err != nil {
+			http.Error(w, "Unable to convert the excludePreliminary to a bool", http.StatusBadRequest)
+			return
+		}
+	}
+
+	//Bypassing the cache is optional and defaults to false
+	bypassCache := false
+	if values.Get("bypassCache") != "" {
+		bypassCache, err = strconv.ParseBool(values.Get("bypassCache"))
+		if err != nil {
+			http.Error(w, "Unable to convert the bypassCache to a bool", http.StatusBadRequest)
+			return
+		}
+	}
+
+	//The request's context is cancelled when the caller goes away so we stop calling Noaa
+	stations, err := station.RetrieveStationData(req.Context(), &station.Request{StationIDs: arrayOfStationIDs, Window: window, Datum: datumEnum, PreferredMetric: preferredMetric, TimeZone: timeZone, FailureMode: failureMode, Products: products, BypassCache: bypassCache, Units: productUnits, QualityFilter: qualityFilter})
+	if err != nil {
+		writeError(w, err)
 		return
 	}
 
@@ -81,3 +171,186 @@ func stationRequestHandler(w http.ResponseWriter, req *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Write(js)
 }
+
+//catalogRequestHandler - looks up stations in the catalog
+//
+//	/stations/nearest?lat=41.8&lon=-71.4&limit=5 - the stations closest to the point
+//	/stations/box?minLat=41&minLon=-72&maxLat=42&maxLon=-71 - the stations inside the box
+//	/stations/search?q=providence&limit=5 - the stations with a name (or ID) close to the query
+//
+//All of them take an optional comma separated list of products (e.g. products=water_level,wind) that the stations must have
+func catalogRequestHandler(catalog *stationcatalog.Catalog) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		values := req.URL.Query()
+		products, err := convertToProducts(values.Get("products"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		//Limit is optional and defaults to 10
+		limit := 0
+		if values.Get("limit") != "" {
+			limit, err = strconv.Atoi(values.Get("limit"))
+			if err != nil {
+				http.Error(w, "Unable to convert the limit to a number", http.StatusBadRequest)
+				return
+			}
+		}
+		var results []stationcatalog.Result
+		var coordinates []float64
+		switch strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, "/stations/"), "/") {
+		case "nearest":
+			coordinates, err = convertToCoordinates(values, "lat", "lon")
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			results, err = catalog.Nearest(coordinates[0], coordinates[1], limit, products...)
+		case "box":
+			coordinates, err = convertToCoordinates(values, "minLat", "minLon", "maxLat", "maxLon")
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			results, err = catalog.WithinBox(coordinates[0], coordinates[1], coordinates[2], coordinates[3], products...)
+		case "search":
+			results, err = catalog.Search(values.Get("q"), limit, products...)
+		default:
+			http.NotFound(w, req)
+			return
+		}
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		js, err := json.Marshal(stationcatalog.ConvertResultsToGrpc(results))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(js)
+	}
+}
+
+//writeError - maps our custom errors (and the context ones) over to the status codes
+func writeError(w http.ResponseWriter, err error) {
+	switch err {
+	case context.DeadlineExceeded:
+		http.Error(w, err.Error(), http.StatusGatewayTimeout)
+		return
+	case context.Canceled:
+		//Nobody is listening anymore
+		return
+	}
+	switch err.(type) {
+	case customerrors.PreconditionError:
+		http.Error(w, "Invalid Data", http.StatusBadRequest)
+	case customerrors.InvalidData:
+		http.Error(w, "Invalid Data", http.StatusBadRequest)
+	case customerrors.NotFoundError:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case customerrors.ServiceUnavailableError:
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+//convertToCoordinates - parses the query params as numbers in the order they are named
+func convertToCoordinates(values url.Values, names ...string) ([]float64, error) {
+	coordinates := make([]float64, 0, len(names))
+	for _, name := range names {
+		coordinate, err := strconv.ParseFloat(values.Get(name), 64)
+		if err != nil {
+			return nil, customerrors.BadRequest{Msg: "Unable to convert the " + name + " to a number"}
+		}
+		coordinates = append(coordinates, coordinate)
+	}
+	return coordinates, nil
+}
+
+//convertToQueryWindow - builds the Noaa time window from the query params
+//
+//	queryMode - daterange (default), latest, today, recent or range
+//	startTime/endTime - epoch seconds.  Both are needed for daterange and endTime is optional for range
+//	rangeInHours - number of hours for range
+func convertToQueryWindow(values url.Values) (*noaaclient.QueryWindow, error) {
+	queryMode, err := noaaclient.ConvertStringQueryModeToEnum(values.Get("queryMode"))
+	if err != nil {
+		return nil, customerrors.BadRequest{Msg: "Unable to convert the queryMode to a valid query mode"}
+	}
+	switch queryMode {
+	case noaaclient.DateRangeQuery:
+		startTimeEpochInt, err := strconv.ParseInt(values.Get("startTime"), 10, 64)
+		if err != nil {
+			return nil, customerrors.BadRequest{Msg: "Unable to convert the startTime to a valid time"}
+		}
+		startTime := time.Unix(startTimeEpochInt, 0)
+
+		endTimeEpochInt, err := strconv.ParseInt(values.Get("endTime"), 10, 64)
+		if err != nil {
+			return nil, customerrors.BadRequest{Msg: "Unable to convert the end time to a valid time"}
+		}
+		endTime := time.Unix(endTimeEpochInt, 0)
+		return noaaclient.NewDateRangeWindow(&startTime, &endTime), nil
+	case noaaclient.RangeQuery:
+		rangeInHours, err := strconv.Atoi(values.Get("rangeInHours"))
+		if err != nil {
+			return nil, customerrors.BadRequest{Msg: "Unable to convert the rangeInHours to a number"}
+		}
+		window := noaaclient.NewRangeWindow(rangeInHours)
+		//End time is optional
+		if values.Get("endTime") != "" {
+			endTimeEpochInt, err := strconv.ParseInt(values.Get("endTime"), 10, 64)
+			if err != nil {
+				return nil, customerrors.BadRequest{Msg: "Unable to convert the end time to a valid time"}
+			}
+			endTime := time.Unix(endTimeEpochInt, 0)
+			window.EndDate = &endTime
+		}
+		return window, nil
+	default:
+		return noaaclient.NewQueryModeWindow(queryMode), nil
+	}
+}
+
+//convertToProducts - splits up the comma separated list of products
+func convertToProducts(value string) ([]noaaclient.DataProduct, error) {
+	products := make([]noaaclient.DataProduct, 0)
+	if value == "" {
+		return products, nil
+	}
+	for _, productName := range strings.Split(value, ",") {
+		product, err := noaaclient.ConvertStringDataProductToEnum(strings.TrimSpace(productName))
+		if err != nil {
+			return nil, customerrors.BadRequest{Msg: "Unable to convert " + productName + " to a valid product"}
+		}
+		products = append(products, product)
+	}
+	return products, nil
+}
+
+//convertToUnits - splits up the comma separated list of product:unit pairs.  The unit can be the symbol or the name (e.g. kn or knots)
+func convertToUnits(value string) (map[noaaclient.DataProduct]units.Unit, error) {
+	productUnits := make(map[noaaclient.DataProduct]units.Unit)
+	if value == "" {
+		return productUnits, nil
+	}
+	for _, pair := range strings.Split(value, ",") {
+		productName, unitName, ok := strings.Cut(pair, ":")
+		if !ok {
+			return nil, customerrors.BadRequest{Msg: "Unable to convert " + pair + " to a product and unit"}
+		}
+		product, err := noaaclient.ConvertStringDataProductToEnum(strings.TrimSpace(productName))
+		if err != nil {
+			return nil, customerrors.BadRequest{Msg: "Unable to convert " + productName + " to a valid product"}
+		}
+		unit, err := units.ConvertStringUnitToEnum(unitName)
+		if err != nil {
+			return nil, customerrors.BadRequest{Msg: "Unable to convert " + unitName + " to a valid unit"}
+		}
+		productUnits[product] = unit
+	}
+	return productUnits, nil
+}
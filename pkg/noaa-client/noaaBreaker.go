@@ -0,0 +1,194 @@
+package noaaclient
+
+import (
+	"sync"
+	"time"
+)
+
+//BreakerState - enum for the state of a circuit breaker
+//
+//	BreakerClosed - calls go through
+//	BreakerOpen - calls fail fast until the open duration is up
+//	BreakerHalfOpen - a single call is let through to see if the host is back
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+//String - the name of the state.  Empty when it isn't one of the states
+func (enum BreakerState) String() string {
+	if enum < BreakerClosed || enum > BreakerHalfOpen {
+		return ""
+	}
+	return []string{"closed", "open", "half_open"}[enum]
+}
+
+//BreakerPolicy - when a breaker opens and for how long
+type BreakerPolicy struct {
+	//FailureThreshold - consecutive failed calls before the breaker opens
+	FailureThreshold int
+	//OpenDuration - how long to fail fast before letting a call through to check the host
+	OpenDuration time.Duration
+}
+
+//DefaultBreakerPolicy - what DefaultCircuitBreakers uses
+var DefaultBreakerPolicy = BreakerPolicy{FailureThreshold: 5, OpenDuration: 30 * time.Second}
+
+//CircuitBreakers - a circuit breaker for each host.  Safe to share between clients
+type CircuitBreakers struct {
+	policy   BreakerPolicy
+	lock     sync.Mutex
+	breakers map[string]*circuitBreaker
+	//now - swapped out in the tests
+	now func() time.Time
+}
+
+//DefaultCircuitBreakers - every client shares these unless WithCircuitBreakers is used.  The retriever keeps one long lived client and the catalog has its own but they call the same host so they share its breaker
+var DefaultCircuitBreakers = NewCircuitBreakers(DefaultBreakerPolicy)
+
+//NewCircuitBreakers - Constructor
+func NewCircuitBreakers(policy BreakerPolicy) *CircuitBreakers {
+	if policy.FailureThreshold < 1 {
+		policy.FailureThreshold = 1
+	}
+	return &CircuitBreakers{policy: policy, breakers: make(map[string]*circuitBreaker), now: time.Now}
+}
+
+//WithCircuitBreakers - sets the breakers that the client uses.  Nil turns the breakers off
+func WithCircuitBreakers(breakers *CircuitBreakers) ClientOption {
+	return func(object *NoaaClient) {
+		object.breakers = breakers
+	}
+}
+
+//State - the state of the breaker for the host.  Hosts that haven't been called are closed
+func (object *CircuitBreakers) State(host string) BreakerState {
+	object.lock.Lock()
+	defer object.lock.Unlock()
+	breaker, ok := object.breakers[host]
+	if !ok {
+		return BreakerClosed
+	}
+	return breaker.currentState(object.now(), object.policy)
+}
+
+//States - the state of every host that has been called
+func (object *CircuitBreakers) States() map[string]BreakerState {
+	object.lock.Lock()
+	defer object.lock.Unlock()
+	states := make(map[string]BreakerState, len(object.breakers))
+	for host, breaker := range object.breakers {
+		states[host] = breaker.currentState(object.now(), object.policy)
+	}
+	return states
+}
+
+///INTERNAL FUNCTIONS
+
+//circuitBreaker - the state for a single host.  Only touched while holding the CircuitBreakers lock
+type circuitBreaker struct {
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	//generation - goes up every time the state changes (or a probe is given up on) so calls let through before then can be ignored
+	generation int
+	//probing - the call that was let through while half open hasn't finished yet
+	probing        bool
+	probeStartedAt time.Time
+}
+
+//breakerToken - handed out by allow for each call that is let through and handed back with how it went
+type breakerToken struct {
+	generation int
+	//probe - the call is the one checking the host while half open
+	probe bool
+}
+
+//currentState - an open breaker goes half open once the open duration is up.  A probe that hasn't finished within the open duration is given up on so another call can check the host
+func (object *circuitBreaker) currentState(now time.Time, policy BreakerPolicy) BreakerState {
+	if object.state == BreakerOpen && now.Sub(object.openedAt) >= policy.OpenDuration {
+		object.setState(BreakerHalfOpen)
+	}
+	if object.state == BreakerHalfOpen && object.probing && now.Sub(object.probeStartedAt) >= policy.OpenDuration {
+		//Whatever the stuck probe says when it does finish is ignored
+		object.generation++
+		object.probing = false
+	}
+	return object.state
+}
+
+//setState - moves to the state and starts a new generation
+func (object *circuitBreaker) setState(state BreakerState) {
+	object.state = state
+	object.generation++
+	object.probing = false
+}
+
+//allow - whether a call can be made to the host and the token to hand back with how it went.  Nil breakers allow everything
+func (object *CircuitBreakers) allow(host string) (breakerToken, bool) {
+	if object == nil {
+		return breakerToken{}, true
+	}
+	object.lock.Lock()
+	defer object.lock.Unlock()
+	breaker, ok := object.breakers[host]
+	if !ok {
+		breaker = &circuitBreaker{}
+		object.breakers[host] = breaker
+	}
+	token := breakerToken{}
+	switch breaker.currentState(object.now(), object.policy) {
+	case BreakerOpen:
+		return token, false
+	case BreakerHalfOpen:
+		//Only one call gets to check the host
+		if breaker.probing {
+			return token, false
+		}
+		breaker.probing = true
+		breaker.probeStartedAt = object.now()
+		token.probe = true
+	}
+	token.generation = breaker.generation
+	return token, true
+}
+
+//release - the call was let through but didn't finish (e.g. the caller cancelled it) so it doesn't count either way.  When it was the probe another call gets to check the host
+func (object *CircuitBreakers) release(host string, token breakerToken) {
+	if object == nil || !token.probe {
+		return
+	}
+	object.lock.Lock()
+	defer object.lock.Unlock()
+	if breaker, ok := object.breakers[host]; ok && breaker.generation == token.generation {
+		breaker.probing = false
+	}
+}
+
+//record - records how a call went.  Calls that were let through before the state changed don't count
+func (object *CircuitBreakers) record(host string, token breakerToken, success bool) {
+	if object == nil {
+		return
+	}
+	object.lock.Lock()
+	defer object.lock.Unlock()
+	breaker, ok := object.breakers[host]
+	if !ok || breaker.generation != token.generation {
+		return
+	}
+	if success {
+		breaker.failures = 0
+		if breaker.state != BreakerClosed {
+			breaker.setState(BreakerClosed)
+		}
+		return
+	}
+	breaker.failures++
+	if breaker.state == BreakerHalfOpen || breaker.failures >= object.policy.FailureThreshold {
+		breaker.setState(BreakerOpen)
+		breaker.openedAt = object.now()
+	}
+}
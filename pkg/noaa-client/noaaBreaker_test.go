@@ -0,0 +1,103 @@
+package noaaclient
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
+)
+
+//TestBreakerStates - closed to open after the threshold, half open after the duration, and a single probe decides what is next
+func TestBreakerStates(t *testing.T) {
+	now := time.Date(2021, time.August, 20, 15, 0, 0, 0, time.UTC)
+	breakers := NewCircuitBreakers(BreakerPolicy{FailureThreshold: 2, OpenDuration: time.Minute})
+	breakers.now = func() time.Time { return now }
+	host := "noaa.test"
+
+	token, ok := breakers.allow(host)
+	if !ok || breakers.State(host) != BreakerClosed {
+		t.Fatal("Expected a new host to be closed")
+	}
+	breakers.record(host, token, false)
+	token, _ = breakers.allow(host)
+	breakers.record(host, token, false)
+	if _, ok := breakers.allow(host); breakers.State(host) != BreakerOpen || ok {
+		t.Fatal("Expected the breaker to open after 2 failures")
+	}
+	//After the duration a single call is let through
+	now = now.Add(time.Minute)
+	probe, ok := breakers.allow(host)
+	if _, second := breakers.allow(host); breakers.State(host) != BreakerHalfOpen || !ok || second {
+		t.Fatal("Expected one probe while half open")
+	}
+	breakers.record(host, probe, false)
+	if breakers.State(host) != BreakerOpen {
+		t.Fatal("Expected a failed probe to open the breaker again")
+	}
+	now = now.Add(time.Minute)
+	probe, _ = breakers.allow(host)
+	breakers.record(host, probe, true)
+	if breakers.State(host) != BreakerClosed || breakers.States()[host] != BreakerClosed {
+		t.Fatal("Expected a good probe to close the breaker")
+	}
+}
+
+//TestBreakerStaleCalls - calls that were let through before the state changed don't change it when they finish
+func TestBreakerStaleCalls(t *testing.T) {
+	now := time.Date(2021, time.August, 20, 15, 0, 0, 0, time.UTC)
+	breakers := NewCircuitBreakers(BreakerPolicy{FailureThreshold: 1, OpenDuration: time.Minute})
+	breakers.now = func() time.Time { return now }
+	host := "noaa.test"
+
+	late, _ := breakers.allow(host)
+	token, _ := breakers.allow(host)
+	breakers.record(host, token, false)
+	//A late success from before it opened doesn't close it
+	breakers.record(host, late, true)
+	if breakers.State(host) != BreakerOpen {
+		t.Fatal("Expected a late success to be ignored")
+	}
+	now = now.Add(time.Minute)
+	if _, ok := breakers.allow(host); !ok {
+		t.Fatal("Expected a probe while half open")
+	}
+	//A late cancel or failure from before it opened doesn't free the probe or open it again
+	breakers.release(host, late)
+	breakers.record(host, late, false)
+	if _, ok := breakers.allow(host); ok || breakers.State(host) != BreakerHalfOpen {
+		t.Fatalf("Expected the probe to still be running but got %s", breakers.State(host))
+	}
+}
+
+//TestBreakerStateString - states outside the enum don't panic
+func TestBreakerStateString(t *testing.T) {
+	if BreakerHalfOpen.String() != "half_open" || BreakerState(-1).String() != "" || BreakerState(3).String() != "" {
+		t.Error("Unexpected breaker state names")
+	}
+}
+
+//TestBreakerFailsFast - once Noaa is down the client stops calling it
+func TestBreakerFailsFast(t *testing.T) {
+	var calls int32
+	server := newFlakyServer(t, 100, http.StatusInternalServerError, "", &calls)
+	defer server.Close()
+	breakers := NewCircuitBreakers(BreakerPolicy{FailureThreshold: 2, OpenDuration: time.Minute})
+	client := NewNoaaClient(MLLW, "metric", WithBaseURL(server.URL), WithRetryPolicy(NoRetryPolicy), WithCircuitBreakers(breakers))
+	stationID := "8454000"
+	for index := 0; index < 2; index++ {
+		_, err := client.RetrieveDataForWindow(context.Background(), NewQueryModeWindow(LatestQuery), GMT, Wind, &stationID)
+		if _, ok := err.(customerrors.InternalServerError); !ok {
+			t.Fatalf("Expected an internal server error but got %v", err)
+		}
+	}
+	_, err := client.RetrieveDataForWindow(context.Background(), NewQueryModeWindow(LatestQuery), GMT, Wind, &stationID)
+	if _, ok := err.(customerrors.ServiceUnavailableError); !ok {
+		t.Fatalf("Expected the breaker to fail fast but got %v", err)
+	}
+	if atomic.LoadInt32(&calls) != 2 || client.BreakerState() != BreakerOpen {
+		t.Error("Expected the open breaker to stop the call")
+	}
+}
@@ -0,0 +1,65 @@
+package noaaclient
+
+import (
+	"time"
+
+	sledgconf_demo_proto_v1 "github.com/mornindew/sledgeconf2021/pkg/grpc-service/genProto"
+)
+
+//DefaultChunkConcurrency - how many chunks of a long window are fetched at the same time
+const DefaultChunkConcurrency = 4
+
+//timeWindow - one chunk of a larger request
+type timeWindow struct {
+	start time.Time
+	end   time.Time
+}
+
+//splitTimeWindow - breaks the window up into chunks that are no longer than the maximum.  A maximum of zero means don't split
+func splitTimeWindow(startDate, endDate time.Time, maximum time.Duration) []timeWindow {
+	if maximum <= 0 || !endDate.After(startDate) {
+		return []timeWindow{{start: startDate, end: endDate}}
+	}
+	windows := make([]timeWindow, 0)
+	for chunkStart := startDate; chunkStart.Before(endDate); chunkStart = chunkStart.Add(maximum) {
+		chunkEnd := chunkStart.Add(maximum)
+		if chunkEnd.After(endDate) {
+			chunkEnd = endDate
+		}
+		windows = append(windows, timeWindow{start: chunkStart, end: chunkEnd})
+	}
+	return windows
+}
+
+//stitchProductData - puts the chunks back together in order.  Noaa's windows are inclusive so the edges of the chunks overlap and need to be de-duplicated
+func stitchProductData(chunks []*sledgconf_demo_proto_v1.ProductDataValues) *sledgconf_demo_proto_v1.ProductDataValues {
+	stitched := &sledgconf_demo_proto_v1.ProductDataValues{}
+	//Currents have a point per bin at the same time so the bin is part of the key
+	seen := make(map[string]bool)
+	for _, chunk := range chunks {
+		if chunk == nil {
+			continue
+		}
+		//Take the first metadata we find
+		if stitched.Metadata == nil {
+			stitched.Metadata = chunk.Metadata
+		}
+		stitched.DataType = chunk.DataType
+		if stitched.NoDataReason == "" {
+			stitched.NoDataReason = chunk.NoDataReason
+		}
+		for _, dataPoint := range chunk.Data {
+			key := dataPoint.T + "|" + dataPoint.B + "|" + dataPoint.N
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			stitched.Data = append(stitched.Data, dataPoint)
+		}
+	}
+	//Only say why there isn't any data when there isn't any
+	if len(stitched.Data) > 0 {
+		stitched.NoDataReason = ""
+	}
+	return stitched
+}
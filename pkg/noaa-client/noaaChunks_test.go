@@ -0,0 +1,67 @@
+package noaaclient
+
+import (
+	"testing"
+	"time"
+
+	sledgconf_demo_proto_v1 "github.com/mornindew/sledgeconf2021/pkg/grpc-service/genProto"
+)
+
+//TestSplitTimeWindow - long windows should be split by the product's limit
+func TestSplitTimeWindow(t *testing.T) {
+	endTime := time.Date(2021, time.August, 20, 0, 0, 0, 0, time.UTC)
+	startTime := endTime.AddDate(0, 0, -70)
+
+	chunks := splitTimeWindow(startTime, endTime, WaterLevel.MaximumRequestWindow())
+	if len(chunks) != 3 {
+		t.Fatalf("Expected 3 chunks but got %d", len(chunks))
+	}
+	if !chunks[0].start.Equal(startTime) || !chunks[2].end.Equal(endTime) {
+		t.Error("Chunks don't cover the window")
+	}
+	for index := 1; index < len(chunks); index++ {
+		if !chunks[index].start.Equal(chunks[index-1].end) {
+			t.Error("Chunks have a gap")
+		}
+		if chunks[index].end.Sub(chunks[index].start) > WaterLevel.MaximumRequestWindow() {
+			t.Error("Chunk is too long")
+		}
+	}
+	//The same window fits in a single high/low request
+	if len(splitTimeWindow(startTime, endTime, HighLow.MaximumRequestWindow())) != 1 {
+		t.Error("Expected a single high low chunk")
+	}
+	//Datums don't take a window
+	if len(splitTimeWindow(startTime, endTime, Datums.MaximumRequestWindow())) != 1 {
+		t.Error("Expected a single datums chunk")
+	}
+	//One minute data is limited to 4 days
+	if len(splitTimeWindow(endTime.AddDate(0, 0, -9), endTime, OneMinuteWaterLevel.MaximumRequestWindow())) != 3 {
+		t.Error("Expected 3 one minute chunks")
+	}
+}
+
+//TestStitchProductData - chunks should be joined in order with the overlapping edges removed
+func TestStitchProductData(t *testing.T) {
+	metadata := &sledgconf_demo_proto_v1.Metadata{Id: "8454000"}
+	chunks := []*sledgconf_demo_proto_v1.ProductDataValues{
+		{Metadata: metadata, Data: []*sledgconf_demo_proto_v1.Data{{T: "2021-08-01 00:00", V: "1"}, {T: "2021-08-01 00:06", V: "2"}}},
+		//A chunk with no data (e.g. a 400)
+		{},
+		{Metadata: metadata, Data: []*sledgconf_demo_proto_v1.Data{{T: "2021-08-01 00:06", V: "2"}, {T: "2021-08-01 00:12", V: "3"}}},
+	}
+	stitched := stitchProductData(chunks)
+	if len(stitched.Data) != 3 {
+		t.Fatalf("Expected 3 points but got %d", len(stitched.Data))
+	}
+	if stitched.Data[0].V != "1" || stitched.Data[2].V != "3" || stitched.Metadata != metadata {
+		t.Error("Chunks were not stitched in order")
+	}
+	//Currents can have the same time in different bins
+	currents := stitchProductData([]*sledgconf_demo_proto_v1.ProductDataValues{
+		{Data: []*sledgconf_demo_proto_v1.Data{{T: "2021-08-01 00:00", B: "1"}, {T: "2021-08-01 00:00", B: "2"}}},
+	})
+	if len(currents.Data) != 2 {
+		t.Error("Bins should not be de-duplicated")
+	}
+}
@@ -1,10 +1,13 @@
 package noaaclient
 
 import (
-	"fmt"
+	"context"
 	"io"
 	"io/ioutil"
 	"net/http"
+	neturl "net/url"
+	"strings"
+	"sync"
 	"time"
 
 	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
@@ -13,40 +16,222 @@ import (
 	"github.com/mornindew/sledgeconf2021/pkg/utils"
 )
 
-//Define the struct
+//Define the struct - safe to use from many go routines at once and meant to be long lived so the connections and station time zones get reused
 type NoaaClient struct {
-	client          *http.Client
+	client      *http.Client
+	baseURL     string
+	userAgent   string
+	retryPolicy RetryPolicy
+	//Breakers are per host and shared between clients by default
+	breakers        *CircuitBreakers
 	datum           Datum
 	preferredMetric MeasurementUnit
 	timeZone        string
 	format          string
 	application     string
+	//How many chunks of a long window are fetched at the same time
+	chunkConcurrency int
+	//The largest response body that will be decoded
+	maxResponseSize int64
+	//Station time zones are cached since they don't change
+	timeZoneLock     sync.Mutex
+	stationTimeZones map[string]*StationTimeZone
 }
 
-//Constructor
-func NewNoaaClient(datum Datum, preferredMetric string) *NoaaClient {
+//DataFetcher - anything that can get the data for a request.  The NoaaClient calls Noaa and the others (e.g. the Coalescer) wrap a DataFetcher so they can be stacked
+type DataFetcher interface {
+	RetrieveData(ctx context.Context, request *DataRequest) (*sledgconf_demo_proto_v1.ProductDataValues, error)
+}
+
+//Constructor - the options can change where and how Noaa is called
+func NewNoaaClient(datum Datum, preferredMetric string, options ...ClientOption) *NoaaClient {
 	//Construct the object to return
-	noaaClientToReturn := &NoaaClient{timeZone: "gmt", format: "json", application: "sledgeconf", datum: datum}
-	//Assumes metric
-	if preferredMetric != English.String() {
-		noaaClientToReturn.preferredMetric = English
-	} else {
-		noaaClientToReturn.preferredMetric = Metric
+	noaaClientToReturn := &NoaaClient{baseURL: DefaultBaseURL, retryPolicy: DefaultRetryPolicy, breakers: DefaultCircuitBreakers, timeZone: "gmt", format: "json", application: "sledgeconf", datum: datum, chunkConcurrency: DefaultChunkConcurrency, maxResponseSize: DefaultMaxResponseSize, stationTimeZones: make(map[string]*StationTimeZone)}
+	noaaClientToReturn.preferredMetric = PreferredMeasurementUnit(preferredMetric)
+	//Every client shares the pooled transport unless it is given its own
+	noaaClientToReturn.client = sharedHTTPClient
+	for _, option := range options {
+		option(noaaClientToReturn)
 	}
-	noaaClientToReturn.client = &http.Client{Timeout: time.Duration(3) * time.Second}
 	return noaaClientToReturn
 }
 
+//PreferredMeasurementUnit - converts the preferred metric that comes in on a request over to the unit.  Doesn't care about case since GRPC sends English and Metric
+func PreferredMeasurementUnit(preferredMetric string) MeasurementUnit {
+	//Assumes metric
+	if strings.EqualFold(strings.TrimSpace(preferredMetric), English.String()) {
+		return English
+	}
+	return Metric
+}
+
 //RetreiveDataVariable - will retreive a specific data set from the noaa station.  It will return empty values if the site doesn't have that data.
 func (object *NoaaClient) RetreiveDataVariable(startDate, endDate *time.Time, dataProduct DataProduct, stationID *string) (*sledgconf_demo_proto_v1.ProductDataValues, error) {
 	//Precondition
 	if startDate == nil || endDate == nil || stationID == nil {
 		return nil, customerrors.PreconditionError{Msg: "Empty Mandatory Values"}
 	}
+	return object.RetrieveDataForWindow(context.Background(), NewDateRangeWindow(startDate, endDate), GMT, dataProduct, stationID)
+}
 
-	url := object.constructURL(startDate, endDate, dataProduct, stationID)
+//RetrieveDataForWindow - will retreive a specific data set from the noaa station for any of the query modes (date range, latest, today, recent or range).  It will return empty values if the site doesn't have that data.
+//
+//The times come back in the time zone that is passed in (gmt, lst or lst_ldt) and the metadata says which zone was used
+//
+//Cancelling the context (or hitting its deadline) aborts any calls that are in flight and returns the context's error
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	InvalidData - the window or station isn't valid
+//	NotFoundError - the station's time zone couldn't be found
+//	ServiceUnavailableError - Noaa is down and the circuit breaker is open
+//	BadFormat - Noaa's response is malformed, truncated, too large, or has values that can't be parsed
+//	InternalServerError - error from Noaa
+func (object *NoaaClient) RetrieveDataForWindow(ctx context.Context, window *QueryWindow, timeZone TimeZone, dataProduct DataProduct, stationID *string) (*sledgconf_demo_proto_v1.ProductDataValues, error) {
+	//Precondition
+	if ctx == nil || window == nil || stationID == nil {
+		return nil, customerrors.PreconditionError{Msg: "Empty Mandatory Values"}
+	}
+	request, err := NewDataRequestBuilder(*stationID, dataProduct).Window(window).TimeZone(timeZone).Build()
+	if err != nil {
+		return nil, err
+	}
+	return object.RetrieveData(ctx, request)
+}
 
-	resp, err := http.Get(url)
+//RetrieveData - will retreive the data for a request made with the DataRequestBuilder.  It will return empty values if the site doesn't have that data.  The no data reason has Noaa's message when it turned the request down
+//
+//Noaa limits how long of a window a single request can cover (e.g. 31 days for 6 minute data) so long windows are split into chunks, fetched concurrently, and stitched back together
+//
+//The times come back in the request's time zone and the metadata says which zone was used
+//
+//Cancelling the context (or hitting its deadline) aborts any calls that are in flight and returns the context's error
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	InvalidData - a param isn't valid for the product
+//	NotFoundError - the station's time zone couldn't be found
+//	ServiceUnavailableError - Noaa is down and the circuit breaker is open
+//	BadFormat - Noaa's response is malformed, truncated, too large, or has values that can't be parsed
+//	InternalServerError - error from Noaa
+func (object *NoaaClient) RetrieveData(ctx context.Context, request *DataRequest) (*sledgconf_demo_proto_v1.ProductDataValues, error) {
+	//Precondition
+	if ctx == nil || request == nil {
+		return nil, customerrors.PreconditionError{Msg: "Empty Mandatory Values"}
+	}
+	//Requests can be built by hand so check them again
+	err := request.Validate()
+	if err != nil {
+		return nil, err
+	}
+	//Figure out the zone first so we don't call for data we cannot convert
+	location, zoneName, err := object.resolveLocation(ctx, request.TimeZone, request.StationID)
+	if err != nil {
+		return nil, err
+	}
+
+	//Daily means are asked for in local standard time so the dates have to be sent in it too.  Without the station's zone the dates go in gmt and are off by the station's offset
+	if request.Product == DailyMean {
+		standardLocation, _, err := object.resolveLocation(ctx, LST, request.StationID)
+		if err != nil && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err == nil {
+			request = request.withQueryLocation(standardLocation)
+		}
+	}
+
+	chunkData, err := object.retrieveChunks(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	productData := stitchProductData(chunkData)
+	productData.DataType = request.Product.ConvertToGrpcEnum()
+	//No data (400s) won't have any data points so there is nothing else to fill in
+	if len(productData.Data) == 0 {
+		return productData, nil
+	}
+	//Predictions and datums don't send metadata back so fill in the station that we asked for
+	if productData.Metadata == nil {
+		productData.Metadata = &sledgconf_demo_proto_v1.Metadata{Id: request.StationID}
+	}
+	productData.Metadata.TimeZone = request.TimeZone.String()
+	productData.Metadata.TimeZoneName = zoneName
+	//Add the typed version of the data - we always ask for gmt so the times are parsed as UTC and then moved to the requested zone
+	observations, err := ConvertToObservations(productData, time.UTC)
+	if err != nil {
+		//The cache, store and filters all count on the typed data lining up with the raw data so this can't go back half done
+		return nil, customerrors.BadFormat{Msg: "Unable to convert the " + request.Product.String() + " data from Noaa: " + err.Error()}
+	}
+	localizeObservations(request.Product, productData, observations, location)
+	productData.TypedData = ConvertObservationsToGrpc(observations)
+	return productData, nil
+}
+
+//BreakerState - the state of the circuit breaker for the host that the client calls.  Always closed if the breakers are turned off
+func (object *NoaaClient) BreakerState() BreakerState {
+	if object.breakers == nil {
+		return BreakerClosed
+	}
+	return object.breakers.State(object.host())
+}
+
+//SetChunkConcurrency - sets how many chunks of a long window can be requested from Noaa at the same time.  Anything less than 1 is ignored.  Call it before the client is shared
+func (object *NoaaClient) SetChunkConcurrency(limit int) {
+	if limit < 1 {
+		return
+	}
+	object.chunkConcurrency = limit
+}
+
+//Internal methods
+
+//retrieveChunks - fetches all the chunks with at most chunkConcurrency requests in flight.  Results come back in the same order as the chunks
+func (object *NoaaClient) retrieveChunks(ctx context.Context, request *DataRequest) ([]*sledgconf_demo_proto_v1.ProductDataValues, error) {
+	chunks := request.Window.chunksForProduct(request.Product)
+	//Nothing to coordinate if there is only one
+	if len(chunks) == 1 {
+		chunkData, err := object.retrieveChunk(ctx, request.withWindow(chunks[0]))
+		if err != nil {
+			return nil, err
+		}
+		return []*sledgconf_demo_proto_v1.ProductDataValues{chunkData}, nil
+	}
+	results := make([]*sledgconf_demo_proto_v1.ProductDataValues, len(chunks))
+	errs := make([]error, len(chunks))
+	//The semaphore limits how many are in flight
+	semaphore := make(chan struct{}, object.chunkConcurrency)
+	var wg sync.WaitGroup
+	wg.Add(len(chunks))
+	for index := range chunks {
+		go func(chunkIndex int) {
+			defer wg.Done()
+			//Chunks that are still waiting don't get started once the context is done
+			select {
+			case semaphore <- struct{}{}:
+			case <-ctx.Done():
+				errs[chunkIndex] = ctx.Err()
+				return
+			}
+			defer func() { <-semaphore }()
+			//Each go routine only writes to its own slot so there is no need for a lock
+			results[chunkIndex], errs[chunkIndex] = object.retrieveChunk(ctx, request.withWindow(chunks[chunkIndex]))
+		}(index)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+//retrieveChunk - makes a single call to Noaa
+func (object *NoaaClient) retrieveChunk(ctx context.Context, request *DataRequest) (*sledgconf_demo_proto_v1.ProductDataValues, error) {
+	url := object.constructURL(request)
+
+	resp, err := object.get(ctx, url)
 	if err != nil {
 		return nil, err
 	}
@@ -54,61 +239,116 @@ func (object *NoaaClient) RetreiveDataVariable(startDate, endDate *time.Time, da
 	//Handle the status codes
 	if resp.StatusCode == 200 {
 		//Parse it into object
-		return object.parse200Response(&resp.Body), nil
+		return object.parse200Response(resp.Body, request.Product)
 	} else if resp.StatusCode == 400 {
 		//They use 400 to handle when a station doesn't have those values.  Will return an empty response body
-		//They should use 404
-		return &sledgconf_demo_proto_v1.ProductDataValues{}, nil
+		//They should use 404.  It is also what comes back for a window without data so keep their message in case the station should have had it
+		return &sledgconf_demo_proto_v1.ProductDataValues{DataType: request.Product.ConvertToGrpcEnum(), NoDataReason: object.parseBadRequestMessage(&resp.Body)}, nil
 	}
 	//If we got here then something went wrong - for simplicity going to genericze to internal server errors
 
 	return nil, object.parseErrorResponse(&resp.Body)
-
 }
 
-//Internal methods
+//constructURL - internal function to build the URL.  Every param is escaped so nothing in the request can add params of its own
+func (object *NoaaClient) constructURL(request *DataRequest) string {
+	return object.baseURL + dataGetterPath + "?" + request.queryValues(object).Encode()
+}
 
-//constructURL - internal function to build the URL.  This is NOT nil safe as it is private and we assume the public method is checking nil values
-func (object *NoaaClient) constructURL(startDate, endDate *time.Time, dataProduct DataProduct, stationID *string) string {
-	params := "begin_date=" + utils.ConvertTimeToyyyyMMdd(*startDate) + "&end_date=" + utils.ConvertTimeToyyyyMMdd(*endDate) + "&station=" + *stationID + "&product=" + dataProduct.String() + "&time_zone=" + object.timeZone + "&application=" + object.application + "&format=" + object.format + "&units=" + object.preferredMetric.String()
-	switch dataProduct {
-	case WaterLevel:
-		params = params + "&datum=" + object.datum.String()
-		break
-	case WaterTemperature:
-		params = params + "&datum=" + object.datum.String()
-		break
+//get - every call to Noaa goes through here so that it uses the client's http.Client, user agent, retry policy and circuit breaker
+//
+//Transport errors, 429s and 5xxs are retried.  Once the retries run out the last response is returned so the caller can handle the status code
+//
+//Context errors are returned as is and don't count against the breaker since Noaa didn't do anything wrong
+//
+//	Errors:
+//	ServiceUnavailableError - the circuit breaker for the host is open
+//	InternalServerError - the call couldn't be made
+func (object *NoaaClient) get(ctx context.Context, url string) (*http.Response, error) {
+	host := object.host()
+	maxAttempts := object.retryPolicy.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	for attempt := 1; ; attempt++ {
+		token, ok := object.breakers.allow(host)
+		if !ok {
+			return nil, customerrors.ServiceUnavailableError{Msg: "Noaa is unavailable - not calling " + host + " until it recovers"}
+		}
+		resp, err := object.doGet(ctx, url)
+		if err != nil && ctx.Err() != nil {
+			//Noaa didn't get a chance to answer so the breaker has to let the next call through
+			object.breakers.release(host, token)
+			return nil, ctx.Err()
+		}
+		retryable := err != nil || isRetryableStatus(resp.StatusCode)
+		object.breakers.record(host, token, !retryable)
+		if !retryable {
+			return resp, nil
+		}
+		if attempt >= maxAttempts {
+			if err != nil {
+				return nil, customerrors.InternalServerError{Msg: "Error Calling Noaa: " + err.Error()}
+			}
+			return resp, nil
+		}
+		wait := object.retryPolicy.backoff(attempt)
+		if resp != nil {
+			retryAfterWait, ok := retryAfter(resp, time.Now())
+			if ok && retryAfterWait > object.retryPolicy.MaxRetryAfter {
+				//Longer than we are willing to wait
+				return resp, nil
+			}
+			if ok && retryAfterWait > wait {
+				wait = retryAfterWait
+			}
+			discardBody(resp)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
 	}
-	baseUrl := "https://api.tidesandcurrents.noaa.gov/api/prod/datagetter?"
-	return baseUrl + params
 }
 
-//this function blows - but not all 400's are the same and we need to differentiate based on the message
-func (object *NoaaClient) parse200Response(response *io.ReadCloser) *sledgconf_demo_proto_v1.ProductDataValues {
-	//Object to return
-	successObject := &sledgconf_demo_proto_v1.ProductDataValues{}
-
-	//Have to parse out the error message
-	body, err := ioutil.ReadAll(*response)
+//doGet - a single call
+func (object *NoaaClient) doGet(ctx context.Context, url string) (*http.Response, error) {
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		fmt.Println("Error Reading the response!  Sending back nil " + err.Error())
-		return successObject
+		return nil, err
 	}
-	//Marshal the data
-	err = utils.MarshalDataToInterface(body, successObject)
-	if err != nil {
-		//If we cannot parse then we should log but we did get a 200 so return an empty
-		fmt.Println("Error Parsing the body! " + err.Error())
+	if object.userAgent != "" {
+		httpRequest.Header.Set("User-Agent", object.userAgent)
 	}
+	return object.client.Do(httpRequest)
+}
 
-	//Since we got here it appears to be a valid 400
-	return successObject
+//host - the breakers are keyed off of the host in the base url
+func (object *NoaaClient) host() string {
+	parsedURL, err := neturl.Parse(object.baseURL)
+	if err != nil || parsedURL.Host == "" {
+		return object.baseURL
+	}
+	return parsedURL.Host
+}
+
+//parse200Response - streams the body into the product data.  Noaa sends back an error message with a 200 when there is no data which is handled the same as a 400
+//
+//Each product has its own JSON shape so the product decides which decoder gets used
+//
+//	Errors:
+//	BadFormat - the body is malformed, truncated, or larger than the client's max response size
+func (object *NoaaClient) parse200Response(response io.Reader, dataProduct DataProduct) (*sledgconf_demo_proto_v1.ProductDataValues, error) {
+	return decodeProductResponse(dataProduct, response, object.maxResponseSize)
 }
 
 //generic method to handle error marshalling.   This API really only returns 200, 400, or 500.  Since we handle 400 as a 200 then everythign gets parsed as a 500
 func (object *NoaaClient) parseErrorResponse(response *io.ReadCloser) error {
 	//Have to parse out the error message
-	body, err := ioutil.ReadAll(*response)
+	body, err := ioutil.ReadAll(io.LimitReader(*response, object.maxResponseSize))
 	if err != nil {
 		//For some reason we couldn't parse so just assuming a bad format
 		return customerrors.InternalServerError{Msg: "Bad Format Error"}
@@ -123,3 +363,16 @@ func (object *NoaaClient) parseErrorResponse(response *io.ReadCloser) error {
 	//Since we got here it appears to be a valid 500
 	return customerrors.InternalServerError{Msg: errorObject.Error.Message}
 }
+
+//parseBadRequestMessage - Noaa's message for a 400 (e.g. no data was found or a bad param).  Empty if there isn't one
+func (object *NoaaClient) parseBadRequestMessage(response *io.ReadCloser) string {
+	body, err := ioutil.ReadAll(io.LimitReader(*response, object.maxResponseSize))
+	if err != nil {
+		return ""
+	}
+	errorObject := &ErrorResponse{}
+	if utils.MarshalDataToInterface(body, errorObject) != nil {
+		return ""
+	}
+	return errorObject.Error.Message
+}
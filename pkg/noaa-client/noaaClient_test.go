@@ -1,7 +1,12 @@
 package noaaclient
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -21,6 +26,19 @@ func TestDatumEnums(t *testing.T) {
 	}
 }
 
+func TestDataProductEnums(t *testing.T) {
+
+	for product := DataProduct(0); product < MaximumLimit; product++ {
+		val, err := ConvertStringDataProductToEnum(product.String())
+		if err != nil || val != product {
+			t.Error("Value didn't match for " + product.String())
+		}
+	}
+	if _, err := ConvertStringDataProductToEnum("tides"); err == nil {
+		t.Error("Expected an error for a product that doesn't exist")
+	}
+}
+
 func TestMakeCallsForAllProducts(t *testing.T) {
 
 	client := NewNoaaClient(CRD, "metric")
@@ -45,3 +63,123 @@ func TestMakeCallsForAllProducts(t *testing.T) {
 		}
 	}
 }
+
+//TestContextCancellation - a deadline aborts a call that is in flight and a wait between retries without tripping the breaker
+func TestContextCancellation(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("product") == "wind" {
+			//Hang until the test is done
+			select {
+			case <-r.Context().Done():
+			case <-release:
+			}
+			return
+		}
+		w.Header().Set("Retry-After", "5")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	breakers := NewCircuitBreakers(BreakerPolicy{FailureThreshold: 1, OpenDuration: time.Minute})
+	client := NewNoaaClient(MLLW, "metric", WithBaseURL(server.URL), WithCircuitBreakers(breakers))
+	stationID := "8454000"
+	for _, product := range []DataProduct{Wind, WaterLevel} {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		started := time.Now()
+		_, err := client.RetrieveDataForWindow(ctx, NewQueryModeWindow(LatestQuery), GMT, product, &stationID)
+		cancel()
+		if err != context.DeadlineExceeded {
+			t.Errorf("%s: expected the deadline to be exceeded but got %v", product.String(), err)
+		}
+		if time.Since(started) > time.Second {
+			t.Errorf("%s: the deadline didn't stop the call", product.String())
+		}
+		if product == Wind && client.BreakerState() != BreakerClosed {
+			t.Error("A cancelled call should not count against the breaker")
+		}
+	}
+}
+
+//TestContextCancellationHalfOpen - a half open probe that is cancelled lets the next call check the host instead of leaving the breaker stuck
+func TestContextCancellationHalfOpen(t *testing.T) {
+	var hang int32 = 1
+	server := newTestServer(t, func(request *http.Request) {
+		if atomic.LoadInt32(&hang) == 1 {
+			<-request.Context().Done()
+		}
+	})
+	defer server.Close()
+
+	breakers := NewCircuitBreakers(BreakerPolicy{FailureThreshold: 1, OpenDuration: time.Minute})
+	current := time.Now()
+	breakers.now = func() time.Time { return current }
+	client := NewNoaaClient(MLLW, "metric", WithBaseURL(server.URL), WithRetryPolicy(NoRetryPolicy), WithCircuitBreakers(breakers))
+	host := client.host()
+	token, _ := breakers.allow(host)
+	breakers.record(host, token, false)
+	current = current.Add(time.Minute)
+	stationID := "8454000"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	_, err := client.RetrieveDataForWindow(ctx, NewQueryModeWindow(LatestQuery), GMT, WaterLevel, &stationID)
+	cancel()
+	if err != context.DeadlineExceeded || client.BreakerState() != BreakerHalfOpen {
+		t.Fatalf("Expected the deadline to be exceeded while half open but got %v and %s", err, client.BreakerState())
+	}
+	atomic.StoreInt32(&hang, 0)
+	if _, err = client.RetrieveDataForWindow(context.Background(), NewQueryModeWindow(LatestQuery), GMT, WaterLevel, &stationID); err != nil || client.BreakerState() != BreakerClosed {
+		t.Errorf("Expected the next call to check the host and close the breaker but got %v and %s", err, client.BreakerState())
+	}
+
+	//A probe that never finishes is given up on after the open duration
+	token, _ = breakers.allow(host)
+	breakers.record(host, token, false)
+	current = current.Add(time.Minute)
+	stuck, ok := breakers.allow(host)
+	if _, second := breakers.allow(host); !ok || second {
+		t.Fatal("Expected only one probe while half open")
+	}
+	current = current.Add(time.Minute)
+	if _, ok := breakers.allow(host); !ok {
+		t.Error("Expected a stuck probe to be given up on")
+	}
+	//The stuck probe finishing late doesn't decide anything
+	breakers.record(host, stuck, true)
+	if breakers.State(host) != BreakerHalfOpen {
+		t.Errorf("Expected the stuck probe to be ignored but got %s", breakers.State(host))
+	}
+}
+
+//TestRetrieveDataBadValues - values that can't be typed fail the call instead of coming back without the typed data
+func TestRetrieveDataBadValues(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": [{"t":"2021-08-20 15:00", "v":"high", "s":"0.003", "f":"0,0,0,0", "q":"p"}]}`))
+	}))
+	defer server.Close()
+	client := NewNoaaClient(MLLW, "metric", WithBaseURL(server.URL), WithRetryPolicy(NoRetryPolicy), WithCircuitBreakers(nil))
+	stationID := "8454000"
+	productData, err := client.RetrieveDataForWindow(context.Background(), NewQueryModeWindow(LatestQuery), GMT, WaterLevel, &stationID)
+	if !isBadFormat(err) || productData != nil {
+		t.Errorf("Expected a bad format but got %v", err)
+	}
+}
+
+//TestBadRequestReason - a 400 is no data with Noaa's message as the reason
+func TestBadRequestReason(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": {"message": "No data was found. This product may not be offered at this station at the requested time."}}`))
+	}))
+	defer server.Close()
+	client := NewNoaaClient(MLLW, "metric", WithBaseURL(server.URL), WithRetryPolicy(NoRetryPolicy), WithCircuitBreakers(nil))
+	request, err := NewDataRequestBuilder("8454000", WaterLevel).Window(NewQueryModeWindow(LatestQuery)).Build()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	productData, err := client.RetrieveData(context.Background(), request)
+	if err != nil || productData == nil || len(productData.Data) != 0 || !strings.Contains(productData.NoDataReason, "No data was found") {
+		t.Errorf("Expected no data with Noaa's message but got %+v %v", productData, err)
+	}
+}
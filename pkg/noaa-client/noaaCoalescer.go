@@ -0,0 +1,128 @@
+package noaaclient
+
+import (
+	"context"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
+	sledgconf_demo_proto_v1 "github.com/mornindew/sledgeconf2021/pkg/grpc-service/genProto"
+)
+
+//Coalescer - identical requests that are in flight at the same time share one call and its result or error.  Requests are identical when they have the same Key (station, product, window, datum, units, time zone, etc.)
+//
+//Safe to use from many go routines at once
+type Coalescer struct {
+	fetcher DataFetcher
+	lock    sync.Mutex
+	calls   map[string]*coalescedCall
+	//Counters for the stats.  Only touched while holding the lock
+	started int64
+	saved   int64
+}
+
+//CoalescingStats - how many calls were made and how many were saved by sharing a call that was already in flight
+type CoalescingStats struct {
+	//Calls - calls that were passed on to the fetcher
+	Calls int64 `json:"calls"`
+	//Saved - requests that shared a call instead of making their own
+	Saved int64 `json:"saved"`
+	//InFlight - calls that are running right now
+	InFlight int `json:"inFlight"`
+}
+
+//NewCoalescer - Constructor.  Wraps the fetcher that makes the real calls (e.g. a NoaaClient)
+func NewCoalescer(fetcher DataFetcher) *Coalescer {
+	return &Coalescer{fetcher: fetcher, calls: make(map[string]*coalescedCall)}
+}
+
+//RetrieveData - joins the call for an identical request if there is one in flight, otherwise starts one
+//
+//Each request gets its own copy of the data.  The call keeps going as long as one of the requests is still waiting on it.  Cancelling the context only stops the wait (and the call if nothing else is waiting) and returns the context's error
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	Anything that the fetcher returns
+func (object *Coalescer) RetrieveData(ctx context.Context, request *DataRequest) (*sledgconf_demo_proto_v1.ProductDataValues, error) {
+	//Precondition
+	if ctx == nil || request == nil {
+		return nil, customerrors.PreconditionError{Msg: "Empty Mandatory Values"}
+	}
+	key := request.Key()
+	object.lock.Lock()
+	call, shared := object.calls[key]
+	if shared {
+		object.saved++
+	} else {
+		call = object.startCall(key, request)
+	}
+	call.waiters++
+	object.lock.Unlock()
+
+	select {
+	case <-call.done:
+		if call.err != nil || call.result == nil {
+			return nil, call.err
+		}
+		//Every request gets a copy so that nobody can change the data out from under the others
+		return proto.Clone(call.result).(*sledgconf_demo_proto_v1.ProductDataValues), nil
+	case <-ctx.Done():
+		object.leave(key, call)
+		return nil, ctx.Err()
+	}
+}
+
+//Stats - the counts since the coalescer was made
+func (object *Coalescer) Stats() CoalescingStats {
+	object.lock.Lock()
+	defer object.lock.Unlock()
+	return CoalescingStats{Calls: object.started, Saved: object.saved, InFlight: len(object.calls)}
+}
+
+///INTERNAL FUNCTIONS
+
+//coalescedCall - a call that one or more requests are waiting on.  The result and error are only read after done is closed
+type coalescedCall struct {
+	done   chan struct{}
+	result *sledgconf_demo_proto_v1.ProductDataValues
+	err    error
+	//waiters - requests still waiting.  Only touched while holding the coalescer's lock
+	waiters int
+	cancel  context.CancelFunc
+}
+
+//startCall - runs the call on its own context so that the request that started it can go away without failing the others.  Must hold the lock
+func (object *Coalescer) startCall(key string, request *DataRequest) *coalescedCall {
+	ctx, cancel := context.WithCancel(context.Background())
+	call := &coalescedCall{done: make(chan struct{}), cancel: cancel}
+	object.calls[key] = call
+	object.started++
+	go func() {
+		defer cancel()
+		call.result, call.err = object.fetcher.RetrieveData(ctx, request)
+		object.lock.Lock()
+		object.remove(key, call)
+		object.lock.Unlock()
+		close(call.done)
+	}()
+	return call
+}
+
+//leave - a request stopped waiting.  When nobody is left the call is cancelled and new requests start their own
+func (object *Coalescer) leave(key string, call *coalescedCall) {
+	object.lock.Lock()
+	defer object.lock.Unlock()
+	call.waiters--
+	if call.waiters > 0 {
+		return
+	}
+	object.remove(key, call)
+	call.cancel()
+}
+
+//remove - takes the call out of the map unless it was already replaced.  Must hold the lock
+func (object *Coalescer) remove(key string, call *coalescedCall) {
+	if object.calls[key] == call {
+		delete(object.calls, key)
+	}
+}
@@ -0,0 +1,173 @@
+package noaaclient
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sledgconf_demo_proto_v1 "github.com/mornindew/sledgeconf2021/pkg/grpc-service/genProto"
+)
+
+//fetcherFunc - lets a function stand in for the client
+type fetcherFunc func(ctx context.Context, request *DataRequest) (*sledgconf_demo_proto_v1.ProductDataValues, error)
+
+func (fetch fetcherFunc) RetrieveData(ctx context.Context, request *DataRequest) (*sledgconf_demo_proto_v1.ProductDataValues, error) {
+	return fetch(ctx, request)
+}
+
+//newGatedFetcher - a fetcher that counts its calls and holds them until the gate is closed (or the call is cancelled)
+func newGatedFetcher(gate chan struct{}, calls *int32, err error) fetcherFunc {
+	return func(ctx context.Context, request *DataRequest) (*sledgconf_demo_proto_v1.ProductDataValues, error) {
+		atomic.AddInt32(calls, 1)
+		select {
+		case <-gate:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &sledgconf_demo_proto_v1.ProductDataValues{DataType: request.Product.ConvertToGrpcEnum(), Data: []*sledgconf_demo_proto_v1.Data{{T: "2021-08-20 15:00", V: "1.234"}}}, nil
+	}
+}
+
+//waitForStats - waits for the coalescer to get to the stats
+func waitForStats(t *testing.T, coalescer *Coalescer, check func(stats CoalescingStats) bool) {
+	deadline := time.Now().Add(2 * time.Second)
+	for !check(coalescer.Stats()) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out with stats %+v", coalescer.Stats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+//TestCoalescerSharesCalls - identical requests share one call and each get their own copy.  Different requests get their own call
+func TestCoalescerSharesCalls(t *testing.T) {
+	gate := make(chan struct{})
+	var calls int32
+	coalescer := NewCoalescer(newGatedFetcher(gate, &calls, nil))
+	waterLevel, _ := NewDataRequestBuilder("8454000", WaterLevel).Window(NewQueryModeWindow(LatestQuery)).Build()
+	wind, _ := NewDataRequestBuilder("8454000", Wind).Window(NewQueryModeWindow(LatestQuery)).Build()
+
+	requests := []*DataRequest{waterLevel, waterLevel, waterLevel, waterLevel, wind, wind}
+	results := make([]*sledgconf_demo_proto_v1.ProductDataValues, len(requests))
+	errs := make([]error, len(requests))
+	var wg sync.WaitGroup
+	for index, request := range requests {
+		wg.Add(1)
+		go func(index int, request *DataRequest) {
+			defer wg.Done()
+			results[index], errs[index] = coalescer.RetrieveData(context.Background(), request)
+		}(index, request)
+	}
+	waitForStats(t, coalescer, func(stats CoalescingStats) bool { return stats.Saved == 4 })
+	close(gate)
+	wg.Wait()
+
+	for index, err := range errs {
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if results[index].DataType != requests[index].Product.ConvertToGrpcEnum() || len(results[index].Data) != 1 {
+			t.Errorf("Request %d got the wrong data", index)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 calls but got %d", calls)
+	}
+	if results[0] == results[1] || results[0].Data[0] == results[1].Data[0] {
+		t.Error("Expected every request to get its own copy")
+	}
+	stats := coalescer.Stats()
+	if stats.Calls != 2 || stats.Saved != 4 || stats.InFlight != 0 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	//Once the call is done the next request makes a new one
+	_, err := coalescer.RetrieveData(context.Background(), waterLevel)
+	if err != nil || calls != 3 {
+		t.Errorf("Expected a new call but got %d calls and %v", calls, err)
+	}
+}
+
+//TestCoalescerSharesErrors - every request waiting on a call gets its error
+func TestCoalescerSharesErrors(t *testing.T) {
+	gate := make(chan struct{})
+	var calls int32
+	expected := errors.New("Noaa is having a bad day")
+	coalescer := NewCoalescer(newGatedFetcher(gate, &calls, expected))
+	request, _ := NewDataRequestBuilder("8454000", WaterLevel).Build()
+	errs := make(chan error, 3)
+	for index := 0; index < cap(errs); index++ {
+		go func() {
+			_, err := coalescer.RetrieveData(context.Background(), request)
+			errs <- err
+		}()
+	}
+	waitForStats(t, coalescer, func(stats CoalescingStats) bool { return stats.Saved == 2 })
+	close(gate)
+	for index := 0; index < cap(errs); index++ {
+		if err := <-errs; err != expected {
+			t.Errorf("Expected the shared error but got %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected 1 call but got %d", calls)
+	}
+}
+
+//TestCoalescerCancel - a request going away doesn't fail the others.  When every request has gone away the call is cancelled
+func TestCoalescerCancel(t *testing.T) {
+	gate := make(chan struct{})
+	var calls int32
+	coalescer := NewCoalescer(newGatedFetcher(gate, &calls, nil))
+	request, _ := NewDataRequestBuilder("8454000", WaterLevel).Build()
+
+	//The request that started the call goes away
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := coalescer.RetrieveData(ctx, request)
+		first <- err
+	}()
+	waitForStats(t, coalescer, func(stats CoalescingStats) bool { return stats.InFlight == 1 })
+	second := make(chan error, 1)
+	go func() {
+		_, err := coalescer.RetrieveData(context.Background(), request)
+		second <- err
+	}()
+	waitForStats(t, coalescer, func(stats CoalescingStats) bool { return stats.Saved == 1 })
+	cancel()
+	if err := <-first; err != context.Canceled {
+		t.Errorf("Expected the first request to be cancelled but got %v", err)
+	}
+	close(gate)
+	if err := <-second; err != nil {
+		t.Errorf("Expected the second request to get the data but got %v", err)
+	}
+
+	//Nobody waiting cancels the call
+	cancelled := make(chan struct{})
+	coalescer = NewCoalescer(fetcherFunc(func(ctx context.Context, request *DataRequest) (*sledgconf_demo_proto_v1.ProductDataValues, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return nil, ctx.Err()
+	}))
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := coalescer.RetrieveData(ctx, request); err != context.DeadlineExceeded {
+		t.Errorf("Expected the deadline but got %v", err)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("Expected the call to be cancelled")
+	}
+	if stats := coalescer.Stats(); stats.InFlight != 0 {
+		t.Errorf("Expected nothing in flight but got %+v", stats)
+	}
+}
@@ -0,0 +1,253 @@
+package noaaclient
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
+	sledgconf_demo_proto_v1 "github.com/mornindew/sledgeconf2021/pkg/grpc-service/genProto"
+)
+
+//DefaultDatumRefreshInterval - how long a station's datums are used before they are pulled again.  They only change when a new tidal epoch is accepted
+const DefaultDatumRefreshInterval = 24 * time.Hour
+
+//DefaultDatumFailureInterval - how long a failed pull of a station's datums is remembered before asking again.  Keeps every water level request from going to the metadata API first while it is down
+const DefaultDatumFailureInterval = time.Minute
+
+//DefaultBaseDatums - the datums that the water levels are fetched in.  The first one that the station has is used.  Tidal stations have MLLW and the great lakes have IGLD and LWD
+var DefaultBaseDatums = []Datum{MLLW, IGLD, LWD}
+
+//DatumSource - where a station's datums come from (e.g. a NoaaClient)
+type DatumSource interface {
+	RetrieveStationDatums(ctx context.Context, stationID string, units MeasurementUnit) (*StationDatums, error)
+}
+
+//DatumHeight - how high the datum is above the station datum.  False if the station doesn't have it.  The station datum is always 0
+func (object *StationDatums) DatumHeight(datum Datum) (float64, bool) {
+	switch datum {
+	case STND:
+		return 0, true
+	case NAVD:
+		//The metadata API names the epoch that it is
+		return object.Datum("NAVD88")
+	}
+	return object.Datum(datum.String())
+}
+
+//DatumOffset - what to add to a water level in one datum to have it in the other
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	InvalidData - the station doesn't have one of the datums
+func DatumOffset(stationDatums *StationDatums, stationID string, from, to Datum) (float64, error) {
+	//Precondition
+	if stationDatums == nil {
+		return 0, customerrors.PreconditionError{Msg: "Empty Mandatory Values"}
+	}
+	fromHeight, ok := stationDatums.DatumHeight(from)
+	if !ok {
+		return 0, customerrors.InvalidData{Msg: "Can't convert from " + from.String() + " because station " + stationID + " doesn't have that datum", InternalErrorCode: 1168}
+	}
+	toHeight, ok := stationDatums.DatumHeight(to)
+	if !ok {
+		return 0, customerrors.InvalidData{Msg: "Can't convert to " + to.String() + " because station " + stationID + " doesn't have that datum", InternalErrorCode: 1168}
+	}
+	return fromHeight - toHeight, nil
+}
+
+//ConvertDatum - moves the water levels in the product data by the offset (see DatumOffset).  Both the raw strings and the typed data are moved.  Missing values stay missing
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	InvalidData - the product isn't relative to a datum
+//	BadFormat - a value couldn't be parsed
+func ConvertDatum(productData *sledgconf_demo_proto_v1.ProductDataValues, offset float64) error {
+	//Precondition
+	if productData == nil {
+		return customerrors.PreconditionError{Msg: "Empty Mandatory Values"}
+	}
+	dataProduct := ConvertGrpcEnumToDataProduct(productData.DataType)
+	if !dataProduct.convertsDatum() {
+		return customerrors.InvalidData{Msg: dataProduct.String() + " can't be converted to another datum", InternalErrorCode: 1169}
+	}
+	//Parse everything first so nothing is half converted
+	values := make([]string, len(productData.Data))
+	for index, dataPoint := range productData.Data {
+		if dataPoint.V == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(dataPoint.V, 64)
+		if err != nil {
+			return customerrors.BadFormat{Msg: "Unable to parse the water level " + dataPoint.V}
+		}
+		values[index] = strconv.FormatFloat(value+offset, 'f', 3, 64)
+	}
+	for index, dataPoint := range productData.Data {
+		dataPoint.V = values[index]
+	}
+	for _, typedData := range productData.TypedData {
+		if !typedData.Missing {
+			typedData.Value += offset
+		}
+	}
+	return nil
+}
+
+//DatumConverter - fetches the water levels in one base datum per station and converts them to the datum that was asked for locally.  Every datum shares the same call (and cache entry) so MLLW, MHHW and NAVD only go to Noaa once
+//
+//The station's datums are pulled once per refresh interval.  When they can't be pulled the old ones are used (or the request goes to Noaa as is) and the metadata API isn't asked again until the failure interval is up.  Safe to use from many go routines at once
+type DatumConverter struct {
+	fetcher         DataFetcher
+	source          DatumSource
+	baseDatums      []Datum
+	interval        time.Duration
+	failureInterval time.Duration
+	lock            sync.Mutex
+	stations        map[string]*stationDatumsEntry
+	//now - swapped out in the tests
+	now func() time.Time
+}
+
+//NewDatumConverter - Constructor.  Wraps the fetcher that gets the data (e.g. a Coalescer).  Uses the DefaultBaseDatums, the DefaultDatumRefreshInterval and the DefaultDatumFailureInterval
+func NewDatumConverter(fetcher DataFetcher, source DatumSource) *DatumConverter {
+	return &DatumConverter{fetcher: fetcher, source: source, baseDatums: DefaultBaseDatums, interval: DefaultDatumRefreshInterval, failureInterval: DefaultDatumFailureInterval, stations: make(map[string]*stationDatumsEntry), now: time.Now}
+}
+
+//RetrieveData - fetches the water levels in the station's base datum and converts them.  Products that aren't relative to a datum (and requests without a datum or units) go straight through
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	InvalidData - the station doesn't have the datum that was asked for
+//	BadFormat - a water level couldn't be parsed
+//	Anything that the fetcher returns
+func (object *DatumConverter) RetrieveData(ctx context.Context, request *DataRequest) (*sledgconf_demo_proto_v1.ProductDataValues, error) {
+	//Precondition
+	if ctx == nil || request == nil {
+		return nil, customerrors.PreconditionError{Msg: "Empty Mandatory Values"}
+	}
+	if !request.Product.convertsDatum() || request.Datum == nil || request.Units == nil {
+		return object.fetcher.RetrieveData(ctx, request)
+	}
+	stationDatums, err := object.stationDatums(ctx, request.StationID, *request.Units)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		//Noaa converts it instead
+		return object.fetcher.RetrieveData(ctx, request)
+	}
+	baseDatum, ok := object.baseDatum(stationDatums)
+	if !ok || baseDatum == *request.Datum {
+		return object.fetcher.RetrieveData(ctx, request)
+	}
+	//Check before calling so a datum the station doesn't have fails fast
+	offset, err := DatumOffset(stationDatums, request.StationID, baseDatum, *request.Datum)
+	if err != nil {
+		return nil, err
+	}
+	baseRequest := *request
+	baseRequest.Datum = &baseDatum
+	productData, err := object.fetcher.RetrieveData(ctx, &baseRequest)
+	if err != nil || productData == nil {
+		return productData, err
+	}
+	err = ConvertDatum(productData, offset)
+	if err != nil {
+		return nil, err
+	}
+	return productData, nil
+}
+
+///INTERNAL FUNCTIONS
+
+//stationDatumsEntry - a station's datums.  Done is closed once the pull has finished so everyone asking at the same time shares it.  A failed pull keeps the old datums (if any) along with the error
+type stationDatumsEntry struct {
+	done    chan struct{}
+	datums  *StationDatums
+	err     error
+	fetched time.Time
+}
+
+//result - what the callers get.  The old datums win over the error
+func (object *stationDatumsEntry) result() (*StationDatums, error) {
+	if object.datums != nil {
+		return object.datums, nil
+	}
+	return nil, object.err
+}
+
+//convertsDatum - the products that can be moved to another datum.  The means are left to Noaa since a monthly mean carries every datum for the month
+func (enum DataProduct) convertsDatum() bool {
+	switch enum {
+	case WaterLevel, OneMinuteWaterLevel, HourlyHeight, HighLow, Preditions:
+		return true
+	default:
+		return false
+	}
+}
+
+//baseDatum - the first of the base datums that the station has
+func (object *DatumConverter) baseDatum(stationDatums *StationDatums) (Datum, bool) {
+	for _, datum := range object.baseDatums {
+		if _, ok := stationDatums.DatumHeight(datum); ok {
+			return datum, true
+		}
+	}
+	return -1, false
+}
+
+//stationDatums - the station's datums in the units.  Pulled if they aren't there or are older than the refresh interval.  When the pull fails the old ones are used if there are any and the failure is remembered for the failure interval
+//
+//The pull isn't tied to the caller that started it so everyone waiting on it still gets the datums if that caller gives up
+func (object *DatumConverter) stationDatums(ctx context.Context, stationID string, units MeasurementUnit) (*StationDatums, error) {
+	key := stationID + "/" + units.String()
+	object.lock.Lock()
+	entry, ok := object.stations[key]
+	if ok {
+		select {
+		case <-entry.done:
+			if entry.err == nil && object.now().Sub(entry.fetched) < object.interval {
+				object.lock.Unlock()
+				return entry.datums, nil
+			}
+			if entry.err != nil && object.now().Sub(entry.fetched) < object.failureInterval {
+				object.lock.Unlock()
+				return entry.result()
+			}
+		default:
+			//Someone else is pulling them
+			object.lock.Unlock()
+			return object.wait(ctx, entry)
+		}
+	}
+	previous := entry
+	entry = &stationDatumsEntry{done: make(chan struct{})}
+	object.stations[key] = entry
+	object.lock.Unlock()
+
+	go object.pull(context.WithoutCancel(ctx), entry, previous, stationID, units)
+	return object.wait(ctx, entry)
+}
+
+//pull - pulls the station's datums into the entry and closes it.  Keeps the old datums when the pull fails
+func (object *DatumConverter) pull(ctx context.Context, entry, previous *stationDatumsEntry, stationID string, units MeasurementUnit) {
+	defer close(entry.done)
+	entry.datums, entry.err = object.source.RetrieveStationDatums(ctx, stationID, units)
+	entry.fetched = object.now()
+	if entry.err != nil && previous != nil {
+		//Keep the old ones until the pull works again
+		entry.datums = previous.datums
+	}
+}
+
+//wait - waits on a pull
+func (object *DatumConverter) wait(ctx context.Context, entry *stationDatumsEntry) (*StationDatums, error) {
+	select {
+	case <-entry.done:
+		return entry.result()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
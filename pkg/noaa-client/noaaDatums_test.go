@@ -0,0 +1,231 @@
+package noaaclient
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
+	sledgconf_demo_proto_v1 "github.com/mornindew/sledgeconf2021/pkg/grpc-service/genProto"
+)
+
+//recordingFetcher - sends back the same water levels for every request and keeps the requests
+type recordingFetcher struct {
+	lock     sync.Mutex
+	requests []DataRequest
+}
+
+func (object *recordingFetcher) RetrieveData(ctx context.Context, request *DataRequest) (*sledgconf_demo_proto_v1.ProductDataValues, error) {
+	object.lock.Lock()
+	object.requests = append(object.requests, *request)
+	object.lock.Unlock()
+	return &sledgconf_demo_proto_v1.ProductDataValues{
+		DataType:  request.Product.ConvertToGrpcEnum(),
+		Data:      []*sledgconf_demo_proto_v1.Data{{T: "2021-08-20 15:00", V: "1.234"}, {T: "2021-08-20 15:06", V: ""}},
+		TypedData: []*sledgconf_demo_proto_v1.TypedData{{Value: 1.234}, {Missing: true}},
+	}, nil
+}
+
+//failingDatumSource - Noaa is down
+type failingDatumSource struct{}
+
+func (object failingDatumSource) RetrieveStationDatums(ctx context.Context, stationID string, units MeasurementUnit) (*StationDatums, error) {
+	return nil, customerrors.ServiceUnavailableError{Msg: "Down"}
+}
+
+//TestDatumOffset - the offsets between datums come from their heights above the station datum
+func TestDatumOffset(t *testing.T) {
+	stationDatums := &StationDatums{Datums: []DatumValue{{Name: "MHHW", Value: 3.096}, {Name: "MLLW", Value: 1.695}, {Name: "NAVD88", Value: 2.554}}}
+	for _, test := range []struct {
+		from, to Datum
+		offset   float64
+	}{{MLLW, MHHW, -1.401}, {MHHW, MLLW, 1.401}, {MLLW, NAVD, -0.859}, {MLLW, STND, 1.695}, {MLLW, MLLW, 0}} {
+		offset, err := DatumOffset(stationDatums, "8454000", test.from, test.to)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if math.Abs(offset-test.offset) > 0.0000001 {
+			t.Errorf("Expected %f from %s to %s but got %f", test.offset, test.from, test.to, offset)
+		}
+	}
+	if _, err := DatumOffset(stationDatums, "8454000", MLLW, IGLD); !isInvalidData(err) {
+		t.Errorf("Expected invalid data but got %v", err)
+	}
+}
+
+//TestConvertDatum - the raw strings and typed data move and missing values stay missing
+func TestConvertDatum(t *testing.T) {
+	productData, _ := (&recordingFetcher{}).RetrieveData(context.Background(), &DataRequest{Product: HighLow})
+	if err := ConvertDatum(productData, -1.401); err != nil {
+		t.Fatal(err.Error())
+	}
+	if productData.Data[0].V != "-0.167" || productData.Data[1].V != "" || math.Abs(productData.TypedData[0].Value+0.167) > 0.0000001 || productData.TypedData[1].Value != 0 {
+		t.Errorf("Unexpected converted data %+v %+v", productData.Data, productData.TypedData)
+	}
+
+	wind := &sledgconf_demo_proto_v1.ProductDataValues{DataType: Wind.ConvertToGrpcEnum()}
+	if err := ConvertDatum(wind, 1); !isInvalidData(err) {
+		t.Errorf("Expected invalid data but got %v", err)
+	}
+	productData.Data[0].V = "junk"
+	if err := ConvertDatum(productData, 1); !isBadFormat(err) || productData.Data[0].V != "junk" {
+		t.Errorf("Expected a bad format with nothing converted but got %v", err)
+	}
+}
+
+//TestDatumConverter - every datum is fetched in MLLW and the datums are only pulled once
+func TestDatumConverter(t *testing.T) {
+	var urls []string
+	server := newMetadataServer(&urls)
+	defer server.Close()
+	fetcher := &recordingFetcher{}
+	converter := NewDatumConverter(fetcher, newMetadataClient(server))
+
+	for _, datum := range []Datum{MHHW, NAVD, MLLW, STND} {
+		request, err := NewDataRequestBuilder("8454000", Preditions).Datum(datum).Units(Metric).Build()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		productData, err := converter.RetrieveData(context.Background(), request)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		expected := map[Datum]string{MHHW: "-0.276", NAVD: "0.349", MLLW: "1.234", STND: "2.929"}[datum]
+		if productData.Data[0].V != expected {
+			t.Errorf("Expected %s in %s but got %s", expected, datum, productData.Data[0].V)
+		}
+	}
+	if len(urls) != 1 || len(fetcher.requests) != 4 {
+		t.Fatalf("Expected the datums to be pulled once for 4 requests but got %v and %d", urls, len(fetcher.requests))
+	}
+	for _, request := range fetcher.requests {
+		if *request.Datum != MLLW {
+			t.Errorf("Expected everything to be fetched in MLLW but got %s", request.Datum)
+		}
+	}
+
+	//The station doesn't have IGLD so Noaa isn't asked
+	request, _ := NewDataRequestBuilder("8454000", WaterLevel).Datum(IGLD).Units(Metric).Build()
+	if _, err := converter.RetrieveData(context.Background(), request); !isInvalidData(err) || len(fetcher.requests) != 4 {
+		t.Errorf("Expected invalid data without a call but got %v", err)
+	}
+
+	//Products that aren't relative to a datum go straight through
+	request, _ = NewDataRequestBuilder("8454000", MonthlyMean).Datum(MHHW).Units(Metric).Build()
+	if _, err := converter.RetrieveData(context.Background(), request); err != nil || *fetcher.requests[4].Datum != MHHW {
+		t.Errorf("Expected the monthly mean to be fetched in MHHW but got %v", err)
+	}
+
+	//Without the datums Noaa converts it
+	converter = NewDatumConverter(fetcher, failingDatumSource{})
+	request, _ = NewDataRequestBuilder("8454000", WaterLevel).Datum(MHHW).Units(Metric).Build()
+	productData, err := converter.RetrieveData(context.Background(), request)
+	if err != nil || productData.Data[0].V != "1.234" || *fetcher.requests[5].Datum != MHHW {
+		t.Errorf("Expected the request to go through as is but got %v", err)
+	}
+}
+
+//TestDatumConverterFailure - a failed pull is remembered for the failure interval and the old datums are used until the pull works again
+func TestDatumConverterFailure(t *testing.T) {
+	source := &countingDatumSource{}
+	converter := NewDatumConverter(&recordingFetcher{}, source)
+	now := time.Date(2021, 8, 20, 12, 0, 0, 0, time.UTC)
+	converter.now = func() time.Time { return now }
+
+	source.setErr(customerrors.ServiceUnavailableError{Msg: "Down"})
+	for index := 0; index < 3; index++ {
+		if _, err := converter.stationDatums(context.Background(), "8454000", Metric); err == nil {
+			t.Error("Expected the failure")
+		}
+	}
+	if source.count() != 1 {
+		t.Errorf("Expected the failure to be remembered but got %d pulls", source.count())
+	}
+
+	//Once it works the datums are used for the refresh interval and kept when the next pull fails
+	now = now.Add(DefaultDatumFailureInterval)
+	source.setErr(nil)
+	if stationDatums, err := converter.stationDatums(context.Background(), "8454000", Metric); err != nil || stationDatums == nil || source.count() != 2 {
+		t.Fatalf("Expected the datums after 2 pulls but got %d and %v", source.count(), err)
+	}
+	now = now.Add(DefaultDatumRefreshInterval)
+	source.setErr(customerrors.ServiceUnavailableError{Msg: "Down"})
+	for index := 0; index < 3; index++ {
+		if stationDatums, err := converter.stationDatums(context.Background(), "8454000", Metric); err != nil || stationDatums == nil {
+			t.Errorf("Expected the old datums but got %v", err)
+		}
+	}
+	if source.count() != 3 {
+		t.Errorf("Expected one more pull but got %d", source.count())
+	}
+}
+
+//TestDatumConverterDetachedPull - the caller that started the pull giving up doesn't fail everyone else waiting on it
+func TestDatumConverterDetachedPull(t *testing.T) {
+	source := &countingDatumSource{release: make(chan struct{})}
+	converter := NewDatumConverter(&recordingFetcher{}, source)
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error)
+	go func() {
+		_, err := converter.stationDatums(ctx, "8454000", Metric)
+		first <- err
+	}()
+	for source.count() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	second := make(chan error)
+	go func() {
+		_, err := converter.stationDatums(context.Background(), "8454000", Metric)
+		second <- err
+	}()
+	cancel()
+	if err := <-first; err != context.Canceled {
+		t.Errorf("Expected the first caller to give up but got %v", err)
+	}
+	close(source.release)
+	if err := <-second; err != nil || source.count() != 1 {
+		t.Errorf("Expected the second caller to get the datums from the one pull but got %d pulls and %v", source.count(), err)
+	}
+}
+
+//countingDatumSource - counts the pulls and fails when err is set.  Holds each pull until release is closed when there is one
+type countingDatumSource struct {
+	lock    sync.Mutex
+	calls   int
+	err     error
+	release chan struct{}
+}
+
+func (object *countingDatumSource) RetrieveStationDatums(ctx context.Context, stationID string, units MeasurementUnit) (*StationDatums, error) {
+	object.lock.Lock()
+	object.calls++
+	err := object.err
+	object.lock.Unlock()
+	if object.release != nil {
+		<-object.release
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &StationDatums{}, nil
+}
+
+func (object *countingDatumSource) setErr(err error) {
+	object.lock.Lock()
+	defer object.lock.Unlock()
+	object.err = err
+}
+
+func (object *countingDatumSource) count() int {
+	object.lock.Lock()
+	defer object.lock.Unlock()
+	return object.calls
+}
+
+//isInvalidData - whether the error is an InvalidData
+func isInvalidData(err error) bool {
+	_, ok := err.(customerrors.InvalidData)
+	return ok
+}
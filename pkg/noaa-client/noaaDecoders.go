@@ -0,0 +1,316 @@
+package noaaclient
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+
+	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
+	sledgconf_demo_proto_v1 "github.com/mornindew/sledgeconf2021/pkg/grpc-service/genProto"
+)
+
+//DefaultMaxResponseSize - the largest body from Noaa that will be decoded.  A year of 6 minute water levels is around 10MB so this leaves plenty of room
+const DefaultMaxResponseSize int64 = 64 << 20
+
+//errResponseTooLarge - returned by the limitedReader once the body goes past the max size
+var errResponseTooLarge = errors.New("response is too large")
+
+//rowDecoder - function that knows how to decode the next row of one product's response into a data point
+type rowDecoder func(decoder *json.Decoder) (*sledgconf_demo_proto_v1.Data, error)
+
+//decoderForProduct - returns the decoder that understands the JSON shape for the data product's rows
+//
+//Most of the observed products share the same shape so they share a decoder
+func decoderForProduct(dataProduct DataProduct) rowDecoder {
+	switch dataProduct {
+	case MonthlyMean:
+		return decodeMonthlyMean
+	case Preditions:
+		return decodePrediction
+	case Datums:
+		return decodeDatum
+	case CurrentsPredictions:
+		return decodeCurrentPrediction
+	default:
+		return decodeObservation
+	}
+}
+
+//decodeObservation - handles water level, met, hourly height, high/low, daily mean, one minute and currents data
+func decodeObservation(decoder *json.Decoder) (*sledgconf_demo_proto_v1.Data, error) {
+	row := observationRow{}
+	err := decoder.Decode(&row)
+	if err != nil {
+		return nil, err
+	}
+	return &sledgconf_demo_proto_v1.Data{
+		T:  string(row.T),
+		V:  string(row.V),
+		F:  string(row.F),
+		S:  string(row.S),
+		D:  string(row.D),
+		Dr: string(row.DR),
+		G:  string(row.G),
+		Q:  string(row.Q),
+		Ty: string(row.Ty),
+		B:  string(row.B),
+	}, nil
+}
+
+//decodeMonthlyMean - each month becomes one data point.  The time is the year and month, the value is MSL and every column is kept in the values map
+func decodeMonthlyMean(decoder *json.Decoder) (*sledgconf_demo_proto_v1.Data, error) {
+	row := monthlyMeanRow{}
+	err := decoder.Decode(&row)
+	if err != nil {
+		return nil, err
+	}
+	dataPoint := &sledgconf_demo_proto_v1.Data{V: string(row["MSL"]), Values: make(map[string]string)}
+	//Month comes through without a leading zero
+	month := string(row["month"])
+	if len(month) == 1 {
+		month = "0" + month
+	}
+	dataPoint.T = string(row["year"]) + "-" + month
+	//Keep everything else
+	for key, value := range row {
+		if key == "year" || key == "month" {
+			continue
+		}
+		dataPoint.Values[key] = string(value)
+	}
+	return dataPoint, nil
+}
+
+//decodePrediction - tide predictions.  Hilo predictions carry the type (H or L)
+func decodePrediction(decoder *json.Decoder) (*sledgconf_demo_proto_v1.Data, error) {
+	row := predictionRow{}
+	err := decoder.Decode(&row)
+	if err != nil {
+		return nil, err
+	}
+	return &sledgconf_demo_proto_v1.Data{T: string(row.T), V: string(row.V), Ty: string(row.Type)}, nil
+}
+
+//decodeDatum - each datum becomes a data point with the name and the value.  There is no time on a datum
+func decodeDatum(decoder *json.Decoder) (*sledgconf_demo_proto_v1.Data, error) {
+	row := datumRow{}
+	err := decoder.Decode(&row)
+	if err != nil {
+		return nil, err
+	}
+	return &sledgconf_demo_proto_v1.Data{N: string(row.N), V: string(row.V)}, nil
+}
+
+//decodeCurrentPrediction - the value is the velocity along the major axis.  Flood/ebb directions and depth go into the values map
+func decodeCurrentPrediction(decoder *json.Decoder) (*sledgconf_demo_proto_v1.Data, error) {
+	row := currentPredictionRow{}
+	err := decoder.Decode(&row)
+	if err != nil {
+		return nil, err
+	}
+	dataPoint := &sledgconf_demo_proto_v1.Data{
+		T:      string(row.Time),
+		V:      string(row.VelocityMajor),
+		B:      string(row.Bin),
+		Ty:     string(row.Type),
+		Values: make(map[string]string),
+	}
+	if row.MeanFloodDir != "" {
+		dataPoint.Values["meanFloodDir"] = string(row.MeanFloodDir)
+	}
+	if row.MeanEbbDir != "" {
+		dataPoint.Values["meanEbbDir"] = string(row.MeanEbbDir)
+	}
+	if row.Depth != "" {
+		dataPoint.Values["depth"] = string(row.Depth)
+	}
+	return dataPoint, nil
+}
+
+//decodeProductResponse - streams the body and appends each row straight into the product data so the raw body is never held in memory
+//
+//Checks for the error message that Noaa sometimes sends back on a 200.  A 200 with an error message is treated the same as a 400 (empty values).  Anything less than 1 for the max size uses the default
+//
+//	Errors:
+//	BadFormat - the body is malformed, truncated, or larger than the max size
+func decodeProductResponse(dataProduct DataProduct, body io.Reader, maxSize int64) (*sledgconf_demo_proto_v1.ProductDataValues, error) {
+	if maxSize < 1 {
+		maxSize = DefaultMaxResponseSize
+	}
+	reader := &limitedReader{reader: body, remaining: maxSize}
+	stream := &responseStream{
+		decoder:     json.NewDecoder(reader),
+		decodeRow:   decoderForProduct(dataProduct),
+		productData: &sledgconf_demo_proto_v1.ProductDataValues{DataType: dataProduct.ConvertToGrpcEnum()},
+	}
+	err := stream.decode()
+	switch {
+	case errors.Is(err, errResponseTooLarge):
+		return nil, customerrors.BadFormat{Msg: "The response for " + dataProduct.String() + " is larger than the " + strconv.FormatInt(maxSize, 10) + " byte limit"}
+	case reader.truncated(err, maxSize):
+		return nil, customerrors.BadFormat{Msg: "The response for " + dataProduct.String() + " was truncated"}
+	case err != nil:
+		return nil, customerrors.BadFormat{Msg: "Unable to decode " + dataProduct.String() + ": " + err.Error()}
+	}
+	if stream.errorMessage != "" {
+		return &sledgconf_demo_proto_v1.ProductDataValues{DataType: dataProduct.ConvertToGrpcEnum()}, nil
+	}
+	return stream.productData, nil
+}
+
+//convertToGrpc - nil safe as some products don't send metadata
+func (object *responseMetadata) convertToGrpc() *sledgconf_demo_proto_v1.Metadata {
+	if object == nil {
+		return nil
+	}
+	return &sledgconf_demo_proto_v1.Metadata{Id: object.ID, Name: object.Name, Lat: object.Lat, Lon: object.Lon}
+}
+
+///INTERNAL FUNCTIONS
+
+//responseStream - walks the tokens of a response.  Only the current row is decoded at a time
+type responseStream struct {
+	decoder      *json.Decoder
+	decodeRow    rowDecoder
+	productData  *sledgconf_demo_proto_v1.ProductDataValues
+	errorMessage string
+}
+
+//decode - the response is a single object.  Anything after it means the body is malformed
+func (object *responseStream) decode() error {
+	err := object.decodeObject()
+	if err != nil {
+		return err
+	}
+	_, err = object.decoder.Token()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return errors.New("unexpected data after the response")
+}
+
+//decodeObject - handles the keys that we know about and skips the rest.  Current predictions nest their rows in another object
+func (object *responseStream) decodeObject() error {
+	err := object.expectDelim('{')
+	if err != nil {
+		return err
+	}
+	for object.decoder.More() {
+		token, err := object.decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch token {
+		case observationsKey, predictionsKey, datumsKey, currentRowsKey:
+			err = object.decodeRows()
+		case currentPredictionsKey:
+			err = object.decodeObject()
+		case metadataKey:
+			var metadata *responseMetadata
+			err = object.decoder.Decode(&metadata)
+			object.productData.Metadata = metadata.convertToGrpc()
+		case errorKey:
+			errorObject := &ErrorResponse{}
+			err = object.decoder.Decode(&errorObject.Error)
+			object.errorMessage = errorObject.Error.Message
+		default:
+			err = object.skipValue()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return object.expectDelim('}')
+}
+
+//decodeRows - appends each row of the array to the product data.  Null is the same as no rows
+func (object *responseStream) decodeRows() error {
+	token, err := object.decoder.Token()
+	if err != nil || token == nil {
+		return err
+	}
+	if token != json.Delim('[') {
+		return errors.New("expected the rows to be an array")
+	}
+	for object.decoder.More() {
+		dataPoint, err := object.decodeRow(object.decoder)
+		if err != nil {
+			return err
+		}
+		object.productData.Data = append(object.productData.Data, dataPoint)
+	}
+	return object.expectDelim(']')
+}
+
+//skipValue - reads past a value token by token so that it isn't buffered
+func (object *responseStream) skipValue() error {
+	depth := 0
+	for {
+		token, err := object.decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+//expectDelim - the next token has to be the delimiter
+func (object *responseStream) expectDelim(delim json.Delim) error {
+	token, err := object.decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return errors.New("expected " + delim.String())
+	}
+	return nil
+}
+
+//limitedReader - like io.LimitReader but reading past the limit is an error instead of looking like the end of the body
+type limitedReader struct {
+	reader    io.Reader
+	remaining int64
+	//ended - the body has been read to the end
+	ended bool
+}
+
+//truncated - whether the decode error is from the body ending in the middle of the response
+func (object *limitedReader) truncated(err error, maxSize int64) bool {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return true
+	}
+	//The decoder reports running out of body in the middle of a token as a syntax error at the very end
+	var syntaxError *json.SyntaxError
+	return errors.As(err, &syntaxError) && object.ended && syntaxError.Offset == maxSize-object.remaining
+}
+
+func (object *limitedReader) Read(buffer []byte) (int, error) {
+	if object.remaining < 0 {
+		return 0, errResponseTooLarge
+	}
+	//One byte past the limit is enough to know that it is too big
+	if int64(len(buffer)) > object.remaining+1 {
+		buffer = buffer[:object.remaining+1]
+	}
+	read, err := object.reader.Read(buffer)
+	object.remaining -= int64(read)
+	if object.remaining < 0 {
+		return read, errResponseTooLarge
+	}
+	if err == io.EOF {
+		object.ended = true
+	}
+	return read, err
+}
@@ -0,0 +1,202 @@
+package noaaclient
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
+	sledgconf_demo_proto_v1 "github.com/mornindew/sledgeconf2021/pkg/grpc-service/genProto"
+)
+
+//loadSamplePayload - reads the recorded Noaa response for a product out of testdata
+func loadSamplePayload(t *testing.T, dataProduct DataProduct) []byte {
+	return loadTestdata(t, dataProduct.String()+".json")
+}
+
+//loadTestdata - reads a recorded response out of testdata
+func loadTestdata(t *testing.T, fileName string) []byte {
+	body, err := readTestdata(fileName)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return body
+}
+
+//readTestdata - same as loadTestdata for places that cannot fail the test (e.g. a test server's handler)
+func readTestdata(fileName string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join("testdata", fileName))
+}
+
+//TestDecodeAllProducts - every product has a recorded payload and should decode into a fully populated result
+func TestDecodeAllProducts(t *testing.T) {
+	//What we expect the first data point to look like for each product
+	expected := map[DataProduct]struct {
+		count int
+		first sledgconf_demo_proto_v1.Data
+	}{
+		WaterLevel:          {3, sledgconf_demo_proto_v1.Data{T: "2021-08-20 15:00", V: "1.234", S: "0.003", F: "0,0,0,0", Q: "p"}},
+		AirTemperature:      {2, sledgconf_demo_proto_v1.Data{T: "2021-08-20 15:00", V: "24.3", F: "0,0,0"}},
+		WaterTemperature:    {2, sledgconf_demo_proto_v1.Data{T: "2021-08-20 15:00", V: "22.1", F: "0,0,0"}},
+		Wind:                {2, sledgconf_demo_proto_v1.Data{T: "2021-08-20 15:00", S: "4.22", D: "197.00", Dr: "SSW", G: "6.36", F: "0,0"}},
+		AirPressure:         {2, sledgconf_demo_proto_v1.Data{T: "2021-08-20 15:00", V: "1016.9", F: "0,0,0"}},
+		AirGap:              {2, sledgconf_demo_proto_v1.Data{T: "2021-08-20 15:00", V: "41.127", S: "0.020", F: "0,0,0,0"}},
+		Conductivity:        {2, sledgconf_demo_proto_v1.Data{T: "2021-08-20 15:00", V: "41.32", F: "0,0,0"}},
+		Visibility:          {2, sledgconf_demo_proto_v1.Data{T: "2021-08-20 15:00", V: "5.40", F: "0,0,0"}},
+		Humidity:            {2, sledgconf_demo_proto_v1.Data{T: "2021-08-20 15:00", V: "78.0", F: "0,0,0"}},
+		Salinity:            {2, sledgconf_demo_proto_v1.Data{T: "2021-08-20 15:00", S: "26.66", G: "1.019"}},
+		HourlyHeight:        {2, sledgconf_demo_proto_v1.Data{T: "2021-07-01 00:00", V: "1.014", S: "0.008", F: "0,0"}},
+		HighLow:             {4, sledgconf_demo_proto_v1.Data{T: "2021-07-01 04:18", V: "2.153", Ty: "HH", F: "0,0"}},
+		DailyMean:           {2, sledgconf_demo_proto_v1.Data{T: "2021-08-01", V: "174.702", F: "0"}},
+		MonthlyMean:         {2, sledgconf_demo_proto_v1.Data{T: "2021-07", V: "0.920"}},
+		OneMinuteWaterLevel: {3, sledgconf_demo_proto_v1.Data{T: "2021-08-20 15:00", V: "1.234"}},
+		Preditions:          {3, sledgconf_demo_proto_v1.Data{T: "2021-08-20 15:00", V: "1.307"}},
+		Datums:              {8, sledgconf_demo_proto_v1.Data{N: "STND", V: "0.000"}},
+		Currents:            {2, sledgconf_demo_proto_v1.Data{T: "2021-08-20 15:00", S: "35.21", D: "12", B: "1"}},
+		CurrentsPredictions: {2, sledgconf_demo_proto_v1.Data{T: "2021-08-20 15:00", V: "1.12", B: "1"}},
+	}
+
+	for product := DataProduct(0); product < MaximumLimit; product++ {
+		want, ok := expected[product]
+		if !ok {
+			t.Error("No expectation for " + product.String())
+			continue
+		}
+		productData, err := decodeProductResponse(product, bytes.NewReader(loadSamplePayload(t, product)), 0)
+		if err != nil {
+			t.Error(product.String() + ": " + err.Error())
+			continue
+		}
+		if productData.DataType != product.ConvertToGrpcEnum() {
+			t.Error(product.String() + ": wrong data type " + productData.DataType.String())
+		}
+		if len(productData.Data) != want.count {
+			t.Errorf("%s: expected %d data points but got %d", product.String(), want.count, len(productData.Data))
+			continue
+		}
+		got := productData.Data[0]
+		if got.T != want.first.T || got.V != want.first.V || got.F != want.first.F || got.S != want.first.S || got.D != want.first.D ||
+			got.Dr != want.first.Dr || got.G != want.first.G || got.Q != want.first.Q || got.Ty != want.first.Ty || got.B != want.first.B || got.N != want.first.N {
+			t.Errorf("%s: first data point didn't match %+v", product.String(), *got)
+		}
+	}
+}
+
+//TestDecodeProductSpecificValues - the products with extra columns keep them in the values map
+func TestDecodeProductSpecificValues(t *testing.T) {
+	monthly, err := decodeProductResponse(MonthlyMean, bytes.NewReader(loadSamplePayload(t, MonthlyMean)), 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if monthly.Data[0].Values["MLLW"] != "0.195" || monthly.Data[0].Values["highest"] != "2.421" {
+		t.Error("Monthly mean datums were not kept")
+	}
+	if monthly.Metadata == nil || monthly.Metadata.Id != "8454000" {
+		t.Error("Monthly mean metadata was not kept")
+	}
+
+	currents, err := decodeProductResponse(CurrentsPredictions, bytes.NewReader(loadSamplePayload(t, CurrentsPredictions)), 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if currents.Data[1].V != "-0.34" || currents.Data[1].Values["meanEbbDir"] != "196" || currents.Data[1].Values["depth"] != "8" {
+		t.Error("Current predictions were not fully decoded")
+	}
+}
+
+//TestDecodeErrorOn200 - Noaa will send back an error message with a 200 when there is no data
+func TestDecodeErrorOn200(t *testing.T) {
+	body := []byte(`{"error": {"message": "No data was found. This product may not be offered at this station at the requested time."}}`)
+	productData, err := decodeProductResponse(WaterLevel, bytes.NewReader(body), 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(productData.Data) != 0 {
+		t.Error("Expected an empty response")
+	}
+}
+
+//TestDecodeBadPayloads - truncated, malformed and oversized bodies are errors instead of empty data
+func TestDecodeBadPayloads(t *testing.T) {
+	waterLevel := loadSamplePayload(t, WaterLevel)
+	tests := map[string]struct {
+		body    []byte
+		maxSize int64
+		message string
+	}{
+		"empty":         {[]byte{}, 0, "truncated"},
+		"truncated":     {[]byte(`{"data": [`), 0, "truncated"},
+		"truncated row": {waterLevel[:len(waterLevel)/2], 0, "truncated"},
+		"malformed":     {[]byte(`{"data": [{"t": "2021-08-20 15:00", "v": }]}`), 0, "Unable to decode"},
+		"not an array":  {[]byte(`{"data": {"t": "2021-08-20 15:00"}}`), 0, "Unable to decode"},
+		"trailing data": {[]byte(`{"data": []} {"data": []}`), 0, "Unable to decode"},
+		"too large":     {waterLevel, int64(len(waterLevel)) - 1, "byte limit"},
+	}
+	for name, test := range tests {
+		_, err := decodeProductResponse(WaterLevel, bytes.NewReader(test.body), test.maxSize)
+		if _, ok := err.(customerrors.BadFormat); !ok {
+			t.Errorf("%s: expected a BadFormat error but got %v", name, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.message) {
+			t.Errorf("%s: expected %q in %q", name, test.message, err.Error())
+		}
+	}
+
+	//Right at the limit is fine and keys we don't know about are skipped
+	body := []byte(`{"extra": {"nested": [1, {"a": null}]}, "metadata": null, "data": [{"t": "2021-08-20 15:00", "v": "1.234"}]}`)
+	productData, err := decodeProductResponse(WaterLevel, bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(productData.Data) != 1 || productData.Data[0].V != "1.234" || productData.Metadata != nil {
+		t.Error("Expected the one data point without metadata")
+	}
+}
+
+//TestParse200ResponseMaxSize - the client's max response size is what limits the body
+func TestParse200ResponseMaxSize(t *testing.T) {
+	server := newTestServer(t, nil)
+	defer server.Close()
+	stationID := "8454000"
+	window := NewQueryModeWindow(LatestQuery)
+	client := NewNoaaClient(MLLW, "metric", WithBaseURL(server.URL), WithMaxResponseSize(64))
+	_, err := client.RetrieveDataForWindow(context.Background(), window, GMT, WaterLevel, &stationID)
+	if _, ok := err.(customerrors.BadFormat); !ok {
+		t.Errorf("Expected a BadFormat error but got %v", err)
+	}
+	productData, err := NewNoaaClient(MLLW, "metric", WithBaseURL(server.URL)).RetrieveDataForWindow(context.Background(), window, GMT, WaterLevel, &stationID)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(productData.Data) != 3 {
+		t.Errorf("Expected 3 data points but got %d", len(productData.Data))
+	}
+}
+
+//BenchmarkDecodeYearOfWaterLevels - a year of 6 minute water levels.  Run with -benchmem to see the memory used
+func BenchmarkDecodeYearOfWaterLevels(b *testing.B) {
+	var body bytes.Buffer
+	body.WriteString(`{"metadata": {"id": "8454000", "name": "Providence", "lat": "41.8071", "lon": "-71.4012"}, "data": [`)
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	for index := 0; index < 365*24*10; index++ {
+		if index > 0 {
+			body.WriteString(",")
+		}
+		body.WriteString(`{"t": "` + start.Add(time.Duration(index)*6*time.Minute).Format("2006-01-02 15:04") + `", "v": "1.234", "s": "0.003", "f": "0,0,0,0", "q": "v"}`)
+	}
+	body.WriteString(`]}`)
+	b.SetBytes(int64(body.Len()))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for index := 0; index < b.N; index++ {
+		_, err := decodeProductResponse(WaterLevel, bytes.NewReader(body.Bytes()), 0)
+		if err != nil {
+			b.Fatal(err.Error())
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package noaaclient
 
 import (
+	"time"
+
 	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
 	sledgconf_demo_proto_v1 "github.com/mornindew/sledgeconf2021/pkg/grpc-service/genProto"
 )
@@ -77,6 +79,24 @@ func (enum DataProduct) String() string {
 	}
 }
 
+//MaximumRequestWindow - the longest window that Noaa will return in a single request for the product.  Zero means the product doesn't take a window
+func (enum DataProduct) MaximumRequestWindow() time.Duration {
+	day := 24 * time.Hour
+	switch enum {
+	case OneMinuteWaterLevel:
+		return 4 * day
+	case HourlyHeight, HighLow, Preditions:
+		return 365 * day
+	case DailyMean, MonthlyMean:
+		return 10 * 365 * day
+	case Datums:
+		return 0
+	default:
+		//All the 6 minute products
+		return 31 * day
+	}
+}
+
 func (enum DataProduct) ConvertToGrpcEnum() sledgconf_demo_proto_v1.DataType {
 	switch enum {
 	case WaterLevel:
@@ -123,6 +143,22 @@ func (enum DataProduct) ConvertToGrpcEnum() sledgconf_demo_proto_v1.DataType {
 	}
 }
 
+//ConvertStringDataProductToEnum - Helper Function to convert from Noaa's product name (e.g. water_level) to the data product
+func ConvertStringDataProductToEnum(val string) (DataProduct, error) {
+	for product := DataProduct(0); product < MaximumLimit; product++ {
+		if product.String() == val {
+			return product, nil
+		}
+	}
+	//Handle something not matching
+	return -1, customerrors.InvalidData{Msg: "Not a valid data product"}
+}
+
+//ConvertGrpcEnumToDataProduct - goes the other way.  The protobuf enum is kept in the same order as the data products so it is a straight cast
+func ConvertGrpcEnumToDataProduct(val sledgconf_demo_proto_v1.DataType) DataProduct {
+	return DataProduct(val)
+}
+
 //Datum - enum for the specific datums for Noaa
 type Datum int
 
@@ -187,3 +223,158 @@ const (
 func (enum MeasurementUnit) String() string {
 	return []string{"english", "metric"}[enum]
 }
+
+//QueryMode - enum for the different ways to address the time window on a Noaa request
+type QueryMode int
+
+const (
+	DateRangeQuery QueryMode = iota
+	LatestQuery
+	TodayQuery
+	RecentQuery
+	RangeQuery
+)
+
+func (enum QueryMode) String() string {
+	switch enum {
+	case DateRangeQuery:
+		return "daterange"
+	case LatestQuery:
+		return "latest"
+	case TodayQuery:
+		return "today"
+	case RecentQuery:
+		return "recent"
+	case RangeQuery:
+		return "range"
+	default:
+		return ""
+	}
+}
+
+//ConvertStringQueryModeToEnum - Helper Function to convert from string to query mode.  An empty string is a date range
+func ConvertStringQueryModeToEnum(val string) (QueryMode, error) {
+	switch val {
+	case "", "daterange":
+		return DateRangeQuery, nil
+	case "latest":
+		return LatestQuery, nil
+	case "today":
+		return TodayQuery, nil
+	case "recent":
+		return RecentQuery, nil
+	case "range":
+		return RangeQuery, nil
+	}
+	//Handle something not matching
+	return -1, customerrors.InvalidData{Msg: "Not a valid query mode"}
+}
+
+//ConvertGrpcEnumToQueryMode - the protobuf enum is kept in the same order so it is a straight cast
+func ConvertGrpcEnumToQueryMode(val sledgconf_demo_proto_v1.QueryMode) QueryMode {
+	return QueryMode(val)
+}
+
+//TimeZone - enum for the time zones that Noaa supports
+//
+//	GMT - Greenwich Mean Time
+//	LST - the station's local standard time (no daylight saving)
+//	LSTLDT - the station's local time with daylight saving
+type TimeZone int
+
+const (
+	GMT TimeZone = iota
+	LST
+	LSTLDT
+)
+
+//String - Noaa's time_zone param.  Empty when it isn't one of the time zones
+func (enum TimeZone) String() string {
+	if enum < GMT || enum > LSTLDT {
+		return ""
+	}
+	return []string{"gmt", "lst", "lst_ldt"}[enum]
+}
+
+//ConvertStringTimeZoneToEnum - Helper Function to convert from string to time zone.  An empty string is gmt
+func ConvertStringTimeZoneToEnum(val string) (TimeZone, error) {
+	switch val {
+	case "", "gmt":
+		return GMT, nil
+	case "lst":
+		return LST, nil
+	case "lst_ldt":
+		return LSTLDT, nil
+	}
+	//Handle something not matching
+	return -1, customerrors.InvalidData{Msg: "Not a valid time zone"}
+}
+
+//ConvertGrpcEnumToTimeZone - the protobuf enum is kept in the same order so it is a straight cast
+func ConvertGrpcEnumToTimeZone(val sledgconf_demo_proto_v1.TimeZone) TimeZone {
+	return TimeZone(val)
+}
+
+//Interval - enum for the interval param.  DefaultInterval leaves it off so Noaa uses the product's normal interval
+type Interval int
+
+const (
+	DefaultInterval Interval = iota
+	HourlyInterval
+	HighLowInterval
+	OneMinuteInterval
+	SixMinuteInterval
+	FifteenMinuteInterval
+	ThirtyMinuteInterval
+	SixtyMinuteInterval
+	MaxSlackInterval
+)
+
+//String - Noaa's interval param.  Empty when it isn't one of the intervals
+func (enum Interval) String() string {
+	if enum < DefaultInterval || enum > MaxSlackInterval {
+		return ""
+	}
+	return []string{"", "h", "hilo", "1", "6", "15", "30", "60", "MAX_SLACK"}[enum]
+}
+
+//VelocityType - enum for how current predictions come back.  DefaultVelocityType leaves it off
+type VelocityType int
+
+const (
+	DefaultVelocityType VelocityType = iota
+	SpeedDirectionVelocityType
+)
+
+//String - Noaa's vel_type param.  Empty when it isn't one of the velocity types
+func (enum VelocityType) String() string {
+	if enum < DefaultVelocityType || enum > SpeedDirectionVelocityType {
+		return ""
+	}
+	return []string{"default", "speed_dir"}[enum]
+}
+
+//usesDatum - the water level products are the only ones that are relative to a datum
+func (enum DataProduct) usesDatum() bool {
+	switch enum {
+	case WaterLevel, HourlyHeight, HighLow, DailyMean, MonthlyMean, OneMinuteWaterLevel, Preditions:
+		return true
+	default:
+		return false
+	}
+}
+
+//supportsInterval - which intervals Noaa accepts for the product
+func (enum DataProduct) supportsInterval(interval Interval) bool {
+	switch enum {
+	case Preditions:
+		return interval != MaxSlackInterval
+	case CurrentsPredictions:
+		return interval != HighLowInterval && interval != FifteenMinuteInterval
+	case AirTemperature, WaterTemperature, Wind, AirPressure, Conductivity, Visibility, Humidity, Salinity:
+		//The met products are 6 minute data that can be thinned to hourly
+		return interval == HourlyInterval || interval == SixMinuteInterval
+	default:
+		return false
+	}
+}
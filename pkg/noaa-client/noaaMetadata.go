@@ -0,0 +1,608 @@
+package noaaclient
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
+)
+
+//StationType - which stations the metadata API lists
+type StationType string
+
+const (
+	AllStations                 StationType = ""
+	WaterLevelStations          StationType = "waterlevels"
+	OneMinuteWaterLevelStations StationType = "1minute"
+	HistoricWaterLevelStations  StationType = "historicwl"
+	DatumStations               StationType = "datums"
+	TidePredictionStations      StationType = "tidepredictions"
+	CurrentStations             StationType = "currents"
+	CurrentPredictionStations   StationType = "currentpredictions"
+	MetStations                 StationType = "met"
+	WaterTemperatureStations    StationType = "watertemp"
+	ConductivityStations        StationType = "cond"
+	VisibilityStations          StationType = "visibility"
+	AirGapStations              StationType = "airgap"
+)
+
+//StationMetadata - a station as the metadata API describes it
+type StationMetadata struct {
+	ID        string
+	Name      string
+	State     string
+	Latitude  float64
+	Longitude float64
+	TimeZone  StationTimeZone
+	//Tidal - whether the water levels have tides (the great lakes don't)
+	Tidal      bool
+	GreatLakes bool
+	//Affiliations - the programs that the station is part of (e.g. NWLON, PORTS)
+	Affiliations []string
+	TideType     string
+	ShefCode     string
+	PortsCode    string
+	Forecast     bool
+}
+
+//StationDetails - when the station was set up and what chart it is on
+type StationDetails struct {
+	ID          string
+	Established time.Time
+	//Removed - zero if the station is still running
+	Removed   time.Time
+	NoaaChart string
+	//TimeMeridian - the meridian (in degrees) that the station's local standard time is based on
+	TimeMeridian   float64
+	TimeZoneOffset float64
+}
+
+//StationProduct - a product page that Noaa has for the station (e.g. Tides/Water Levels)
+type StationProduct struct {
+	Name string
+	Link string
+}
+
+//StationSensor - a sensor on the station
+type StationSensor struct {
+	ID   string
+	Name string
+	//RefDatum - the datum the sensor measures against.  Usually empty
+	RefDatum string
+	DCP      int
+	Active   bool
+	//Message - why the sensor isn't working
+	Message string
+}
+
+//DatumValue - the height of a datum above the station datum
+type DatumValue struct {
+	Name        string
+	Description string
+	Value       float64
+}
+
+//StationDatums - the tidal datums for the station along with the extremes.  Extremes that Noaa doesn't have are nil
+type StationDatums struct {
+	//Epoch - the tidal epoch the datums were computed over (e.g. 1983-2001)
+	Epoch                       string
+	Units                       string
+	OrthometricDatum            string
+	Accepted                    time.Time
+	Datums                      []DatumValue
+	LowestAstronomicalTide      *float64
+	LowestAstronomicalTideTime  time.Time
+	HighestAstronomicalTide     *float64
+	HighestAstronomicalTideTime time.Time
+	MinWaterLevel               *float64
+	MinWaterLevelTime           time.Time
+	MaxWaterLevel               *float64
+	MaxWaterLevelTime           time.Time
+}
+
+//Datum - the datum's value.  False if the station doesn't have it
+func (object *StationDatums) Datum(name string) (float64, bool) {
+	for _, datum := range object.Datums {
+		if datum.Name == name {
+			return datum.Value, true
+		}
+	}
+	return 0, false
+}
+
+//FloodLevels - the water levels that Noaa (NOS) and the weather service (NWS) call minor, moderate and major flooding.  Nil if there isn't a threshold
+type FloodLevels struct {
+	Units       string
+	NosMinor    *float64
+	NosModerate *float64
+	NosMajor    *float64
+	NwsMinor    *float64
+	NwsModerate *float64
+	NwsMajor    *float64
+}
+
+//HarmonicConstituent - one of the constituents that the tide predictions are built from
+type HarmonicConstituent struct {
+	Number      int
+	Name        string
+	Description string
+	Amplitude   float64
+	//PhaseGMT and PhaseLocal are in degrees
+	PhaseGMT   float64
+	PhaseLocal float64
+	//Speed - in degrees per hour
+	Speed float64
+}
+
+//RetrieveStations - lists the stations of the type from the metadata API.  AllStations lists every station
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	ServiceUnavailableError - Noaa is down
+//	InternalServerError - error from Noaa
+//	BadFormat - the response couldn't be decoded
+func (object *NoaaClient) RetrieveStations(ctx context.Context, stationType StationType) ([]StationMetadata, error) {
+	//Precondition
+	if ctx == nil {
+		return nil, customerrors.PreconditionError{Msg: "Empty Mandatory Values"}
+	}
+	params := neturl.Values{}
+	if stationType != AllStations {
+		params.Set("type", string(stationType))
+	}
+	response := &stationsResponse{}
+	err := object.retrieveMetadata(ctx, stationListPath, params, "", response)
+	if err != nil {
+		return nil, err
+	}
+	stations := make([]StationMetadata, 0, len(response.Stations))
+	for _, station := range response.Stations {
+		stationMetadata, err := station.convert()
+		if err != nil {
+			return nil, err
+		}
+		stations = append(stations, *stationMetadata)
+	}
+	return stations, nil
+}
+
+//RetrieveStation - looks up one station with the metadata API
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	NotFoundError - Noaa doesn't know the station
+//	ServiceUnavailableError - Noaa is down
+//	InternalServerError - error from Noaa
+//	BadFormat - the response couldn't be decoded
+func (object *NoaaClient) RetrieveStation(ctx context.Context, stationID string) (*StationMetadata, error) {
+	//Precondition
+	if ctx == nil || stationID == "" {
+		return nil, customerrors.PreconditionError{Msg: "Empty Mandatory Values"}
+	}
+	response := &stationsResponse{}
+	err := object.retrieveMetadata(ctx, stationsPath+neturl.PathEscape(stationID)+".json", nil, stationID, response)
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Stations) == 0 {
+		return nil, customerrors.NotFoundError{Msg: "Station not found: " + stationID}
+	}
+	return response.Stations[0].convert()
+}
+
+//RetrieveStationDetails - when the station was set up (and removed) and what chart it is on
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	NotFoundError - Noaa doesn't know the station
+//	ServiceUnavailableError - Noaa is down
+//	InternalServerError - error from Noaa
+//	BadFormat - the response couldn't be decoded
+func (object *NoaaClient) RetrieveStationDetails(ctx context.Context, stationID string) (*StationDetails, error) {
+	//Precondition
+	if ctx == nil || stationID == "" {
+		return nil, customerrors.PreconditionError{Msg: "Empty Mandatory Values"}
+	}
+	response := &struct {
+		ID           string         `json:"id"`
+		Established  flexibleString `json:"established"`
+		Removed      flexibleString `json:"removed"`
+		NoaaChart    flexibleString `json:"noaachart"`
+		TimeMeridian flexibleString `json:"timemeridian"`
+		TimeZone     flexibleString `json:"timezone"`
+	}{}
+	err := object.retrieveMetadata(ctx, stationsPath+neturl.PathEscape(stationID)+"/details.json", nil, stationID, response)
+	if err != nil {
+		return nil, err
+	}
+	details := &StationDetails{ID: response.ID, NoaaChart: string(response.NoaaChart)}
+	details.Established, err = parseMetadataTime(response.Established, "")
+	if err == nil {
+		details.Removed, err = parseMetadataTime(response.Removed, "")
+	}
+	if err == nil {
+		details.TimeMeridian, err = parseMetadataFloat(response.TimeMeridian)
+	}
+	if err == nil {
+		details.TimeZoneOffset, err = parseMetadataFloat(response.TimeZone)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return details, nil
+}
+
+//RetrieveStationProducts - the product pages that Noaa has for the station
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	NotFoundError - Noaa doesn't know the station
+//	ServiceUnavailableError - Noaa is down
+//	InternalServerError - error from Noaa
+//	BadFormat - the response couldn't be decoded
+func (object *NoaaClient) RetrieveStationProducts(ctx context.Context, stationID string) ([]StationProduct, error) {
+	//Precondition
+	if ctx == nil || stationID == "" {
+		return nil, customerrors.PreconditionError{Msg: "Empty Mandatory Values"}
+	}
+	response := &struct {
+		Products []struct {
+			Name string `json:"name"`
+			Link string `json:"link"`
+		} `json:"products"`
+	}{}
+	err := object.retrieveMetadata(ctx, stationsPath+neturl.PathEscape(stationID)+"/products.json", nil, stationID, response)
+	if err != nil {
+		return nil, err
+	}
+	products := make([]StationProduct, 0, len(response.Products))
+	for _, product := range response.Products {
+		products = append(products, StationProduct{Name: product.Name, Link: product.Link})
+	}
+	return products, nil
+}
+
+//RetrieveStationSensors - the sensors on the station and whether they are working
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	NotFoundError - Noaa doesn't know the station
+//	ServiceUnavailableError - Noaa is down
+//	InternalServerError - error from Noaa
+//	BadFormat - the response couldn't be decoded
+func (object *NoaaClient) RetrieveStationSensors(ctx context.Context, stationID string) ([]StationSensor, error) {
+	//Precondition
+	if ctx == nil || stationID == "" {
+		return nil, customerrors.PreconditionError{Msg: "Empty Mandatory Values"}
+	}
+	response := &struct {
+		Sensors []struct {
+			SensorID string         `json:"sensorID"`
+			Name     string         `json:"name"`
+			RefDatum string         `json:"refdatum"`
+			DCP      flexibleString `json:"dcp"`
+			Status   flexibleString `json:"status"`
+			Message  string         `json:"message"`
+		} `json:"sensors"`
+	}{}
+	err := object.retrieveMetadata(ctx, stationsPath+neturl.PathEscape(stationID)+"/sensors.json", nil, stationID, response)
+	if err != nil {
+		return nil, err
+	}
+	sensors := make([]StationSensor, 0, len(response.Sensors))
+	for _, sensor := range response.Sensors {
+		dcp, err := strconv.Atoi(string(sensor.DCP))
+		if err != nil {
+			return nil, customerrors.BadFormat{Msg: "Unable to decode the dcp on sensor " + sensor.SensorID}
+		}
+		//Status is 1 when the sensor is working
+		sensors = append(sensors, StationSensor{ID: sensor.SensorID, Name: sensor.Name, RefDatum: sensor.RefDatum, DCP: dcp, Active: sensor.Status == "1", Message: sensor.Message})
+	}
+	return sensors, nil
+}
+
+//RetrieveStationDatums - the tidal datums for the station in the units
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	NotFoundError - Noaa doesn't know the station
+//	ServiceUnavailableError - Noaa is down
+//	InternalServerError - error from Noaa
+//	BadFormat - the response couldn't be decoded
+func (object *NoaaClient) RetrieveStationDatums(ctx context.Context, stationID string, units MeasurementUnit) (*StationDatums, error) {
+	//Precondition
+	if ctx == nil || stationID == "" {
+		return nil, customerrors.PreconditionError{Msg: "Empty Mandatory Values"}
+	}
+	response := &struct {
+		Accepted         flexibleString `json:"accepted"`
+		Epoch            string         `json:"epoch"`
+		Units            string         `json:"units"`
+		OrthometricDatum string         `json:"OrthometricDatum"`
+		Datums           []struct {
+			Name        string         `json:"name"`
+			Description string         `json:"description"`
+			Value       flexibleString `json:"value"`
+		} `json:"datums"`
+		LAT     flexibleString `json:"LAT"`
+		LATDate flexibleString `json:"LATdate"`
+		LATTime flexibleString `json:"LATtime"`
+		HAT     flexibleString `json:"HAT"`
+		HATDate flexibleString `json:"HATdate"`
+		HATTime flexibleString `json:"HATtime"`
+		Min     flexibleString `json:"min"`
+		MinDate flexibleString `json:"mindate"`
+		MinTime flexibleString `json:"mintime"`
+		Max     flexibleString `json:"max"`
+		MaxDate flexibleString `json:"maxdate"`
+		MaxTime flexibleString `json:"maxtime"`
+	}{}
+	err := object.retrieveMetadata(ctx, stationsPath+neturl.PathEscape(stationID)+"/datums.json", unitParams(units), stationID, response)
+	if err != nil {
+		return nil, err
+	}
+	datums := &StationDatums{Epoch: response.Epoch, Units: response.Units, OrthometricDatum: response.OrthometricDatum, Datums: make([]DatumValue, 0, len(response.Datums))}
+	for _, datum := range response.Datums {
+		value, err := parseMetadataFloat(datum.Value)
+		if err != nil {
+			return nil, err
+		}
+		datums.Datums = append(datums.Datums, DatumValue{Name: datum.Name, Description: datum.Description, Value: value})
+	}
+	datums.Accepted, err = parseMetadataTime(response.Accepted, "")
+	if err != nil {
+		return nil, err
+	}
+	//Each extreme has a value with the date and time it happened
+	extremes := []struct {
+		value, date, clock flexibleString
+		valueTo            **float64
+		timeTo             *time.Time
+	}{
+		{response.LAT, response.LATDate, response.LATTime, &datums.LowestAstronomicalTide, &datums.LowestAstronomicalTideTime},
+		{response.HAT, response.HATDate, response.HATTime, &datums.HighestAstronomicalTide, &datums.HighestAstronomicalTideTime},
+		{response.Min, response.MinDate, response.MinTime, &datums.MinWaterLevel, &datums.MinWaterLevelTime},
+		{response.Max, response.MaxDate, response.MaxTime, &datums.MaxWaterLevel, &datums.MaxWaterLevelTime},
+	}
+	for _, extreme := range extremes {
+		*extreme.valueTo, err = parseOptionalMetadataFloat(extreme.value)
+		if err == nil {
+			*extreme.timeTo, err = parseMetadataTime(extreme.date, extreme.clock)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return datums, nil
+}
+
+//RetrieveFloodLevels - the flood thresholds for the station in the units
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	NotFoundError - Noaa doesn't know the station
+//	ServiceUnavailableError - Noaa is down
+//	InternalServerError - error from Noaa
+//	BadFormat - the response couldn't be decoded
+func (object *NoaaClient) RetrieveFloodLevels(ctx context.Context, stationID string, units MeasurementUnit) (*FloodLevels, error) {
+	//Precondition
+	if ctx == nil || stationID == "" {
+		return nil, customerrors.PreconditionError{Msg: "Empty Mandatory Values"}
+	}
+	response := &struct {
+		Units       string         `json:"units"`
+		NosMinor    flexibleString `json:"nos_minor"`
+		NosModerate flexibleString `json:"nos_moderate"`
+		NosMajor    flexibleString `json:"nos_major"`
+		NwsMinor    flexibleString `json:"nws_minor"`
+		NwsModerate flexibleString `json:"nws_moderate"`
+		NwsMajor    flexibleString `json:"nws_major"`
+	}{}
+	err := object.retrieveMetadata(ctx, stationsPath+neturl.PathEscape(stationID)+"/floodlevels.json", unitParams(units), stationID, response)
+	if err != nil {
+		return nil, err
+	}
+	floodLevels := &FloodLevels{Units: response.Units}
+	thresholds := []struct {
+		value   flexibleString
+		valueTo **float64
+	}{
+		{response.NosMinor, &floodLevels.NosMinor},
+		{response.NosModerate, &floodLevels.NosModerate},
+		{response.NosMajor, &floodLevels.NosMajor},
+		{response.NwsMinor, &floodLevels.NwsMinor},
+		{response.NwsModerate, &floodLevels.NwsModerate},
+		{response.NwsMajor, &floodLevels.NwsMajor},
+	}
+	for _, threshold := range thresholds {
+		*threshold.valueTo, err = parseOptionalMetadataFloat(threshold.value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return floodLevels, nil
+}
+
+//RetrieveHarmonicConstituents - the constituents that the station's tide predictions are built from.  The amplitudes are in the units
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	NotFoundError - Noaa doesn't know the station
+//	ServiceUnavailableError - Noaa is down
+//	InternalServerError - error from Noaa
+//	BadFormat - the response couldn't be decoded
+func (object *NoaaClient) RetrieveHarmonicConstituents(ctx context.Context, stationID string, units MeasurementUnit) ([]HarmonicConstituent, error) {
+	//Precondition
+	if ctx == nil || stationID == "" {
+		return nil, customerrors.PreconditionError{Msg: "Empty Mandatory Values"}
+	}
+	response := &struct {
+		HarmonicConstituents []struct {
+			Number      int            `json:"number"`
+			Name        string         `json:"name"`
+			Description string         `json:"description"`
+			Amplitude   flexibleString `json:"amplitude"`
+			PhaseGMT    flexibleString `json:"phase_GMT"`
+			PhaseLocal  flexibleString `json:"phase_local"`
+			Speed       flexibleString `json:"speed"`
+		} `json:"HarmonicConstituents"`
+	}{}
+	err := object.retrieveMetadata(ctx, stationsPath+neturl.PathEscape(stationID)+"/harcon.json", unitParams(units), stationID, response)
+	if err != nil {
+		return nil, err
+	}
+	constituents := make([]HarmonicConstituent, 0, len(response.HarmonicConstituents))
+	for _, row := range response.HarmonicConstituents {
+		constituent := HarmonicConstituent{Number: row.Number, Name: row.Name, Description: row.Description}
+		constituent.Amplitude, err = parseMetadataFloat(row.Amplitude)
+		if err == nil {
+			constituent.PhaseGMT, err = parseMetadataFloat(row.PhaseGMT)
+		}
+		if err == nil {
+			constituent.PhaseLocal, err = parseMetadataFloat(row.PhaseLocal)
+		}
+		if err == nil {
+			constituent.Speed, err = parseMetadataFloat(row.Speed)
+		}
+		if err != nil {
+			return nil, err
+		}
+		constituents = append(constituents, constituent)
+	}
+	return constituents, nil
+}
+
+///INTERNAL FUNCTIONS
+
+//metadataTimeLayouts - the metadata API uses a few different layouts for its dates
+var metadataTimeLayouts = []string{"2006-01-02 15:04:05.0", "2006-01-02 15:04:05", "2006-01-02", "20060102 15:04", "20060102"}
+
+//stationsResponse - the station list and a single station come back in the same shape
+type stationsResponse struct {
+	Stations []stationRow `json:"stations"`
+}
+
+type stationRow struct {
+	ID           string         `json:"id"`
+	Name         string         `json:"name"`
+	State        string         `json:"state"`
+	Lat          flexibleString `json:"lat"`
+	Lng          flexibleString `json:"lng"`
+	TimeZone     string         `json:"timezone"`
+	TimeZoneCorr flexibleString `json:"timezonecorr"`
+	ObservedST   bool           `json:"observedst"`
+	Tidal        bool           `json:"tidal"`
+	GreatLakes   bool           `json:"greatlakes"`
+	Affiliations string         `json:"affiliations"`
+	TideType     string         `json:"tideType"`
+	ShefCode     string         `json:"shefcode"`
+	PortsCode    string         `json:"portscode"`
+	Forecast     bool           `json:"forecast"`
+}
+
+//convert - maps the row over to the typed station
+func (object *stationRow) convert() (*StationMetadata, error) {
+	station := &StationMetadata{ID: object.ID, Name: object.Name, State: object.State, Tidal: object.Tidal, GreatLakes: object.GreatLakes, TideType: object.TideType, ShefCode: object.ShefCode, PortsCode: object.PortsCode, Forecast: object.Forecast}
+	var err error
+	station.Latitude, err = parseMetadataFloat(object.Lat)
+	if err == nil {
+		station.Longitude, err = parseMetadataFloat(object.Lng)
+	}
+	if err != nil {
+		return nil, err
+	}
+	//Not every station has a time zone (e.g. some current stations)
+	offset, err := parseOptionalMetadataFloat(object.TimeZoneCorr)
+	if err != nil {
+		return nil, err
+	}
+	station.TimeZone = StationTimeZone{Abbreviation: object.TimeZone, ObservesDST: object.ObservedST}
+	if offset != nil {
+		station.TimeZone.OffsetInHours = *offset
+	}
+	for _, affiliation := range strings.Split(object.Affiliations, ",") {
+		if affiliation = strings.TrimSpace(affiliation); affiliation != "" {
+			station.Affiliations = append(station.Affiliations, affiliation)
+		}
+	}
+	return station, nil
+}
+
+//retrieveMetadata - calls the metadata API and decodes the body into the response
+//
+//	Errors:
+//	NotFoundError - Noaa doesn't know the station
+//	ServiceUnavailableError - Noaa is down
+//	InternalServerError - error from Noaa
+//	BadFormat - the response couldn't be decoded
+func (object *NoaaClient) retrieveMetadata(ctx context.Context, path string, params neturl.Values, stationID string, response interface{}) error {
+	metadataURL := object.baseURL + path
+	if len(params) > 0 {
+		metadataURL += "?" + params.Encode()
+	}
+	resp, err := object.get(ctx, metadataURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == 404 {
+		return customerrors.NotFoundError{Msg: "Station not found: " + stationID}
+	}
+	if resp.StatusCode != 200 {
+		return customerrors.InternalServerError{Msg: "Error Calling Noaa", InternalErrorCode: resp.StatusCode}
+	}
+	err = json.NewDecoder(io.LimitReader(resp.Body, object.maxResponseSize)).Decode(response)
+	if err != nil {
+		return customerrors.BadFormat{Msg: "Unable to decode the metadata from " + path + ": " + err.Error()}
+	}
+	return nil
+}
+
+//unitParams - the units param that the datums, flood levels and harmonic constituents take
+func unitParams(units MeasurementUnit) neturl.Values {
+	return neturl.Values{"units": []string{units.String()}}
+}
+
+//parseMetadataFloat - numbers come back raw or quoted
+func parseMetadataFloat(value flexibleString) (float64, error) {
+	number, err := strconv.ParseFloat(strings.TrimSpace(string(value)), 64)
+	if err != nil {
+		return 0, customerrors.BadFormat{Msg: "Unable to decode the number " + string(value)}
+	}
+	return number, nil
+}
+
+//parseOptionalMetadataFloat - same as parseMetadataFloat but empty (or null) is nil
+func parseOptionalMetadataFloat(value flexibleString) (*float64, error) {
+	if strings.TrimSpace(string(value)) == "" {
+		return nil, nil
+	}
+	number, err := parseMetadataFloat(value)
+	if err != nil {
+		return nil, err
+	}
+	return &number, nil
+}
+
+//parseMetadataTime - the date (and the time of day when it comes separately) in UTC.  Empty is the zero time
+func parseMetadataTime(date, clock flexibleString) (time.Time, error) {
+	value := strings.TrimSpace(string(date))
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if clock != "" {
+		value += " " + strings.TrimSpace(string(clock))
+	}
+	for _, layout := range metadataTimeLayouts {
+		parsed, err := time.Parse(layout, value)
+		if err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, customerrors.BadFormat{Msg: "Unable to decode the time " + value}
+}
@@ -0,0 +1,213 @@
+package noaaclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
+)
+
+//newMetadataServer - sends back the recorded metadata for the path (e.g. .../8454000/datums.json is mdapi_datums.json) and keeps the urls that were called
+func newMetadataServer(urls *[]string) *httptest.Server {
+	var lock sync.Mutex
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		*urls = append(*urls, r.URL.String())
+		lock.Unlock()
+		fileName := ""
+		switch {
+		case r.URL.Path == stationListPath:
+			fileName = "mdapi_stations.json"
+		case r.URL.Path == stationsPath+"8454000.json":
+			fileName = "mdapi_station.json"
+		case strings.HasPrefix(r.URL.Path, stationsPath+"8454000/"):
+			fileName = "mdapi_" + strings.TrimPrefix(r.URL.Path, stationsPath+"8454000/")
+		}
+		body, err := readTestdata(fileName)
+		if fileName == "" || err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(body)
+	}))
+}
+
+//newMetadataClient - a client pointed at the server that doesn't retry or trip breakers
+func newMetadataClient(server *httptest.Server) *NoaaClient {
+	return NewNoaaClient(MLLW, Metric.String(), WithBaseURL(server.URL), WithRetryPolicy(NoRetryPolicy), WithCircuitBreakers(nil))
+}
+
+//TestRetrieveStations - the list and a single station come back typed
+func TestRetrieveStations(t *testing.T) {
+	var urls []string
+	server := newMetadataServer(&urls)
+	defer server.Close()
+	client := newMetadataClient(server)
+
+	stations, err := client.RetrieveStations(context.Background(), WaterLevelStations)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(stations) != 3 || urls[0] != stationListPath+"?type=waterlevels" {
+		t.Fatalf("Expected 3 water level stations but got %d from %v", len(stations), urls)
+	}
+	quonset := stations[2]
+	if quonset.ID != "8454049" || quonset.Name != "Quonset Point" || quonset.Latitude != 41.5868 || quonset.Longitude != -71.41 || len(quonset.Affiliations) != 1 || quonset.Affiliations[0] != "PORTS" {
+		t.Errorf("Unexpected station %+v", quonset)
+	}
+
+	station, err := client.RetrieveStation(context.Background(), "8454000")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expected := StationTimeZone{Abbreviation: "EST", OffsetInHours: -5, ObservesDST: true}
+	if station.Name != "Providence" || station.State != "RI" || station.TimeZone != expected || !station.Tidal || station.GreatLakes || station.TideType != "Semi-diurnal" || len(station.Affiliations) != 2 {
+		t.Errorf("Unexpected station %+v", station)
+	}
+	if _, err := client.RetrieveStation(context.Background(), "1234567"); !isNotFound(err) {
+		t.Errorf("Expected not found but got %v", err)
+	}
+}
+
+//TestRetrieveStationMetadata - details, products and sensors for a station
+func TestRetrieveStationMetadata(t *testing.T) {
+	var urls []string
+	server := newMetadataServer(&urls)
+	defer server.Close()
+	client := newMetadataClient(server)
+
+	details, err := client.RetrieveStationDetails(context.Background(), "8454000")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !details.Established.Equal(time.Date(1938, 6, 3, 0, 0, 0, 0, time.UTC)) || !details.Removed.IsZero() || details.NoaaChart != "13225" || details.TimeMeridian != -75 || details.TimeZoneOffset != -5 {
+		t.Errorf("Unexpected details %+v", details)
+	}
+
+	products, err := client.RetrieveStationProducts(context.Background(), "8454000")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(products) != 9 || products[0].Name != "Tides/Water Levels" || !strings.HasSuffix(products[0].Link, "?id=8454000") {
+		t.Errorf("Unexpected products %+v", products)
+	}
+
+	sensors, err := client.RetrieveStationSensors(context.Background(), "8454000")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(sensors) != 8 || sensors[0].ID != "A1" || sensors[0].Name != "Aquatrak" || !sensors[0].Active || sensors[0].DCP != 1 {
+		t.Fatalf("Unexpected sensors %+v", sensors)
+	}
+	if sensors[6].Name != "Conductivity" || sensors[6].Active || sensors[6].Message == "" {
+		t.Errorf("Expected the conductivity sensor to be down but got %+v", sensors[6])
+	}
+
+	if _, err := client.RetrieveStationSensors(context.Background(), "1234567"); !isNotFound(err) {
+		t.Errorf("Expected not found but got %v", err)
+	}
+}
+
+//TestRetrieveStationDatums - the datums, flood levels and harmonic constituents ask for the units
+func TestRetrieveStationDatums(t *testing.T) {
+	var urls []string
+	server := newMetadataServer(&urls)
+	defer server.Close()
+	client := newMetadataClient(server)
+
+	datums, err := client.RetrieveStationDatums(context.Background(), "8454000", Metric)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !strings.HasSuffix(urls[0], "/datums.json?units=metric") {
+		t.Errorf("Expected the units on %s", urls[0])
+	}
+	mllw, ok := datums.Datum("MLLW")
+	if !ok || mllw != 1.695 || len(datums.Datums) != 13 || datums.Epoch != "1983-2001" || datums.Units != "meters" || datums.OrthometricDatum != "NAVD88" {
+		t.Errorf("Unexpected datums %+v", datums)
+	}
+	if _, ok := datums.Datum("IGLD"); ok {
+		t.Error("Expected the station not to have IGLD")
+	}
+	if datums.MaxWaterLevel == nil || *datums.MaxWaterLevel != 7.068 || !datums.MaxWaterLevelTime.Equal(time.Date(1938, 9, 21, 21, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the 1938 hurricane but got %v at %s", datums.MaxWaterLevel, datums.MaxWaterLevelTime)
+	}
+	if datums.LowestAstronomicalTide == nil || !datums.Accepted.Equal(time.Date(2011, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected datums %+v", datums)
+	}
+
+	floodLevels, err := client.RetrieveFloodLevels(context.Background(), "8454000", English)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !strings.HasSuffix(urls[1], "/floodlevels.json?units=english") {
+		t.Errorf("Expected the units on %s", urls[1])
+	}
+	if floodLevels.NosMinor == nil || *floodLevels.NosMinor != 4.094 || floodLevels.NwsMajor == nil || *floodLevels.NwsMajor != 5.528 {
+		t.Errorf("Unexpected flood levels %+v", floodLevels)
+	}
+
+	constituents, err := client.RetrieveHarmonicConstituents(context.Background(), "8454000", Metric)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	m2 := constituents[0]
+	if len(constituents) != 6 || m2.Number != 1 || m2.Name != "M2" || m2.Amplitude != 0.637 || m2.PhaseGMT != 9.2 || m2.PhaseLocal != 114.5 || m2.Speed != 28.984104 {
+		t.Errorf("Unexpected constituents %+v", constituents)
+	}
+}
+
+//TestRetrieveMetadataErrors - missing thresholds are nil and junk is a bad format
+func TestRetrieveMetadataErrors(t *testing.T) {
+	body := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if body == "" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+	client := newMetadataClient(server)
+
+	body = `{"nos_minor":null,"nos_moderate":"","nos_major":"5.1","nws_minor":null,"nws_moderate":null,"nws_major":null,"units":"feet"}`
+	floodLevels, err := client.RetrieveFloodLevels(context.Background(), "8454000", English)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if floodLevels.NosMinor != nil || floodLevels.NosModerate != nil || floodLevels.NosMajor == nil || *floodLevels.NosMajor != 5.1 {
+		t.Errorf("Unexpected flood levels %+v", floodLevels)
+	}
+
+	for _, junk := range []string{`{"sensors":[{"sensorID":"A1","dcp":"one"}]}`, `{"sensors":`} {
+		body = junk
+		if _, err := client.RetrieveStationSensors(context.Background(), "8454000"); !isBadFormat(err) {
+			t.Errorf("Expected a bad format for %s but got %v", junk, err)
+		}
+	}
+
+	body = ""
+	if _, err := client.RetrieveStationDetails(context.Background(), "8454000"); err == nil {
+		t.Error("Expected the error from Noaa")
+	}
+	if _, err := client.RetrieveStationDatums(nil, "8454000", Metric); err == nil {
+		t.Error("Expected a precondition error")
+	}
+}
+
+//isNotFound - whether the error is a NotFoundError
+func isNotFound(err error) bool {
+	_, ok := err.(customerrors.NotFoundError)
+	return ok
+}
+
+//isBadFormat - whether the error is a BadFormat
+func isBadFormat(err error) bool {
+	_, ok := err.(customerrors.BadFormat)
+	return ok
+}
@@ -0,0 +1,265 @@
+package noaaclient
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
+	sledgconf_demo_proto_v1 "github.com/mornindew/sledgeconf2021/pkg/grpc-service/genProto"
+)
+
+//Names of the secondary values on an observation
+const (
+	SigmaValue           = "sigma"
+	DirectionValue       = "direction"
+	GustValue            = "gust"
+	SpecificGravityValue = "specificGravity"
+	BinValue             = "bin"
+)
+
+//Time layouts that Noaa uses.  Daily means only have a date and monthly means only have a year and month
+var observationTimeLayouts = []string{"2006-01-02 15:04", "2006-01-02", "2006-01"}
+
+//Observation - typed version of a single Noaa data point so callers don't have to parse strings
+type Observation struct {
+	Time time.Time
+	//Primary value for the product (e.g. water level, wind speed, salinity)
+	Value float64
+	//Missing - Noaa sends an empty value when the sensor didn't report.  Value will be 0
+	Missing bool
+	Flags   []bool
+	//Values - secondary numeric values keyed by name (e.g. sigma, direction, gust)
+	Values           map[string]float64
+	Type             string
+	CompassDirection string
+	Name             string
+	Quality          string
+	//QualityFlags - the flags by name for the product
+	QualityFlags QualityFlags
+	QualityLevel QualityLevel
+}
+
+//ConvertToObservations - parses the string data points into observations.  The location is the time zone that the data was requested in
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	BadFormat - a time or value couldn't be parsed
+func ConvertToObservations(productData *sledgconf_demo_proto_v1.ProductDataValues, location *time.Location) ([]Observation, error) {
+	//Precondition
+	if productData == nil || location == nil {
+		return nil, customerrors.PreconditionError{Msg: "Empty Mandatory Values"}
+	}
+	dataProduct := ConvertGrpcEnumToDataProduct(productData.DataType)
+	observations := make([]Observation, 0, len(productData.Data))
+	for _, dataPoint := range productData.Data {
+		observation, err := convertToObservation(dataProduct, dataPoint, location)
+		if err != nil {
+			return nil, err
+		}
+		observations = append(observations, observation)
+	}
+	return observations, nil
+}
+
+//ConvertToGrpc - converts the observation over to the protobuf struct
+func (object *Observation) ConvertToGrpc() *sledgconf_demo_proto_v1.TypedData {
+	typedData := &sledgconf_demo_proto_v1.TypedData{
+		Value:            object.Value,
+		Missing:          object.Missing,
+		Flags:            object.Flags,
+		Values:           object.Values,
+		Type:             object.Type,
+		CompassDirection: object.CompassDirection,
+		Name:             object.Name,
+		Quality:          object.Quality,
+		QualityFlags:     object.QualityFlags.ConvertToGrpc(),
+		QualityLevel:     object.QualityLevel.ConvertToGrpcEnum(),
+	}
+	//Datums don't have a time and we don't want to send back year 1
+	if !object.Time.IsZero() {
+		typedData.TimeEpochInSeconds = object.Time.Unix()
+		_, offset := object.Time.Zone()
+		typedData.UtcOffsetInSeconds = int32(offset)
+	}
+	return typedData
+}
+
+//ConvertObservationsToGrpc - helper to convert the whole list
+func ConvertObservationsToGrpc(observations []Observation) []*sledgconf_demo_proto_v1.TypedData {
+	typedData := make([]*sledgconf_demo_proto_v1.TypedData, 0, len(observations))
+	for index := range observations {
+		typedData = append(typedData, observations[index].ConvertToGrpc())
+	}
+	return typedData
+}
+
+//ConvertTypedDataToObservations - goes the other way and rebuilds the observations from the typed data.  The times are put back in the zone from the metadata
+//
+//If there isn't any typed data then the raw strings are parsed instead
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	BadFormat - a time or value couldn't be parsed
+func ConvertTypedDataToObservations(productData *sledgconf_demo_proto_v1.ProductDataValues) ([]Observation, error) {
+	//Precondition
+	if productData == nil {
+		return nil, customerrors.PreconditionError{Msg: "Empty Mandatory Values"}
+	}
+	location := locationForProductData(productData)
+	if len(productData.TypedData) == 0 {
+		return ConvertToObservations(productData, location)
+	}
+	observations := make([]Observation, 0, len(productData.TypedData))
+	for _, typedData := range productData.TypedData {
+		observation := Observation{
+			Value:            typedData.Value,
+			Missing:          typedData.Missing,
+			Flags:            typedData.Flags,
+			Values:           typedData.Values,
+			Type:             typedData.Type,
+			CompassDirection: typedData.CompassDirection,
+			Name:             typedData.Name,
+			Quality:          typedData.Quality,
+			QualityFlags:     ConvertGrpcToQualityFlags(typedData.QualityFlags),
+			QualityLevel:     ConvertGrpcEnumToQualityLevel(typedData.QualityLevel),
+		}
+		if typedData.TimeEpochInSeconds != 0 {
+			observation.Time = time.Unix(typedData.TimeEpochInSeconds, 0).In(location)
+		}
+		observations = append(observations, observation)
+	}
+	return observations, nil
+}
+
+///INTERNAL FUNCTIONS
+
+//locationForProductData - finds the zone that the product data is in.  Names that aren't real zones (e.g. a standard time abbreviation) use the offset from the data
+func locationForProductData(productData *sledgconf_demo_proto_v1.ProductDataValues) *time.Location {
+	if productData.Metadata == nil || productData.Metadata.TimeZoneName == "" || productData.Metadata.TimeZoneName == "UTC" {
+		return time.UTC
+	}
+	location, err := time.LoadLocation(productData.Metadata.TimeZoneName)
+	if err == nil {
+		return location
+	}
+	offset := 0
+	if len(productData.TypedData) > 0 {
+		offset = int(productData.TypedData[0].UtcOffsetInSeconds)
+	}
+	return time.FixedZone(productData.Metadata.TimeZoneName, offset)
+}
+
+//convertToObservation - the primary value moves around depending on the product so this knows where to look
+func convertToObservation(dataProduct DataProduct, dataPoint *sledgconf_demo_proto_v1.Data, location *time.Location) (Observation, error) {
+	observation := Observation{
+		Type:             strings.TrimSpace(dataPoint.Ty),
+		CompassDirection: dataPoint.Dr,
+		Name:             dataPoint.N,
+		Quality:          dataPoint.Q,
+		Values:           make(map[string]float64),
+	}
+	var err error
+	//Datums are the only product without a time
+	if dataPoint.T != "" {
+		observation.Time, err = parseObservationTime(dataPoint.T, location)
+		if err != nil {
+			return observation, err
+		}
+	}
+	observation.Flags, err = parseFlags(dataPoint.F)
+	if err != nil {
+		return observation, err
+	}
+	//Anything other than p or v is left as unknown
+	observation.QualityLevel, err = ConvertStringQualityLevelToEnum(dataPoint.Q)
+	if err != nil {
+		observation.QualityLevel = UnknownQuality
+	}
+	observation.QualityFlags, err = DecodeQualityFlags(dataProduct, dataPoint.F, observation.QualityLevel)
+	if err != nil {
+		return observation, err
+	}
+
+	//Figure out the primary value and what the secondary values mean
+	primaryValue := dataPoint.V
+	secondaryValues := map[string]string{}
+	switch dataProduct {
+	case Wind:
+		primaryValue = dataPoint.S
+		secondaryValues[DirectionValue] = dataPoint.D
+		secondaryValues[GustValue] = dataPoint.G
+	case Salinity:
+		primaryValue = dataPoint.S
+		secondaryValues[SpecificGravityValue] = dataPoint.G
+	case Currents:
+		primaryValue = dataPoint.S
+		secondaryValues[DirectionValue] = dataPoint.D
+		secondaryValues[BinValue] = dataPoint.B
+	case CurrentsPredictions:
+		secondaryValues[BinValue] = dataPoint.B
+	default:
+		secondaryValues[SigmaValue] = dataPoint.S
+	}
+	//Anything else that came in the values map (monthly means and current predictions)
+	for key, value := range dataPoint.Values {
+		secondaryValues[key] = value
+	}
+
+	observation.Value, observation.Missing, err = parseObservationValue(primaryValue)
+	if err != nil {
+		return observation, err
+	}
+	for key, value := range secondaryValues {
+		parsedValue, missing, err := parseObservationValue(value)
+		if err != nil {
+			return observation, err
+		}
+		//We just leave missing secondary values out of the map
+		if !missing {
+			observation.Values[key] = parsedValue
+		}
+	}
+	return observation, nil
+}
+
+//parseObservationTime - tries each of the layouts that Noaa uses
+func parseObservationTime(value string, location *time.Location) (time.Time, error) {
+	for _, layout := range observationTimeLayouts {
+		parsedTime, err := time.ParseInLocation(layout, value, location)
+		if err == nil {
+			return parsedTime, nil
+		}
+	}
+	return time.Time{}, customerrors.BadFormat{Msg: "Unable to parse the time: " + value}
+}
+
+//parseObservationValue - empty values (and the NaN that shows up sometimes) are treated as missing
+func parseObservationValue(value string) (float64, bool, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.EqualFold(value, "nan") {
+		return 0, true, nil
+	}
+	parsedValue, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, true, customerrors.BadFormat{Msg: "Unable to parse the value: " + value}
+	}
+	return parsedValue, false, nil
+}
+
+//parseFlags - flags come through as a comma separated list of 0s and 1s.  The outlier flag on water levels is a count so anything above 0 is set
+func parseFlags(value string) ([]bool, error) {
+	if value == "" {
+		return nil, nil
+	}
+	splits := strings.Split(value, ",")
+	flags := make([]bool, 0, len(splits))
+	for _, split := range splits {
+		count, err := strconv.Atoi(strings.TrimSpace(split))
+		if err != nil || count < 0 {
+			return nil, customerrors.BadFormat{Msg: "Unable to parse the flags: " + value}
+		}
+		flags = append(flags, count > 0)
+	}
+	return flags, nil
+}
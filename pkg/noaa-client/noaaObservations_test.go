@@ -0,0 +1,103 @@
+package noaaclient
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+//TestConvertWaterLevelObservations - times, values, flags and missing values should all be typed
+func TestConvertWaterLevelObservations(t *testing.T) {
+	productData, err := decodeProductResponse(WaterLevel, bytes.NewReader(loadSamplePayload(t, WaterLevel)), 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	observations, err := ConvertToObservations(productData, time.UTC)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(observations) != 3 {
+		t.Fatal("Wrong number of observations")
+	}
+	first := observations[0]
+	if !first.Time.Equal(time.Date(2021, time.August, 20, 15, 0, 0, 0, time.UTC)) {
+		t.Error("Wrong time " + first.Time.String())
+	}
+	if first.Value != 1.234 || first.Missing || first.Values[SigmaValue] != 0.003 || first.Quality != "p" {
+		t.Errorf("Wrong values %+v", first)
+	}
+	if len(first.Flags) != 4 || first.Flags[0] {
+		t.Error("Flags were not decoded")
+	}
+	//The last one didn't report
+	last := observations[2]
+	if !last.Missing || last.Value != 0 || !last.Flags[0] {
+		t.Errorf("Missing value wasn't handled %+v", last)
+	}
+	if _, ok := last.Values[SigmaValue]; ok {
+		t.Error("Missing sigma should be left out")
+	}
+}
+
+//TestConvertProductSpecificObservations - the primary value moves around depending on the product
+func TestConvertProductSpecificObservations(t *testing.T) {
+	tests := []struct {
+		product   DataProduct
+		value     float64
+		valueName string
+		other     float64
+	}{
+		{Wind, 4.22, GustValue, 6.36},
+		{Salinity, 26.66, SpecificGravityValue, 1.019},
+		{Currents, 35.21, DirectionValue, 12},
+		{CurrentsPredictions, 1.12, "meanEbbDir", 196},
+		{MonthlyMean, 0.920, "MLLW", 0.195},
+	}
+	for _, test := range tests {
+		productData, err := decodeProductResponse(test.product, bytes.NewReader(loadSamplePayload(t, test.product)), 0)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		observations, err := ConvertToObservations(productData, time.UTC)
+		if err != nil {
+			t.Error(test.product.String() + ": " + err.Error())
+			continue
+		}
+		if observations[0].Value != test.value || observations[0].Values[test.valueName] != test.other {
+			t.Errorf("%s: wrong values %+v", test.product.String(), observations[0])
+		}
+	}
+	//Monthly means only have a year and month
+	productData, _ := decodeProductResponse(MonthlyMean, bytes.NewReader(loadSamplePayload(t, MonthlyMean)), 0)
+	observations, _ := ConvertToObservations(productData, time.UTC)
+	if !observations[0].Time.Equal(time.Date(2021, time.July, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Error("Wrong monthly mean time " + observations[0].Time.String())
+	}
+	//Datums have a name and no time
+	productData, _ = decodeProductResponse(Datums, bytes.NewReader(loadSamplePayload(t, Datums)), 0)
+	observations, _ = ConvertToObservations(productData, time.UTC)
+	if observations[1].Name != "MHHW" || observations[1].Value != 3.519 || !observations[1].Time.IsZero() {
+		t.Errorf("Wrong datum %+v", observations[1])
+	}
+	if observations[1].ConvertToGrpc().TimeEpochInSeconds != 0 {
+		t.Error("Datums shouldn't have a time")
+	}
+}
+
+//TestConvertBadObservations - bad data should come back as errors
+func TestConvertBadObservations(t *testing.T) {
+	productData, _ := decodeProductResponse(AirTemperature, bytes.NewReader([]byte(`{"data": [{"t":"yesterday", "v":"1.0", "f":"0,0,0"}]}`)), 0)
+	_, err := ConvertToObservations(productData, time.UTC)
+	if err == nil {
+		t.Error("Expected a bad time to error")
+	}
+	productData, _ = decodeProductResponse(AirTemperature, bytes.NewReader([]byte(`{"data": [{"t":"2021-08-20 15:00", "v":"warm", "f":"0,0,0"}]}`)), 0)
+	_, err = ConvertToObservations(productData, time.UTC)
+	if err == nil {
+		t.Error("Expected a bad value to error")
+	}
+	_, err = ConvertToObservations(nil, time.UTC)
+	if err == nil {
+		t.Error("Expected a precondition error")
+	}
+}
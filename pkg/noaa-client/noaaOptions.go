@@ -0,0 +1,78 @@
+package noaaclient
+
+import (
+	"net/http"
+	"strings"
+)
+
+//DefaultBaseURL - where Noaa hosts the datagetter and metadata APIs
+const DefaultBaseURL = "https://api.tidesandcurrents.noaa.gov"
+
+//Paths off of the base url
+const (
+	dataGetterPath  = "/api/prod/datagetter"
+	stationsPath    = "/mdapi/prod/webapi/stations/"
+	stationListPath = "/mdapi/prod/webapi/stations.json"
+)
+
+//ClientOption - optional settings for NewNoaaClient.  Options are applied in order
+type ClientOption func(*NoaaClient)
+
+//WithBaseURL - points the client at a mirror, a proxy, or a local stand in (e.g. an httptest server).  Empty is ignored
+func WithBaseURL(baseURL string) ClientOption {
+	return func(object *NoaaClient) {
+		if baseURL == "" {
+			return
+		}
+		object.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+//WithHTTPClient - replaces the shared http.Client (its pooled transport and DefaultRequestTimeout).  Nil is ignored
+func WithHTTPClient(client *http.Client) ClientOption {
+	return func(object *NoaaClient) {
+		if client == nil {
+			return
+		}
+		object.client = client
+	}
+}
+
+//WithRoundTripper - sets the transport on the client.  The http.Client is copied so one passed in with WithHTTPClient isn't changed.  Nil is ignored
+func WithRoundTripper(roundTripper http.RoundTripper) ClientOption {
+	return func(object *NoaaClient) {
+		if roundTripper == nil {
+			return
+		}
+		client := *object.client
+		client.Transport = roundTripper
+		object.client = &client
+	}
+}
+
+//WithUserAgent - sets the User-Agent header on every call.  Empty uses Go's default
+func WithUserAgent(userAgent string) ClientOption {
+	return func(object *NoaaClient) {
+		object.userAgent = userAgent
+	}
+}
+
+//WithApplication - sets the application param that Noaa uses to identify who is calling.  Empty is ignored
+func WithApplication(application string) ClientOption {
+	return func(object *NoaaClient) {
+		if application == "" {
+			return
+		}
+		object.application = application
+	}
+}
+
+//WithMaxResponseSize - the largest body from Noaa (in bytes) that will be decoded.  Bigger responses fail with a BadFormat error.  Anything less than 1 is ignored
+func WithMaxResponseSize(maxSize int64) ClientOption {
+	return func(object *NoaaClient) {
+		if maxSize < 1 {
+			return
+		}
+		object.maxResponseSize = maxSize
+	}
+}
@@ -0,0 +1,103 @@
+package noaaclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+//newTestServer - stands in for Noaa.  Data calls get the recorded payload for the product and station calls get the recorded station
+func newTestServer(t *testing.T, handler func(request *http.Request)) *httptest.Server {
+	stationBody := loadTestdata(t, "mdapi_station.json")
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if handler != nil {
+			handler(r)
+		}
+		switch {
+		case r.URL.Path == dataGetterPath:
+			body, err := readTestdata(r.URL.Query().Get("product") + ".json")
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write(body)
+		case strings.HasPrefix(r.URL.Path, stationsPath):
+			w.Write(stationBody)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+//TestClientOptions - every call should go to the base url with the user agent and application that were set
+func TestClientOptions(t *testing.T) {
+	var lock sync.Mutex
+	var requests []*http.Request
+	server := newTestServer(t, func(request *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		requests = append(requests, request)
+	})
+	defer server.Close()
+
+	client := NewNoaaClient(MLLW, "metric", WithBaseURL(server.URL+"/"), WithUserAgent("sledgeconf-test"), WithApplication("unit_test"))
+	request, err := NewDataRequestBuilder("8454000", WaterLevel).Window(NewQueryModeWindow(LatestQuery)).TimeZone(LST).Build()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	productData, err := client.RetrieveData(context.Background(), request)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(productData.Data) != 3 || productData.Metadata.TimeZone != "lst" {
+		t.Error("Expected the recorded water levels in lst")
+	}
+	//One call for the station's time zone and one for the data
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 calls but got %d", len(requests))
+	}
+	for _, request := range requests {
+		if request.Header.Get("User-Agent") != "sledgeconf-test" {
+			t.Error("Expected the user agent on " + request.URL.Path)
+		}
+	}
+	if requests[1].URL.Query().Get("application") != "unit_test" {
+		t.Error("Expected the application on the data call")
+	}
+}
+
+//TestClientRoundTripper - the transport is used and the http.Client that was passed in isn't changed
+func TestClientRoundTripper(t *testing.T) {
+	server := newTestServer(t, nil)
+	defer server.Close()
+
+	httpClient := &http.Client{Timeout: time.Second}
+	called := 0
+	roundTripper := roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		called++
+		return http.DefaultTransport.RoundTrip(request)
+	})
+	client := NewNoaaClient(MLLW, "metric", WithHTTPClient(httpClient), WithRoundTripper(roundTripper), WithBaseURL(server.URL))
+	stationID := "8454000"
+	_, err := client.RetrieveDataForWindow(context.Background(), NewQueryModeWindow(LatestQuery), GMT, Wind, &stationID)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if called != 1 {
+		t.Errorf("Expected the round tripper to be called once but was called %d times", called)
+	}
+	if httpClient.Transport != nil {
+		t.Error("The http.Client that was passed in should not be changed")
+	}
+}
+
+//roundTripperFunc - lets a function be used as a transport
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
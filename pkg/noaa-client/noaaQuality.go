@@ -0,0 +1,198 @@
+package noaaclient
+
+import (
+	"strings"
+
+	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
+	sledgconf_demo_proto_v1 "github.com/mornindew/sledgeconf2021/pkg/grpc-service/genProto"
+)
+
+//QualityLevel - enum for Noaa's QA/QC level on a data point.  The string is what Noaa sends in q.  Products without one (e.g. the met products and predictions) are unknown
+type QualityLevel int
+
+const (
+	UnknownQuality QualityLevel = iota
+	Preliminary
+	Verified
+)
+
+//String - Noaa's q for the level.  Empty when it is unknown or isn't one of the levels
+func (enum QualityLevel) String() string {
+	if enum < UnknownQuality || enum > Verified {
+		return ""
+	}
+	return []string{"", "p", "v"}[enum]
+}
+
+//ConvertStringQualityLevelToEnum - Helper Function to convert from Noaa's q to the quality level.  An empty string is unknown
+func ConvertStringQualityLevelToEnum(val string) (QualityLevel, error) {
+	switch strings.TrimSpace(val) {
+	case "":
+		return UnknownQuality, nil
+	case "p":
+		return Preliminary, nil
+	case "v":
+		return Verified, nil
+	}
+	//Handle something not matching
+	return -1, customerrors.InvalidData{Msg: "Not a valid quality level"}
+}
+
+//ConvertToGrpcEnum - the protobuf enum is kept in the same order so it is a straight cast
+func (enum QualityLevel) ConvertToGrpcEnum() sledgconf_demo_proto_v1.QualityLevel {
+	return sledgconf_demo_proto_v1.QualityLevel(enum)
+}
+
+//ConvertGrpcEnumToQualityLevel - goes the other way
+func ConvertGrpcEnumToQualityLevel(val sledgconf_demo_proto_v1.QualityLevel) QualityLevel {
+	return QualityLevel(val)
+}
+
+//QualityFlags - Noaa's data flags by name.  Which ones are used (and the order they come in) depends on the product
+//
+//	Water levels and air gap - outliers (inferred once verified), flat tolerance, rate of change and limit exceeded
+//	Hourly heights and high/lows - inferred and limit exceeded
+//	Daily means - inferred
+//	Air and water temperature, air pressure, conductivity, visibility and humidity - max exceeded, min exceeded and rate of change
+//	Wind - max exceeded and rate of change
+type QualityFlags struct {
+	//Outliers - some of the 1 second samples were outside a 3 sigma band about the mean.  Noaa sends the count
+	Outliers bool
+	//Inferred - the value was inferred instead of measured
+	Inferred      bool
+	FlatTolerance bool
+	RateOfChange  bool
+	//LimitExceeded - the maximum or minimum expected water level was exceeded
+	LimitExceeded bool
+	MaxExceeded   bool
+	MinExceeded   bool
+}
+
+//Any - whether any of the flags are set
+func (object QualityFlags) Any() bool {
+	return object.Outliers || object.Inferred || object.FlatTolerance || object.RateOfChange || object.LimitExceeded || object.MaxExceeded || object.MinExceeded
+}
+
+//ConvertToGrpc - converts the flags over to the protobuf struct
+func (object QualityFlags) ConvertToGrpc() *sledgconf_demo_proto_v1.QualityFlags {
+	return &sledgconf_demo_proto_v1.QualityFlags{
+		Outliers:      object.Outliers,
+		Inferred:      object.Inferred,
+		FlatTolerance: object.FlatTolerance,
+		RateOfChange:  object.RateOfChange,
+		LimitExceeded: object.LimitExceeded,
+		MaxExceeded:   object.MaxExceeded,
+		MinExceeded:   object.MinExceeded,
+	}
+}
+
+//ConvertGrpcToQualityFlags - goes the other way.  Nil is no flags
+func ConvertGrpcToQualityFlags(qualityFlags *sledgconf_demo_proto_v1.QualityFlags) QualityFlags {
+	if qualityFlags == nil {
+		return QualityFlags{}
+	}
+	return QualityFlags{
+		Outliers:      qualityFlags.Outliers,
+		Inferred:      qualityFlags.Inferred,
+		FlatTolerance: qualityFlags.FlatTolerance,
+		RateOfChange:  qualityFlags.RateOfChange,
+		LimitExceeded: qualityFlags.LimitExceeded,
+		MaxExceeded:   qualityFlags.MaxExceeded,
+		MinExceeded:   qualityFlags.MinExceeded,
+	}
+}
+
+//DecodeQualityFlags - names the flags in Noaa's f for the product.  The quality level matters for water levels since the first flag is the outlier count until the data is verified and inferred after.  Flags past the ones the product has are ignored
+//
+//	Errors:
+//	BadFormat - the flags couldn't be parsed
+func DecodeQualityFlags(dataProduct DataProduct, flags string, qualityLevel QualityLevel) (QualityFlags, error) {
+	qualityFlags := QualityFlags{}
+	values, err := parseFlags(flags)
+	if err != nil {
+		return qualityFlags, err
+	}
+	fields := qualityFlags.fields(dataProduct, qualityLevel)
+	for index, value := range values {
+		if index < len(fields) {
+			*fields[index] = value
+		}
+	}
+	return qualityFlags, nil
+}
+
+//QualityFilter - which data points are left out of the product data.  The zero value keeps everything
+type QualityFilter struct {
+	//ExcludeFlagged - leave out the points with any of the flags set
+	ExcludeFlagged bool
+	//ExcludePreliminary - leave out the points that haven't been verified yet.  Points without a quality level are kept
+	ExcludePreliminary bool
+}
+
+//FilterQuality - takes the points that the filter excludes out of the product data (and the typed data that goes with them)
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	BadFormat - the flags couldn't be parsed
+func FilterQuality(productData *sledgconf_demo_proto_v1.ProductDataValues, filter QualityFilter) error {
+	//Precondition
+	if productData == nil {
+		return customerrors.PreconditionError{Msg: "Empty Mandatory Values"}
+	}
+	if !filter.ExcludeFlagged && !filter.ExcludePreliminary {
+		return nil
+	}
+	dataProduct := ConvertGrpcEnumToDataProduct(productData.DataType)
+	//The typed data lines up with the data when it is there
+	typed := len(productData.TypedData) == len(productData.Data)
+	data := make([]*sledgconf_demo_proto_v1.Data, 0, len(productData.Data))
+	typedData := make([]*sledgconf_demo_proto_v1.TypedData, 0, len(productData.TypedData))
+	for index, dataPoint := range productData.Data {
+		qualityLevel, err := ConvertStringQualityLevelToEnum(dataPoint.Q)
+		if err != nil {
+			qualityLevel = UnknownQuality
+		}
+		if filter.ExcludePreliminary && qualityLevel == Preliminary {
+			continue
+		}
+		qualityFlags, err := DecodeQualityFlags(dataProduct, dataPoint.F, qualityLevel)
+		if err != nil {
+			return err
+		}
+		if filter.ExcludeFlagged && qualityFlags.Any() {
+			continue
+		}
+		data = append(data, dataPoint)
+		if typed {
+			typedData = append(typedData, productData.TypedData[index])
+		}
+	}
+	productData.Data = data
+	if typed {
+		productData.TypedData = typedData
+	}
+	return nil
+}
+
+///INTERNAL FUNCTIONS
+
+//fields - the flags in the order that Noaa sends them for the product.  Empty if the product doesn't have flags
+func (object *QualityFlags) fields(dataProduct DataProduct, qualityLevel QualityLevel) []*bool {
+	switch dataProduct {
+	case WaterLevel, AirGap:
+		first := &object.Outliers
+		if qualityLevel == Verified {
+			first = &object.Inferred
+		}
+		return []*bool{first, &object.FlatTolerance, &object.RateOfChange, &object.LimitExceeded}
+	case HourlyHeight, HighLow:
+		return []*bool{&object.Inferred, &object.LimitExceeded}
+	case DailyMean:
+		return []*bool{&object.Inferred}
+	case AirTemperature, WaterTemperature, AirPressure, Conductivity, Visibility, Humidity:
+		return []*bool{&object.MaxExceeded, &object.MinExceeded, &object.RateOfChange}
+	case Wind:
+		return []*bool{&object.MaxExceeded, &object.RateOfChange}
+	}
+	return nil
+}
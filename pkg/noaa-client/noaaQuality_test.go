@@ -0,0 +1,100 @@
+package noaaclient
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+	"time"
+)
+
+//TestDecodeQualityFlags - the flags are named by where they are for the product
+func TestDecodeQualityFlags(t *testing.T) {
+	for _, test := range []struct {
+		product      DataProduct
+		flags        string
+		qualityLevel QualityLevel
+		expected     QualityFlags
+	}{
+		{WaterLevel, "0,0,0,0", Preliminary, QualityFlags{}},
+		{WaterLevel, "3,0,1,0", Preliminary, QualityFlags{Outliers: true, RateOfChange: true}},
+		{WaterLevel, "1,1,0,1", Verified, QualityFlags{Inferred: true, FlatTolerance: true, LimitExceeded: true}},
+		{HighLow, "0,1", Verified, QualityFlags{LimitExceeded: true}},
+		{DailyMean, "1", Verified, QualityFlags{Inferred: true}},
+		{AirTemperature, "0,1,0", UnknownQuality, QualityFlags{MinExceeded: true}},
+		{Wind, "1,1", UnknownQuality, QualityFlags{MaxExceeded: true, RateOfChange: true}},
+		{Preditions, "1,1", UnknownQuality, QualityFlags{}},
+		{Wind, "", UnknownQuality, QualityFlags{}},
+	} {
+		qualityFlags, err := DecodeQualityFlags(test.product, test.flags, test.qualityLevel)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if qualityFlags != test.expected {
+			t.Errorf("Expected %+v for %s %s but got %+v", test.expected, test.product, test.flags, qualityFlags)
+		}
+	}
+	if _, err := DecodeQualityFlags(WaterLevel, "0,x,0,0", Preliminary); !isBadFormat(err) {
+		t.Errorf("Expected a bad format but got %v", err)
+	}
+}
+
+//TestQualityLevelEnums - Noaa's q goes both ways
+func TestQualityLevelEnums(t *testing.T) {
+	for qualityLevel := UnknownQuality; qualityLevel <= Verified; qualityLevel++ {
+		converted, err := ConvertStringQualityLevelToEnum(qualityLevel.String())
+		if err != nil || converted != qualityLevel || ConvertGrpcEnumToQualityLevel(qualityLevel.ConvertToGrpcEnum()) != qualityLevel {
+			t.Errorf("Expected %d back but got %d %v", qualityLevel, converted, err)
+		}
+	}
+	qualityLevel, err := ConvertStringQualityLevelToEnum("x")
+	if !isInvalidData(err) || qualityLevel.String() != "" {
+		t.Errorf("Expected invalid data but got %v", err)
+	}
+}
+
+//TestObservationQuality - the observations have the named flags and level and they survive the trip through the typed data
+func TestObservationQuality(t *testing.T) {
+	productData := decodeSample(t, WaterLevel)
+	observations, err := ConvertTypedDataToObservations(productData)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if observations[0].QualityLevel != Preliminary || observations[0].QualityFlags.Any() || !observations[2].QualityFlags.Outliers {
+		t.Errorf("Unexpected quality %+v", observations)
+	}
+}
+
+//TestFilterQuality - flagged and preliminary points are left out along with their typed data
+func TestFilterQuality(t *testing.T) {
+	payload := `{"data": [{"t":"2021-08-20 15:00", "v":"1.1", "f":"0,0,0,0", "q":"v"},{"t":"2021-08-20 15:06", "v":"1.2", "f":"0,0,1,0", "q":"v"},{"t":"2021-08-20 15:12", "v":"1.3", "f":"0,0,0,0", "q":"p"},{"t":"2021-08-20 15:18", "v":"1.4", "f":"2,0,0,0", "q":"p"}]}`
+	for _, test := range []struct {
+		filter   QualityFilter
+		expected []string
+	}{
+		{QualityFilter{}, []string{"1.1", "1.2", "1.3", "1.4"}},
+		{QualityFilter{ExcludeFlagged: true}, []string{"1.1", "1.3"}},
+		{QualityFilter{ExcludePreliminary: true}, []string{"1.1", "1.2"}},
+		{QualityFilter{ExcludeFlagged: true, ExcludePreliminary: true}, []string{"1.1"}},
+	} {
+		productData, err := decodeProductResponse(WaterLevel, bytes.NewReader([]byte(payload)), 0)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		observations, _ := ConvertToObservations(productData, time.UTC)
+		productData.TypedData = ConvertObservationsToGrpc(observations)
+		if err := FilterQuality(productData, test.filter); err != nil {
+			t.Fatal(err.Error())
+		}
+		if len(productData.Data) != len(test.expected) || len(productData.TypedData) != len(test.expected) {
+			t.Fatalf("Expected %v with %+v but got %+v", test.expected, test.filter, productData.Data)
+		}
+		for index, value := range test.expected {
+			if productData.Data[index].V != value || strconv.FormatFloat(productData.TypedData[index].Value, 'f', -1, 64) != value {
+				t.Errorf("Expected %s but got %s", value, productData.Data[index].V)
+			}
+		}
+	}
+	if err := FilterQuality(nil, QualityFilter{ExcludeFlagged: true}); err == nil {
+		t.Error("Expected a precondition error")
+	}
+}
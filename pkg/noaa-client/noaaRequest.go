@@ -0,0 +1,240 @@
+package noaaclient
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
+)
+
+//Station IDs are 7 digits for water levels and letters and numbers for currents (e.g. n03020 or cb0102)
+var validStationID = regexp.MustCompile(`^[A-Za-z0-9_]{1,16}$`)
+
+//DataRequest - a validated request for the datagetter API.  Use the DataRequestBuilder to make one
+//
+//The datum and units are optional and fall back to the client's settings when they aren't set
+type DataRequest struct {
+	StationID    string
+	Product      DataProduct
+	Window       *QueryWindow
+	Datum        *Datum
+	Units        *MeasurementUnit
+	TimeZone     TimeZone
+	Interval     Interval
+	Bin          int
+	VelocityType VelocityType
+	//queryLocation - where the dates are formatted for Noaa.  Only set for daily means since they are asked for in local standard time.  Nil is gmt
+	queryLocation *time.Location
+}
+
+//DataRequestBuilder - builds up a DataRequest and checks every param against the product when Build is called
+type DataRequestBuilder struct {
+	request DataRequest
+}
+
+//NewDataRequestBuilder - starts a request for a product on a station.  Defaults to the most recent 3 days in gmt
+func NewDataRequestBuilder(stationID string, dataProduct DataProduct) *DataRequestBuilder {
+	return &DataRequestBuilder{request: DataRequest{StationID: stationID, Product: dataProduct, Window: NewQueryModeWindow(RecentQuery)}}
+}
+
+//Window - sets the time window (date range, latest, today, recent or range)
+func (object *DataRequestBuilder) Window(window *QueryWindow) *DataRequestBuilder {
+	object.request.Window = window
+	return object
+}
+
+//Datum - sets the datum.  Only used by the water level products
+func (object *DataRequestBuilder) Datum(datum Datum) *DataRequestBuilder {
+	object.request.Datum = &datum
+	return object
+}
+
+//Units - sets english or metric
+func (object *DataRequestBuilder) Units(units MeasurementUnit) *DataRequestBuilder {
+	object.request.Units = &units
+	return object
+}
+
+//TimeZone - sets the time zone for the times that come back
+func (object *DataRequestBuilder) TimeZone(timeZone TimeZone) *DataRequestBuilder {
+	object.request.TimeZone = timeZone
+	return object
+}
+
+//Interval - sets the interval (e.g. hourly or hilo predictions)
+func (object *DataRequestBuilder) Interval(interval Interval) *DataRequestBuilder {
+	object.request.Interval = interval
+	return object
+}
+
+//Bin - sets the bin number for currents
+func (object *DataRequestBuilder) Bin(bin int) *DataRequestBuilder {
+	object.request.Bin = bin
+	return object
+}
+
+//VelocityType - sets how current prediction velocities come back
+func (object *DataRequestBuilder) VelocityType(velocityType VelocityType) *DataRequestBuilder {
+	object.request.VelocityType = velocityType
+	return object
+}
+
+//Build - validates the request and returns it
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	InvalidData - a param isn't valid for the product
+func (object *DataRequestBuilder) Build() (*DataRequest, error) {
+	request := object.request
+	err := request.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+//Validate - checks every param against the product
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	InvalidData - a param isn't valid for the product
+func (object *DataRequest) Validate() error {
+	//Precondition
+	if object.StationID == "" || object.Window == nil {
+		return customerrors.PreconditionError{Msg: "Empty Mandatory Values"}
+	}
+	if !validStationID.MatchString(object.StationID) {
+		return customerrors.InvalidData{Msg: "Not a valid station ID", InternalErrorCode: 1160}
+	}
+	if object.Product < 0 || object.Product >= MaximumLimit {
+		return customerrors.InvalidData{Msg: "Not a valid product", InternalErrorCode: 1165}
+	}
+	err := object.Window.Validate()
+	if err != nil {
+		return err
+	}
+	err = object.Window.validateForProduct(object.Product)
+	if err != nil {
+		return err
+	}
+	if object.TimeZone < GMT || object.TimeZone > LSTLDT {
+		return customerrors.InvalidData{Msg: "Not a valid time zone", InternalErrorCode: 1166}
+	}
+	if object.Datum != nil && (*object.Datum < CRD || *object.Datum > STND) {
+		return customerrors.InvalidData{Msg: "Not a valid datum", InternalErrorCode: 1164}
+	}
+	if object.Units != nil && *object.Units != English && *object.Units != Metric {
+		return customerrors.InvalidData{Msg: "Not a valid unit", InternalErrorCode: 1167}
+	}
+	if object.Interval < DefaultInterval || object.Interval > MaxSlackInterval {
+		return customerrors.InvalidData{Msg: "Not a valid interval", InternalErrorCode: 1174}
+	}
+	if object.VelocityType < DefaultVelocityType || object.VelocityType > SpeedDirectionVelocityType {
+		return customerrors.InvalidData{Msg: "Not a valid velocity type", InternalErrorCode: 1175}
+	}
+	if object.Interval != DefaultInterval && !object.Product.supportsInterval(object.Interval) {
+		return customerrors.InvalidData{Msg: "The interval " + object.Interval.String() + " is not valid for " + object.Product.String(), InternalErrorCode: 1161}
+	}
+	if object.Bin < 0 || (object.Bin > 0 && object.Product != Currents && object.Product != CurrentsPredictions) {
+		return customerrors.InvalidData{Msg: "A bin is only valid for currents", InternalErrorCode: 1162}
+	}
+	if object.VelocityType != DefaultVelocityType && object.Product != CurrentsPredictions {
+		return customerrors.InvalidData{Msg: "A velocity type is only valid for current predictions", InternalErrorCode: 1163}
+	}
+	return nil
+}
+
+//withWindow - copy of the request with a different window.  Used when a long window gets split into chunks
+func (object *DataRequest) withWindow(window *QueryWindow) *DataRequest {
+	chunkRequest := *object
+	chunkRequest.Window = window
+	return &chunkRequest
+}
+
+//withQueryLocation - copy of the request with the dates formatted in the location
+func (object *DataRequest) withQueryLocation(location *time.Location) *DataRequest {
+	locatedRequest := *object
+	locatedRequest.queryLocation = location
+	return &locatedRequest
+}
+
+//queryValues - all the params for the request.  The client fills in anything that the request doesn't set.  Everything gets escaped when it is encoded
+func (object *DataRequest) queryValues(client *NoaaClient) url.Values {
+	values := url.Values{}
+	object.Window.addQueryValues(values, object.queryLocation)
+	values.Set("station", object.StationID)
+	values.Set("product", object.Product.String())
+	//We always ask for gmt and convert locally.  Daily means are only calculated on local standard time days
+	timeZone := client.timeZone
+	if object.Product == DailyMean {
+		timeZone = LST.String()
+	}
+	values.Set("time_zone", timeZone)
+	values.Set("application", client.application)
+	values.Set("format", client.format)
+	units := client.preferredMetric
+	if object.Units != nil {
+		units = *object.Units
+	}
+	values.Set("units", units.String())
+	if object.Product.usesDatum() {
+		datum := client.datum
+		if object.Datum != nil {
+			datum = *object.Datum
+		}
+		values.Set("datum", datum.String())
+	}
+	object.addProductParams(values)
+	return values
+}
+
+//Key - identifies the request.  Requests with the same key get the same data back so they can share a call (or a cached result)
+//
+//Unlike the query the time zone is the one that the times are converted to and the datum and units are left off when they aren't set
+func (object *DataRequest) Key() string {
+	values := object.seriesValues()
+	object.Window.addQueryValues(values, nil)
+	return values.Encode()
+}
+
+//SeriesKey - same as the Key without the window.  Every window of the same series of data (station, product, datum, units, etc.) has the same series key
+//
+//The time zone is left off since Noaa is always asked for gmt and the times are moved to the zone locally.  Daily means are the exception since they are asked for in local standard time
+func (object *DataRequest) SeriesKey() string {
+	values := object.seriesValues()
+	if object.Product != DailyMean {
+		values.Del("time_zone")
+	}
+	return values.Encode()
+}
+
+//seriesValues - everything but the window that decides what data comes back
+func (object *DataRequest) seriesValues() url.Values {
+	values := url.Values{}
+	values.Set("station", object.StationID)
+	values.Set("product", object.Product.String())
+	values.Set("time_zone", object.TimeZone.String())
+	if object.Units != nil {
+		values.Set("units", object.Units.String())
+	}
+	if object.Datum != nil && object.Product.usesDatum() {
+		values.Set("datum", object.Datum.String())
+	}
+	object.addProductParams(values)
+	return values
+}
+
+//addProductParams - the params that only some of the products take
+func (object *DataRequest) addProductParams(values url.Values) {
+	if object.Interval != DefaultInterval {
+		values.Set("interval", object.Interval.String())
+	}
+	if object.Bin > 0 {
+		values.Set("bin", strconv.Itoa(object.Bin))
+	}
+	if object.VelocityType != DefaultVelocityType {
+		values.Set("vel_type", object.VelocityType.String())
+	}
+}
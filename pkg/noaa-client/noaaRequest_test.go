@@ -0,0 +1,136 @@
+package noaaclient
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
+)
+
+//TestRequestEscaping - nothing in the request should be able to add params of its own
+func TestRequestEscaping(t *testing.T) {
+	_, err := NewDataRequestBuilder("8454000&product=wind", WaterLevel).Build()
+	if _, ok := err.(customerrors.InvalidData); !ok {
+		t.Error("Expected a station with params in it to be invalid")
+	}
+	//Even if it gets past the builder it is escaped
+	client := NewNoaaClient(MLLW, "metric")
+	request := &DataRequest{StationID: "8454000&product=wind", Product: WaterLevel, Window: NewQueryModeWindow(LatestQuery)}
+	parsed, err := url.Parse(client.constructURL(request))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	query := parsed.Query()
+	if query.Get("station") != "8454000&product=wind" || len(query["product"]) != 1 || query.Get("product") != "water_level" {
+		t.Error("Expected the station to be escaped but got " + parsed.RawQuery)
+	}
+}
+
+//TestRequestValidation - params are checked against the product
+func TestRequestValidation(t *testing.T) {
+	tests := []struct {
+		builder *DataRequestBuilder
+		valid   bool
+	}{
+		{NewDataRequestBuilder("8454000", Preditions).Interval(HighLowInterval), true},
+		{NewDataRequestBuilder("8454000", Preditions).Interval(MaxSlackInterval), false},
+		{NewDataRequestBuilder("8454000", Wind).Interval(HourlyInterval), true},
+		{NewDataRequestBuilder("8454000", Wind).Interval(HighLowInterval), false},
+		{NewDataRequestBuilder("8454000", WaterLevel).Interval(HourlyInterval), false},
+		{NewDataRequestBuilder("cb0102", CurrentsPredictions).Interval(MaxSlackInterval).Bin(3).VelocityType(SpeedDirectionVelocityType), true},
+		{NewDataRequestBuilder("cb0102", Currents).Bin(3), true},
+		{NewDataRequestBuilder("cb0102", Currents).VelocityType(SpeedDirectionVelocityType), false},
+		{NewDataRequestBuilder("8454000", WaterLevel).Bin(3), false},
+		{NewDataRequestBuilder("cb0102", Currents).Bin(-1), false},
+		{NewDataRequestBuilder("8454000", WaterLevel).Window(NewRangeWindow(0)), false},
+		{NewDataRequestBuilder("8454000", DataProduct(42)), false},
+		{NewDataRequestBuilder("8454000", Preditions).Interval(Interval(99)), false},
+		{NewDataRequestBuilder("8454000", Wind).Interval(Interval(-1)), false},
+		{NewDataRequestBuilder("cb0102", CurrentsPredictions).VelocityType(VelocityType(7)), false},
+		{NewDataRequestBuilder("", WaterLevel), false},
+	}
+	for index, test := range tests {
+		_, err := test.builder.Build()
+		if test.valid && err != nil {
+			t.Errorf("%d: expected the request to be valid but got %s", index, err.Error())
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%d: expected the request to be invalid", index)
+		}
+	}
+}
+
+//TestOutOfRangeEnums - hand built requests with values outside the enums don't panic
+func TestOutOfRangeEnums(t *testing.T) {
+	request := &DataRequest{StationID: "8454000", Product: Preditions, Window: NewQueryModeWindow(LatestQuery), Interval: 99, VelocityType: 7}
+	if err := request.Validate(); !isInvalidData(err) {
+		t.Errorf("Expected invalid data but got %v", err)
+	}
+	if request.Key() == "" || Interval(99).String() != "" || VelocityType(-1).String() != "" || TimeZone(-1).String() != "" || TimeZone(3).String() != "" {
+		t.Error("Expected the out of range values to be empty")
+	}
+}
+
+//TestRequestQueryValues - the client fills in what the request doesn't set and the datum is only sent for water level products
+func TestRequestQueryValues(t *testing.T) {
+	client := NewNoaaClient(MLLW, "english")
+	request, err := NewDataRequestBuilder("cb0102", CurrentsPredictions).Window(NewRangeWindow(6)).Interval(MaxSlackInterval).Bin(3).VelocityType(SpeedDirectionVelocityType).Units(English).Build()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	encoded := request.queryValues(client).Encode()
+	for _, expected := range []string{"range=6", "station=cb0102", "product=currents_predictions", "interval=MAX_SLACK", "bin=3", "vel_type=speed_dir", "units=english", "time_zone=gmt", "format=json"} {
+		if !strings.Contains(encoded, expected) {
+			t.Error("Expected " + expected + " in " + encoded)
+		}
+	}
+	if strings.Contains(encoded, "datum=") {
+		t.Error("Current predictions should not send a datum")
+	}
+
+	request, err = NewDataRequestBuilder("8454000", HourlyHeight).Build()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	values := request.queryValues(client)
+	if values.Get("datum") != "MLLW" || values.Get("date") != "recent" || values.Get("interval") != "" {
+		t.Error("Expected the client's datum on a water level product but got " + values.Encode())
+	}
+	request, err = NewDataRequestBuilder("8454000", HourlyHeight).Datum(NAVD).Build()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if request.queryValues(client).Get("datum") != "NAVD" {
+		t.Error("Expected the request's datum to win")
+	}
+}
+
+//TestRequestKey - requests that would get different data back have different keys
+func TestRequestKey(t *testing.T) {
+	build := func(builder *DataRequestBuilder) string {
+		request, err := builder.Build()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		return request.Key()
+	}
+	window := NewQueryModeWindow(TodayQuery)
+	key := build(NewDataRequestBuilder("8454000", WaterLevel).Window(window).Datum(MLLW).Units(Metric))
+	if key != build(NewDataRequestBuilder("8454000", WaterLevel).Window(NewQueryModeWindow(TodayQuery)).Datum(MLLW).Units(Metric)) {
+		t.Error("Expected identical requests to have the same key")
+	}
+	different := map[string]*DataRequestBuilder{
+		"station":   NewDataRequestBuilder("8452944", WaterLevel).Window(window).Datum(MLLW).Units(Metric),
+		"product":   NewDataRequestBuilder("8454000", OneMinuteWaterLevel).Window(window).Datum(MLLW).Units(Metric),
+		"window":    NewDataRequestBuilder("8454000", WaterLevel).Window(NewQueryModeWindow(LatestQuery)).Datum(MLLW).Units(Metric),
+		"datum":     NewDataRequestBuilder("8454000", WaterLevel).Window(window).Datum(MSL).Units(Metric),
+		"units":     NewDataRequestBuilder("8454000", WaterLevel).Window(window).Datum(MLLW).Units(English),
+		"time zone": NewDataRequestBuilder("8454000", WaterLevel).Window(window).Datum(MLLW).Units(Metric).TimeZone(LSTLDT),
+	}
+	for name, builder := range different {
+		if build(builder) == key {
+			t.Errorf("Expected a different %s to have a different key", name)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package noaaclient
+
+import (
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+//RetryPolicy - how failed calls to Noaa are retried.  Only transport errors (including timeouts), 429s and 5xxs are retried
+//
+//The wait before each retry grows by the multiplier up to the max backoff.  Jitter takes up to that fraction off of the wait so that clients don't retry in lock step
+type RetryPolicy struct {
+	//MaxAttempts - total calls including the first one.  1 turns retries off
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	//Jitter - between 0 and 1
+	Jitter float64
+	//MaxRetryAfter - the longest Retry-After we will wait for.  Anything longer gives up and returns the response
+	MaxRetryAfter time.Duration
+}
+
+//DefaultRetryPolicy - what NewNoaaClient uses
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 3, InitialBackoff: 250 * time.Millisecond, MaxBackoff: 5 * time.Second, Multiplier: 2, Jitter: 0.5, MaxRetryAfter: 30 * time.Second}
+
+//NoRetryPolicy - every call is made once
+var NoRetryPolicy = RetryPolicy{MaxAttempts: 1}
+
+//WithRetryPolicy - sets how failed calls are retried
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(object *NoaaClient) {
+		object.retryPolicy = policy
+	}
+}
+
+//backoff - the wait before the retry that follows the attempt (attempts start at 1)
+func (object RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := object.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	wait := float64(object.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if object.MaxBackoff > 0 && wait > float64(object.MaxBackoff) {
+		wait = float64(object.MaxBackoff)
+	}
+	if object.Jitter > 0 {
+		wait = wait * (1 - math.Min(object.Jitter, 1)*rand.Float64())
+	}
+	return time.Duration(wait)
+}
+
+///INTERNAL FUNCTIONS
+
+//isRetryableStatus - Noaa throws 5xxs when it is having problems and 429s when we are calling too much
+func isRetryableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+//retryAfter - reads the Retry-After header.  It can be a number of seconds or a date
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(value)
+	if err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	retryTime, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	wait := retryTime.Sub(now)
+	if wait < 0 {
+		wait = 0
+	}
+	return wait, true
+}
+
+//discardBody - reads what is left of the body so the connection can be reused
+func discardBody(resp *http.Response) {
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+}
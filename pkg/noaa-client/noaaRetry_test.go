@@ -0,0 +1,125 @@
+package noaaclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
+)
+
+//fastRetryPolicy - same shape as the default but quick enough for tests
+var fastRetryPolicy = RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond, Multiplier: 2, Jitter: 0.5, MaxRetryAfter: time.Second}
+
+//newFlakyServer - fails with the status (and Retry-After) for the first few calls and then sends back the recorded wind data
+func newFlakyServer(t *testing.T, failures int32, statusCode int, retryAfterHeader string, calls *int32) *httptest.Server {
+	body := loadSamplePayload(t, Wind)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(calls, 1) <= failures {
+			if retryAfterHeader != "" {
+				w.Header().Set("Retry-After", retryAfterHeader)
+			}
+			w.WriteHeader(statusCode)
+			w.Write([]byte(`{"error": {"message": "try again"}}`))
+			return
+		}
+		w.Write(body)
+	}))
+}
+
+//TestRetryTransientErrors - 5xxs and 429s are retried until they work
+func TestRetryTransientErrors(t *testing.T) {
+	tests := []struct {
+		statusCode       int
+		retryAfterHeader string
+	}{
+		{http.StatusServiceUnavailable, ""},
+		{http.StatusInternalServerError, ""},
+		{http.StatusTooManyRequests, "0"},
+	}
+	stationID := "8454000"
+	for _, test := range tests {
+		var calls int32
+		server := newFlakyServer(t, 2, test.statusCode, test.retryAfterHeader, &calls)
+		client := NewNoaaClient(MLLW, "metric", WithBaseURL(server.URL), WithRetryPolicy(fastRetryPolicy), WithCircuitBreakers(nil))
+		productData, err := client.RetrieveDataForWindow(context.Background(), NewQueryModeWindow(LatestQuery), GMT, Wind, &stationID)
+		server.Close()
+		if err != nil {
+			t.Errorf("%d: %s", test.statusCode, err.Error())
+			continue
+		}
+		if calls != 3 || len(productData.Data) != 2 {
+			t.Errorf("%d: expected 3 calls and the data but got %d calls", test.statusCode, calls)
+		}
+	}
+}
+
+//TestRetryGivesUp - retries stop at the max attempts, aren't made for 400s, and aren't made when Retry-After is too long
+func TestRetryGivesUp(t *testing.T) {
+	tests := []struct {
+		statusCode       int
+		retryAfterHeader string
+		expectedCalls    int32
+		expectError      bool
+	}{
+		{http.StatusBadGateway, "", 3, true},
+		{http.StatusBadRequest, "", 1, false},
+		{http.StatusServiceUnavailable, "120", 1, true},
+	}
+	stationID := "8454000"
+	for _, test := range tests {
+		var calls int32
+		server := newFlakyServer(t, 10, test.statusCode, test.retryAfterHeader, &calls)
+		client := NewNoaaClient(MLLW, "metric", WithBaseURL(server.URL), WithRetryPolicy(fastRetryPolicy), WithCircuitBreakers(nil))
+		_, err := client.RetrieveDataForWindow(context.Background(), NewQueryModeWindow(LatestQuery), GMT, Wind, &stationID)
+		server.Close()
+		if calls != test.expectedCalls {
+			t.Errorf("%d: expected %d calls but got %d", test.statusCode, test.expectedCalls, calls)
+		}
+		if _, ok := err.(customerrors.InternalServerError); ok != test.expectError {
+			t.Errorf("%d: unexpected error %v", test.statusCode, err)
+		}
+	}
+}
+
+//TestRetryBackoff - the wait grows, is capped, and jitter only takes time off
+func TestRetryBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2}
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for index, want := range expected {
+		if got := policy.backoff(index + 1); got != want {
+			t.Errorf("attempt %d: expected %s but got %s", index+1, want, got)
+		}
+	}
+	policy.Jitter = 0.5
+	for attempt := 1; attempt < 10; attempt++ {
+		got := policy.backoff(3)
+		if got > 400*time.Millisecond || got < 200*time.Millisecond {
+			t.Error("Jitter went outside of the range: " + got.String())
+		}
+	}
+}
+
+//TestRetryAfterHeader - seconds and dates both work
+func TestRetryAfterHeader(t *testing.T) {
+	now := time.Date(2021, time.August, 20, 15, 0, 0, 0, time.UTC)
+	resp := &http.Response{Header: http.Header{}}
+	if _, ok := retryAfter(resp, now); ok {
+		t.Error("Expected no Retry-After")
+	}
+	resp.Header.Set("Retry-After", "7")
+	if wait, ok := retryAfter(resp, now); !ok || wait != 7*time.Second {
+		t.Error("Expected 7 seconds")
+	}
+	resp.Header.Set("Retry-After", now.Add(time.Minute).Format(http.TimeFormat))
+	if wait, ok := retryAfter(resp, now); !ok || wait != time.Minute {
+		t.Error("Expected a minute")
+	}
+	resp.Header.Set("Retry-After", "soon")
+	if _, ok := retryAfter(resp, now); ok {
+		t.Error("Expected a bad Retry-After to be ignored")
+	}
+}
@@ -1,7 +1,94 @@
 package noaaclient
 
+import "encoding/json"
+
 type ErrorResponse struct {
 	Error struct {
 		Message string `json:"message"`
 	} `json:"error"`
 }
+
+//The structs below mirror the different JSON shapes that the datagetter API sends back.  The responses are streamed so only one row is decoded at a time and then mapped over to the protobuf structs
+
+//Keys that hold the rows for the different products.  Current predictions nest their rows under current_predictions
+const (
+	observationsKey       = "data"
+	predictionsKey        = "predictions"
+	datumsKey             = "datums"
+	currentPredictionsKey = "current_predictions"
+	currentRowsKey        = "cp"
+	metadataKey           = "metadata"
+	errorKey              = "error"
+)
+
+type responseMetadata struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Lat  string `json:"lat"`
+	Lon  string `json:"lon"`
+}
+
+//observationRow - superset of all the keys that Noaa uses on an observation (water level, met data, currents, etc.).  Each product only fills the ones that it has
+type observationRow struct {
+	T  flexibleString `json:"t"`
+	V  flexibleString `json:"v"`
+	F  flexibleString `json:"f"`
+	S  flexibleString `json:"s"`
+	D  flexibleString `json:"d"`
+	DR flexibleString `json:"dr"`
+	G  flexibleString `json:"g"`
+	Q  flexibleString `json:"q"`
+	Ty flexibleString `json:"ty"`
+	B  flexibleString `json:"b"`
+}
+
+//monthlyMeanRow - monthly means come back with a row per month and a column per datum
+type monthlyMeanRow map[string]flexibleString
+
+//predictionRow - tide predictions don't have metadata and use a different key for the data
+type predictionRow struct {
+	T    flexibleString `json:"t"`
+	V    flexibleString `json:"v"`
+	Type flexibleString `json:"type"`
+}
+
+//datumRow - the datums product is a list of datum names and values
+type datumRow struct {
+	N flexibleString `json:"n"`
+	V flexibleString `json:"v"`
+}
+
+//currentPredictionRow - current predictions use capitalized keys
+type currentPredictionRow struct {
+	Time          flexibleString `json:"Time"`
+	VelocityMajor flexibleString `json:"Velocity_Major"`
+	MeanFloodDir  flexibleString `json:"meanFloodDir"`
+	MeanEbbDir    flexibleString `json:"meanEbbDir"`
+	Bin           flexibleString `json:"Bin"`
+	Depth         flexibleString `json:"Depth"`
+	Type          flexibleString `json:"Type"`
+}
+
+//flexibleString - Noaa isn't consistent about quoting numbers (current predictions send raw numbers) so this will take either
+type flexibleString string
+
+func (value *flexibleString) UnmarshalJSON(data []byte) error {
+	//Null just means empty
+	if string(data) == "null" {
+		*value = ""
+		return nil
+	}
+	//Quoted strings get unquoted
+	if len(data) > 0 && data[0] == '"' {
+		var stringValue string
+		err := json.Unmarshal(data, &stringValue)
+		if err != nil {
+			return err
+		}
+		*value = flexibleString(stringValue)
+		return nil
+	}
+	//Anything else (numbers and bools) we keep the raw text
+	*value = flexibleString(data)
+	return nil
+}
@@ -0,0 +1,193 @@
+package noaaclient
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+
+	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
+	sledgconf_demo_proto_v1 "github.com/mornindew/sledgeconf2021/pkg/grpc-service/genProto"
+	"github.com/mornindew/sledgeconf2021/pkg/utils"
+)
+
+//StationTimeZone - the local time zone for a station as Noaa describes it
+type StationTimeZone struct {
+	//Abbreviation - standard time abbreviation (e.g. EST)
+	Abbreviation string
+	//OffsetInHours - standard time offset from UTC (e.g. -5)
+	OffsetInHours float64
+	ObservesDST   bool
+}
+
+//Noaa only gives us an offset and whether DST is observed.  Every station is in the US or a US territory so this is enough to find the real zone
+var ianaZonesByOffset = map[float64]map[bool]string{
+	-4:  {false: "America/Puerto_Rico"},
+	-5:  {true: "America/New_York", false: "America/Panama"},
+	-6:  {true: "America/Chicago"},
+	-7:  {true: "America/Denver", false: "America/Phoenix"},
+	-8:  {true: "America/Los_Angeles"},
+	-9:  {true: "America/Anchorage"},
+	-10: {true: "America/Adak", false: "Pacific/Honolulu"},
+	-11: {false: "Pacific/Pago_Pago"},
+	10:  {false: "Pacific/Guam"},
+	12:  {false: "Pacific/Kwajalein"},
+}
+
+//Location - returns the location to use for the time zone along with its name
+//
+//LST is always a fixed offset.  LSTLDT uses the real zone so that DST transitions are handled, falling back to the fixed offset if we don't know the zone
+func (object *StationTimeZone) Location(timeZone TimeZone) (*time.Location, string) {
+	fixedZone := time.FixedZone(object.Abbreviation, int(object.OffsetInHours*60*60))
+	switch timeZone {
+	case LST:
+		return fixedZone, object.Abbreviation
+	case LSTLDT:
+		if !object.ObservesDST {
+			return fixedZone, object.Abbreviation
+		}
+		zoneName, ok := ianaZonesByOffset[object.OffsetInHours][object.ObservesDST]
+		if !ok {
+			return fixedZone, object.Abbreviation
+		}
+		location, err := time.LoadLocation(zoneName)
+		if err != nil {
+			//No zone info on the box - best we can do is standard time
+			return fixedZone, object.Abbreviation
+		}
+		return location, zoneName
+	default:
+		return time.UTC, "UTC"
+	}
+}
+
+//RetrieveStationTimeZone - looks up the station's time zone with the Noaa metadata API.  Time zones don't change so they are cached on the client
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	NotFoundError - Noaa doesn't know the station
+//	ServiceUnavailableError - Noaa is down
+//	InternalServerError - error from Noaa
+func (object *NoaaClient) RetrieveStationTimeZone(ctx context.Context, stationID string) (*StationTimeZone, error) {
+	//Precondition
+	if ctx == nil || stationID == "" {
+		return nil, customerrors.PreconditionError{Msg: "Empty Mandatory Values"}
+	}
+	object.timeZoneLock.Lock()
+	cachedTimeZone, ok := object.stationTimeZones[stationID]
+	object.timeZoneLock.Unlock()
+	if ok {
+		return cachedTimeZone, nil
+	}
+
+	resp, err := object.get(ctx, object.baseURL+stationsPath+url.PathEscape(stationID)+".json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == 404 {
+		return nil, customerrors.NotFoundError{Msg: "Station not found: " + stationID}
+	}
+	if resp.StatusCode != 200 {
+		return nil, customerrors.InternalServerError{Msg: "Error Calling Noaa", InternalErrorCode: resp.StatusCode}
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, object.maxResponseSize))
+	if err != nil {
+		return nil, customerrors.InternalServerError{Msg: "Bad Format Error"}
+	}
+	stationTimeZone, err := parseStationTimeZone(body, stationID)
+	if err != nil {
+		return nil, err
+	}
+	object.timeZoneLock.Lock()
+	object.stationTimeZones[stationID] = stationTimeZone
+	object.timeZoneLock.Unlock()
+	return stationTimeZone, nil
+}
+
+//LocalizeProductData - moves product data that is in gmt (e.g. from the station store) over to the time zone for the station.  The metadata says which zone was used
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	NotFoundError - the station's time zone couldn't be found
+//	BadFormat - a time or value couldn't be parsed
+func (object *NoaaClient) LocalizeProductData(ctx context.Context, productData *sledgconf_demo_proto_v1.ProductDataValues, stationID string, timeZone TimeZone) error {
+	//Precondition
+	if ctx == nil || productData == nil || stationID == "" {
+		return customerrors.PreconditionError{Msg: "Empty Mandatory Values"}
+	}
+	location, zoneName, err := object.resolveLocation(ctx, timeZone, stationID)
+	if err != nil {
+		return err
+	}
+	observations, err := ConvertTypedDataToObservations(productData)
+	if err != nil {
+		return err
+	}
+	localizeObservations(ConvertGrpcEnumToDataProduct(productData.DataType), productData, observations, location)
+	productData.TypedData = ConvertObservationsToGrpc(observations)
+	if productData.Metadata != nil {
+		productData.Metadata.TimeZone = timeZone.String()
+		productData.Metadata.TimeZoneName = zoneName
+	}
+	return nil
+}
+
+//resolveLocation - finds the location for the time zone on a station.  GMT doesn't need to call Noaa
+func (object *NoaaClient) resolveLocation(ctx context.Context, timeZone TimeZone, stationID string) (*time.Location, string, error) {
+	if timeZone == GMT {
+		return time.UTC, "UTC", nil
+	}
+	stationTimeZone, err := object.RetrieveStationTimeZone(ctx, stationID)
+	if err != nil {
+		return nil, "", err
+	}
+	location, zoneName := stationTimeZone.Location(timeZone)
+	return location, zoneName, nil
+}
+
+//localizeObservations - moves the observations (and the raw times) over to the location
+//
+//We always ask Noaa for gmt so the times are real instants and DST transitions (where local times repeat) aren't ambiguous.  Daily and monthly means are labels for a day or month so they keep their date
+func localizeObservations(dataProduct DataProduct, productData *sledgconf_demo_proto_v1.ProductDataValues, observations []Observation, location *time.Location) {
+	for index := range observations {
+		observation := &observations[index]
+		if observation.Time.IsZero() {
+			continue
+		}
+		switch dataProduct {
+		case DailyMean, MonthlyMean:
+			year, month, day := observation.Time.Date()
+			observation.Time = time.Date(year, month, day, 0, 0, 0, 0, location)
+		default:
+			observation.Time = observation.Time.In(location)
+			productData.Data[index].T = observation.Time.Format(observationTimeLayouts[0])
+		}
+	}
+}
+
+//parseStationTimeZone - pulls the time zone fields off of the metadata API's station response
+func parseStationTimeZone(body []byte, stationID string) (*StationTimeZone, error) {
+	response := &struct {
+		Stations []struct {
+			TimeZone     string         `json:"timezone"`
+			TimeZoneCorr flexibleString `json:"timezonecorr"`
+			ObservedST   bool           `json:"observedst"`
+		} `json:"stations"`
+	}{}
+	err := utils.MarshalDataToInterface(body, response)
+	if err != nil {
+		return nil, customerrors.BadFormat{Msg: "Unable to decode the station: " + err.Error()}
+	}
+	if len(response.Stations) == 0 {
+		return nil, customerrors.NotFoundError{Msg: "Station not found: " + stationID}
+	}
+	station := response.Stations[0]
+	//The offset comes back as a number but sometimes as a string
+	offset, err := strconv.ParseFloat(string(station.TimeZoneCorr), 64)
+	if err != nil {
+		return nil, customerrors.BadFormat{Msg: "Unable to decode the station time zone offset"}
+	}
+	return &StationTimeZone{Abbreviation: station.TimeZone, OffsetInHours: offset, ObservesDST: station.ObservedST}, nil
+}
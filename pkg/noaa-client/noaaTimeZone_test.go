@@ -0,0 +1,137 @@
+package noaaclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	sledgconf_demo_proto_v1 "github.com/mornindew/sledgeconf2021/pkg/grpc-service/genProto"
+)
+
+//TestParseStationTimeZone - the time zone should come off of the metadata API response
+func TestParseStationTimeZone(t *testing.T) {
+	body := loadTestdata(t, "mdapi_station.json")
+	stationTimeZone, err := parseStationTimeZone(body, "8454000")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if stationTimeZone.Abbreviation != "EST" || stationTimeZone.OffsetInHours != -5 || !stationTimeZone.ObservesDST {
+		t.Errorf("Wrong time zone %+v", *stationTimeZone)
+	}
+	_, err = parseStationTimeZone([]byte(`{"count":0,"stations":[]}`), "1234567")
+	if err == nil {
+		t.Error("Expected a not found error")
+	}
+}
+
+//TestStationTimeZoneMaxSize - the metadata body is held to the max response size like everything else
+func TestStationTimeZoneMaxSize(t *testing.T) {
+	server := newTestServer(t, nil)
+	defer server.Close()
+	client := NewNoaaClient(MLLW, "metric", WithBaseURL(server.URL), WithRetryPolicy(NoRetryPolicy), WithCircuitBreakers(nil), WithMaxResponseSize(16))
+	if _, err := client.RetrieveStationTimeZone(context.Background(), "8454000"); err == nil {
+		t.Error("Expected the cut off body to fail")
+	}
+	client = NewNoaaClient(MLLW, "metric", WithBaseURL(server.URL), WithRetryPolicy(NoRetryPolicy), WithCircuitBreakers(nil))
+	if _, err := client.RetrieveStationTimeZone(context.Background(), "8454000"); err != nil {
+		t.Error(err.Error())
+	}
+}
+
+//TestStationTimeZoneLocation - lst is always standard time and lst_ldt follows DST
+func TestStationTimeZoneLocation(t *testing.T) {
+	stationTimeZone := &StationTimeZone{Abbreviation: "EST", OffsetInHours: -5, ObservesDST: true}
+	summer := time.Date(2021, time.August, 20, 15, 0, 0, 0, time.UTC)
+
+	location, name := stationTimeZone.Location(LST)
+	if _, offset := summer.In(location).Zone(); offset != -5*60*60 || name != "EST" {
+		t.Error("LST should be standard time in the summer")
+	}
+	location, name = stationTimeZone.Location(LSTLDT)
+	if _, offset := summer.In(location).Zone(); offset != -4*60*60 || name != "America/New_York" {
+		t.Error("LST_LDT should be daylight time in the summer")
+	}
+	location, name = stationTimeZone.Location(GMT)
+	if location != time.UTC || name != "UTC" {
+		t.Error("GMT should be UTC")
+	}
+	//Stations that don't observe DST stay on standard time
+	hawaii := &StationTimeZone{Abbreviation: "HST", OffsetInHours: -10}
+	location, _ = hawaii.Location(LSTLDT)
+	if _, offset := summer.In(location).Zone(); offset != -10*60*60 {
+		t.Error("Hawaii should not observe DST")
+	}
+}
+
+//TestLocalizeObservationsAcrossDST - the repeated hour when DST ends should keep both points with different offsets
+func TestLocalizeObservationsAcrossDST(t *testing.T) {
+	productData := &sledgconf_demo_proto_v1.ProductDataValues{
+		DataType: sledgconf_demo_proto_v1.DataType_WaterLevel,
+		Data: []*sledgconf_demo_proto_v1.Data{
+			{T: "2021-11-07 05:30", V: "1.0"},
+			{T: "2021-11-07 06:30", V: "2.0"},
+		},
+	}
+	observations, err := ConvertToObservations(productData, time.UTC)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	location, _ := (&StationTimeZone{Abbreviation: "EST", OffsetInHours: -5, ObservesDST: true}).Location(LSTLDT)
+	localizeObservations(WaterLevel, productData, observations, location)
+	//Both are 1:30 local time but one is EDT and the other is EST
+	if productData.Data[0].T != "2021-11-07 01:30" || productData.Data[1].T != "2021-11-07 01:30" {
+		t.Error("Expected both raw times to be 01:30 but got " + productData.Data[0].T + " and " + productData.Data[1].T)
+	}
+	typedData := ConvertObservationsToGrpc(observations)
+	if typedData[0].UtcOffsetInSeconds != -4*60*60 || typedData[1].UtcOffsetInSeconds != -5*60*60 {
+		t.Error("Offsets should change across the DST transition")
+	}
+	if typedData[1].TimeEpochInSeconds-typedData[0].TimeEpochInSeconds != 60*60 {
+		t.Error("The points should still be an hour apart")
+	}
+
+	//Rebuilding from the typed data puts them back in the zone
+	productData.TypedData = typedData
+	productData.Metadata = &sledgconf_demo_proto_v1.Metadata{TimeZone: LSTLDT.String(), TimeZoneName: "America/New_York"}
+	rebuilt, err := ConvertTypedDataToObservations(productData)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !rebuilt[1].Time.Equal(observations[1].Time) || rebuilt[1].Time.Location().String() != "America/New_York" {
+		t.Error("Rebuilt observations should be zoned")
+	}
+}
+
+//TestDailyMeanDates - daily means are asked for in local standard time so the dates in the url have to be in it too
+func TestDailyMeanDates(t *testing.T) {
+	var lock sync.Mutex
+	var queries []url.Values
+	server := newTestServer(t, func(request *http.Request) {
+		if request.URL.Path == dataGetterPath {
+			lock.Lock()
+			queries = append(queries, request.URL.Query())
+			lock.Unlock()
+		}
+	})
+	defer server.Close()
+	client := NewNoaaClient(MLLW, "metric", WithBaseURL(server.URL), WithRetryPolicy(NoRetryPolicy), WithCircuitBreakers(nil))
+	beginDate := time.Date(2021, time.August, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2021, time.August, 3, 0, 0, 0, 0, time.UTC)
+	for _, product := range []DataProduct{DailyMean, WaterLevel} {
+		request, _ := NewDataRequestBuilder("8454000", product).Window(NewDateRangeWindow(&beginDate, &endDate)).Build()
+		if _, err := client.RetrieveData(context.Background(), request); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	if len(queries) != 2 {
+		t.Fatalf("Expected 2 data calls but got %d", len(queries))
+	}
+	for index, expected := range [][3]string{{"lst", "20210731 19:00", "20210802 19:00"}, {"gmt", "20210801 00:00", "20210803 00:00"}} {
+		if queries[index].Get("time_zone") != expected[0] || queries[index].Get("begin_date") != expected[1] || queries[index].Get("end_date") != expected[2] {
+			t.Errorf("Expected %v but got %v", expected, queries[index])
+		}
+	}
+}
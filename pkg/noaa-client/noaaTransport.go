@@ -0,0 +1,71 @@
+package noaaclient
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+//DefaultRequestTimeout - the longest a single call to Noaa can take including reading the body.  A year of hourly data can take a while
+const DefaultRequestTimeout = 30 * time.Second
+
+//TransportConfig - settings for the pooled transport that the clients share
+type TransportConfig struct {
+	//MaxIdleConns - idle (keep-alive) connections kept across every host
+	MaxIdleConns int
+	//MaxIdleConnsPerHost - idle connections kept for each host.  Should be at least the number of calls in flight or connections get thrown away
+	MaxIdleConnsPerHost int
+	//MaxConnsPerHost - caps the connections to a host.  Calls past the cap wait for a connection
+	MaxConnsPerHost       int
+	IdleConnTimeout       time.Duration
+	DialTimeout           time.Duration
+	KeepAlive             time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	//ForceHTTP2 - try HTTP/2 even though a custom dialer is used.  Noaa supports it so every call shares a connection
+	ForceHTTP2 bool
+	//TLSClientConfig - optional (e.g. for a mirror with its own certificate)
+	TLSClientConfig *tls.Config
+}
+
+//DefaultTransportConfig - sized for the station worker pool
+var DefaultTransportConfig = TransportConfig{
+	MaxIdleConns:          100,
+	MaxIdleConnsPerHost:   32,
+	MaxConnsPerHost:       32,
+	IdleConnTimeout:       90 * time.Second,
+	DialTimeout:           5 * time.Second,
+	KeepAlive:             30 * time.Second,
+	TLSHandshakeTimeout:   5 * time.Second,
+	ResponseHeaderTimeout: 15 * time.Second,
+	ForceHTTP2:            true,
+}
+
+//sharedHTTPClient - every NoaaClient uses this unless it is given its own so connections are reused across requests
+var sharedHTTPClient = &http.Client{Transport: NewTransport(DefaultTransportConfig), Timeout: DefaultRequestTimeout}
+
+//NewTransport - a pooled transport with keep-alive.  Transports are safe to share and should be long lived
+func NewTransport(config TransportConfig) *http.Transport {
+	dialer := &net.Dialer{Timeout: config.DialTimeout, KeepAlive: config.KeepAlive}
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     config.ForceHTTP2,
+		MaxIdleConns:          config.MaxIdleConns,
+		MaxIdleConnsPerHost:   config.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       config.MaxConnsPerHost,
+		IdleConnTimeout:       config.IdleConnTimeout,
+		TLSHandshakeTimeout:   config.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: config.ResponseHeaderTimeout,
+		ExpectContinueTimeout: time.Second,
+		TLSClientConfig:       config.TLSClientConfig,
+	}
+}
+
+//WithTransportConfig - gives the client its own pooled transport instead of the shared one.  The request timeout is kept
+func WithTransportConfig(config TransportConfig) ClientOption {
+	return func(object *NoaaClient) {
+		object.client = &http.Client{Transport: NewTransport(config), Timeout: object.client.Timeout}
+	}
+}
@@ -0,0 +1,70 @@
+package noaaclient
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+//TestSharedTransport - clients share the pooled transport unless they are given their own
+func TestSharedTransport(t *testing.T) {
+	first := NewNoaaClient(MLLW, "metric")
+	second := NewNoaaClient(NAVD, "english")
+	if first.client != second.client || first.client != sharedHTTPClient {
+		t.Error("Expected the clients to share the http client")
+	}
+	config := DefaultTransportConfig
+	config.MaxConnsPerHost = 2
+	config.ResponseHeaderTimeout = time.Second
+	custom := NewNoaaClient(MLLW, "metric", WithTransportConfig(config))
+	transport, ok := custom.client.Transport.(*http.Transport)
+	if !ok || custom.client == sharedHTTPClient {
+		t.Fatal("Expected the client to have its own transport")
+	}
+	if transport.MaxConnsPerHost != 2 || transport.ResponseHeaderTimeout != time.Second || custom.client.Timeout != DefaultRequestTimeout {
+		t.Error("The transport config wasn't used")
+	}
+}
+
+//TestConnectionReuse - one client used from a lot of go routines stays under the per host cap and reuses its connections
+func TestConnectionReuse(t *testing.T) {
+	var newConnections int32
+	body := loadSamplePayload(t, Wind)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Millisecond)
+		w.Write(body)
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&newConnections, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	config := DefaultTransportConfig
+	config.MaxConnsPerHost = 4
+	config.MaxIdleConnsPerHost = 4
+	client := NewNoaaClient(MLLW, "metric", WithBaseURL(server.URL), WithTransportConfig(config), WithCircuitBreakers(nil))
+	stationID := "8454000"
+	var wg sync.WaitGroup
+	for index := 0; index < 50; index++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.RetrieveDataForWindow(context.Background(), NewQueryModeWindow(LatestQuery), GMT, Wind, &stationID)
+			if err != nil {
+				t.Error(err.Error())
+			}
+		}()
+	}
+	wg.Wait()
+	if newConnections > 4 {
+		t.Errorf("Expected at most 4 connections but %d were opened", newConnections)
+	}
+}
@@ -0,0 +1,188 @@
+package noaaclient
+
+import (
+	"strconv"
+
+	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
+	sledgconf_demo_proto_v1 "github.com/mornindew/sledgeconf2021/pkg/grpc-service/genProto"
+	"github.com/mornindew/sledgeconf2021/pkg/units"
+)
+
+//Unit - the unit that Noaa sends the product's values in for english or metric.  False if the product doesn't have one
+func (enum DataProduct) Unit(measurementUnit MeasurementUnit) (units.Unit, bool) {
+	english := measurementUnit == English
+	switch enum {
+	case WaterLevel, HourlyHeight, HighLow, DailyMean, MonthlyMean, OneMinuteWaterLevel, Preditions, Datums, AirGap:
+		return pick(english, units.Feet, units.Meters), true
+	case AirTemperature, WaterTemperature:
+		return pick(english, units.Fahrenheit, units.Celsius), true
+	case Wind:
+		return pick(english, units.Knots, units.MetersPerSecond), true
+	case Currents, CurrentsPredictions:
+		return pick(english, units.Knots, units.CentimetersPerSecond), true
+	case Visibility:
+		return pick(english, units.NauticalMiles, units.Kilometers), true
+	case AirPressure:
+		//Millibars either way
+		return units.Millibars, true
+	case Conductivity:
+		return units.MillisiemensPerCentimeter, true
+	case Salinity:
+		return units.PracticalSalinityUnits, true
+	case Humidity:
+		return units.Percent, true
+	}
+	return -1, false
+}
+
+//ConvertProductUnits - moves the product data that came from Noaa in the fetched units over to the unit.  Values in other dimensions (e.g. the depth on current predictions) go to the english or metric unit for their dimension.  Both the raw strings and the typed data are converted and the product data says which unit it is in
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	InvalidData - the unit doesn't measure the same thing as the product (e.g. wind in feet)
+//	BadFormat - a value couldn't be parsed
+func ConvertProductUnits(productData *sledgconf_demo_proto_v1.ProductDataValues, fetched MeasurementUnit, unit units.Unit, measurementUnit MeasurementUnit) error {
+	//Precondition
+	if productData == nil {
+		return customerrors.PreconditionError{Msg: "Empty Mandatory Values"}
+	}
+	dataProduct := ConvertGrpcEnumToDataProduct(productData.DataType)
+	from, ok := dataProduct.Unit(fetched)
+	if !ok {
+		return nil
+	}
+	if from.Dimension() != unit.Dimension() {
+		return customerrors.InvalidData{Msg: dataProduct.String() + " can't be in " + unit.String(), InternalErrorCode: 1172}
+	}
+	fields := unitFields(dataProduct)
+	//Work out where each field goes first
+	conversions := make([]fieldConversion, 0, len(fields))
+	for _, field := range fields {
+		conversion := fieldConversion{field: field, from: from, to: unit}
+		if field.depth {
+			conversion.from = pick(fetched == English, units.Feet, units.Meters)
+			conversion.to = pick(measurementUnit == English, units.Feet, units.Meters)
+		}
+		if conversion.from != conversion.to {
+			conversions = append(conversions, conversion)
+		}
+	}
+	//Convert into copies so nothing is half converted when a value doesn't parse
+	converted := make([]map[string]string, len(productData.Data))
+	for index, dataPoint := range productData.Data {
+		converted[index] = make(map[string]string)
+		for _, conversion := range conversions {
+			value := conversion.field.get(dataPoint)
+			if value == "" {
+				continue
+			}
+			parsedValue, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return customerrors.BadFormat{Msg: "Unable to parse the value " + value}
+			}
+			parsedValue, err = units.Convert(parsedValue, conversion.from, conversion.to)
+			if err != nil {
+				return err
+			}
+			converted[index][conversion.field.name] = units.Format(parsedValue)
+		}
+	}
+	for index, dataPoint := range productData.Data {
+		for _, conversion := range conversions {
+			if value, ok := converted[index][conversion.field.name]; ok {
+				conversion.field.set(dataPoint, value)
+			}
+		}
+	}
+	for _, typedData := range productData.TypedData {
+		for _, conversion := range conversions {
+			if conversion.field.typedValue == "" {
+				if !typedData.Missing {
+					typedData.Value, _ = units.Convert(typedData.Value, conversion.from, conversion.to)
+				}
+				continue
+			}
+			if value, ok := typedData.Values[conversion.field.typedValue]; ok {
+				typedData.Values[conversion.field.typedValue], _ = units.Convert(value, conversion.from, conversion.to)
+			}
+		}
+	}
+	productData.Unit = unit.String()
+	return nil
+}
+
+///INTERNAL FUNCTIONS
+
+//unitField - a value on a data point that is in the product's unit.  The typed value is where it is on the typed data (empty for the primary value)
+type unitField struct {
+	name       string
+	typedValue string
+	//depth - a length on a product that isn't a length
+	depth bool
+	get   func(dataPoint *sledgconf_demo_proto_v1.Data) string
+	set   func(dataPoint *sledgconf_demo_proto_v1.Data, value string)
+}
+
+//fieldConversion - which units a field goes between
+type fieldConversion struct {
+	field    unitField
+	from, to units.Unit
+}
+
+var (
+	valueField = unitField{name: "v", get: func(dataPoint *sledgconf_demo_proto_v1.Data) string { return dataPoint.V }, set: func(dataPoint *sledgconf_demo_proto_v1.Data, value string) { dataPoint.V = value }}
+	speedField = unitField{name: "s", get: func(dataPoint *sledgconf_demo_proto_v1.Data) string { return dataPoint.S }, set: func(dataPoint *sledgconf_demo_proto_v1.Data, value string) { dataPoint.S = value }}
+	sigmaField = unitField{name: "s", typedValue: SigmaValue, get: speedField.get, set: speedField.set}
+	gustField  = unitField{name: "g", typedValue: GustValue, get: func(dataPoint *sledgconf_demo_proto_v1.Data) string { return dataPoint.G }, set: func(dataPoint *sledgconf_demo_proto_v1.Data, value string) { dataPoint.G = value }}
+)
+
+//monthlyMeanValues - the values on a monthly mean that are heights.  The rest are times (HWI and LWI) or a flag (inferred)
+var monthlyMeanValues = []string{"highest", "lowest", "MHHW", "MHW", "MSL", "MTL", "MLW", "MLLW", "DTL", "GT", "MN", "DHQ", "DLQ"}
+
+//mapField - a value in the values map
+func mapField(key string, depth bool) unitField {
+	return unitField{
+		name:       "values." + key,
+		typedValue: key,
+		depth:      depth,
+		get: func(dataPoint *sledgconf_demo_proto_v1.Data) string {
+			return dataPoint.Values[key]
+		},
+		set: func(dataPoint *sledgconf_demo_proto_v1.Data, value string) {
+			dataPoint.Values[key] = value
+		},
+	}
+}
+
+//unitFields - the fields that are in the product's unit.  The primary value moves around the same as it does for the observations
+func unitFields(dataProduct DataProduct) []unitField {
+	switch dataProduct {
+	case Wind:
+		return []unitField{speedField, gustField}
+	case Currents:
+		return []unitField{speedField}
+	case CurrentsPredictions:
+		return []unitField{valueField, mapField("depth", true)}
+	case MonthlyMean:
+		fields := []unitField{valueField}
+		for _, key := range monthlyMeanValues {
+			fields = append(fields, mapField(key, false))
+		}
+		return fields
+	case WaterLevel, HourlyHeight, OneMinuteWaterLevel, AirGap:
+		return []unitField{valueField, sigmaField}
+	case Salinity:
+		//The salinity is in s and the g is specific gravity so neither have a unit that changes
+		return nil
+	default:
+		return []unitField{valueField}
+	}
+}
+
+//pick - the english or metric unit
+func pick(english bool, englishUnit, metricUnit units.Unit) units.Unit {
+	if english {
+		return englishUnit
+	}
+	return metricUnit
+}
@@ -0,0 +1,93 @@
+package noaaclient
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	sledgconf_demo_proto_v1 "github.com/mornindew/sledgeconf2021/pkg/grpc-service/genProto"
+	"github.com/mornindew/sledgeconf2021/pkg/units"
+)
+
+//TestProductUnits - every product has a unit and english and metric measure the same thing
+func TestProductUnits(t *testing.T) {
+	for product := DataProduct(0); product < MaximumLimit; product++ {
+		english, ok := product.Unit(English)
+		metric, metricOk := product.Unit(Metric)
+		if !ok || !metricOk || english.Dimension() != metric.Dimension() {
+			t.Errorf("Unexpected units for %s %s %s", product, english, metric)
+		}
+	}
+	if unit, _ := Wind.Unit(English); unit != units.Knots {
+		t.Errorf("Expected knots but got %s", unit)
+	}
+}
+
+//TestPreferredMeasurementUnit - english is english no matter the case and everything else is metric
+func TestPreferredMeasurementUnit(t *testing.T) {
+	for preferredMetric, expected := range map[string]MeasurementUnit{"english": English, "English": English, "metric": Metric, "Metric": Metric, "": Metric} {
+		if PreferredMeasurementUnit(preferredMetric) != expected {
+			t.Errorf("Expected %s for %q", expected, preferredMetric)
+		}
+	}
+}
+
+//TestConvertProductUnits - the primary value and the values in the same unit move and everything else stays put
+func TestConvertProductUnits(t *testing.T) {
+	waterLevel := decodeSample(t, WaterLevel)
+	if err := ConvertProductUnits(waterLevel, Metric, units.Feet, Metric); err != nil {
+		t.Fatal(err.Error())
+	}
+	first := waterLevel.Data[0]
+	if waterLevel.Unit != "ft" || first.V != "4.049" || first.S != "0.01" || waterLevel.Data[2].V != "" || math.Abs(waterLevel.TypedData[0].Value-4.048556) > 0.000001 || waterLevel.TypedData[2].Value != 0 {
+		t.Errorf("Unexpected water level %+v %+v", first, waterLevel.TypedData[0])
+	}
+
+	wind := decodeSample(t, Wind)
+	if err := ConvertProductUnits(wind, Metric, units.Knots, Metric); err != nil {
+		t.Fatal(err.Error())
+	}
+	if wind.Data[0].S != "8.203" || wind.Data[0].G != "12.363" || wind.Data[0].D != "197.00" || math.Abs(wind.TypedData[0].Values[GustValue]-12.363) > 0.001 || wind.TypedData[0].Values[DirectionValue] != 197 {
+		t.Errorf("Unexpected wind %+v %+v", wind.Data[0], wind.TypedData[0])
+	}
+
+	//The speed is in knots and the depth follows the preferred metric
+	currents := decodeSample(t, CurrentsPredictions)
+	if err := ConvertProductUnits(currents, Metric, units.Knots, English); err != nil {
+		t.Fatal(err.Error())
+	}
+	if currents.Data[0].Values["depth"] != "26.247" || currents.Data[0].Values["meanFloodDir"] != "16" || currents.Data[0].V != "0.022" {
+		t.Errorf("Unexpected current predictions %+v", currents.Data[0])
+	}
+
+	//Nothing changes when the unit is the one it came in
+	pressure := decodeSample(t, AirPressure)
+	before := pressure.Data[0].V
+	if err := ConvertProductUnits(pressure, English, units.Millibars, English); err != nil || pressure.Data[0].V != before || pressure.Unit != "mb" {
+		t.Errorf("Expected the pressure as is but got %v %+v", err, pressure.Data[0])
+	}
+
+	if err := ConvertProductUnits(decodeSample(t, Wind), Metric, units.Feet, Metric); !isInvalidData(err) {
+		t.Errorf("Expected invalid data but got %v", err)
+	}
+	waterLevel = decodeSample(t, WaterLevel)
+	waterLevel.Data[1].S = "junk"
+	if err := ConvertProductUnits(waterLevel, Metric, units.Feet, Metric); !isBadFormat(err) || waterLevel.Data[0].V != "1.234" {
+		t.Errorf("Expected a bad format with nothing converted but got %v", err)
+	}
+}
+
+//decodeSample - the sample payload for the product with the typed data filled in
+func decodeSample(t *testing.T, dataProduct DataProduct) *sledgconf_demo_proto_v1.ProductDataValues {
+	productData, err := decodeProductResponse(dataProduct, bytes.NewReader(loadSamplePayload(t, dataProduct)), 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	observations, err := ConvertToObservations(productData, time.UTC)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	productData.TypedData = ConvertObservationsToGrpc(observations)
+	return productData
+}
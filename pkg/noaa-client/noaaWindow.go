@@ -0,0 +1,136 @@
+package noaaclient
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+
+	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
+	"github.com/mornindew/sledgeconf2021/pkg/utils"
+)
+
+//MaximumChunks - the most calls to Noaa that one window can be split into (about 10 years of the 6 minute products).  Longer windows are turned away before anything is asked for
+const MaximumChunks = 120
+
+//QueryWindow - the different ways that Noaa lets you address the time window of a request
+//
+//	DateRangeQuery - BeginDate to EndDate (minute precision)
+//	LatestQuery - the last data point available (water level and met products)
+//	TodayQuery - today's data starting at midnight
+//	RecentQuery - the last 3 days
+//	RangeQuery - RangeInHours before EndDate (or before now if there isn't an EndDate)
+type QueryWindow struct {
+	Mode         QueryMode
+	BeginDate    *time.Time
+	EndDate      *time.Time
+	RangeInHours int
+}
+
+//NewDateRangeWindow - window between two times
+func NewDateRangeWindow(beginDate, endDate *time.Time) *QueryWindow {
+	return &QueryWindow{Mode: DateRangeQuery, BeginDate: beginDate, EndDate: endDate}
+}
+
+//NewRangeWindow - window that covers the hours before now
+func NewRangeWindow(rangeInHours int) *QueryWindow {
+	return &QueryWindow{Mode: RangeQuery, RangeInHours: rangeInHours}
+}
+
+//NewQueryModeWindow - window for the modes that don't take any parameters (latest, today and recent)
+func NewQueryModeWindow(mode QueryMode) *QueryWindow {
+	return &QueryWindow{Mode: mode}
+}
+
+//Validate - checks that the window has what the mode needs
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	InvalidData - incorrect data
+func (object *QueryWindow) Validate() error {
+	switch object.Mode {
+	case DateRangeQuery:
+		if object.BeginDate == nil || object.EndDate == nil {
+			return customerrors.PreconditionError{Msg: "Missing Mandatory Data"}
+		}
+		//check that start date is after end date
+		if !object.EndDate.After(*object.BeginDate) {
+			return customerrors.InvalidData{Msg: "The End Date is Not After the Start Date", InternalErrorCode: 1156}
+		}
+	case RangeQuery:
+		if object.RangeInHours <= 0 {
+			return customerrors.InvalidData{Msg: "The Range Must Be A Positive Number Of Hours", InternalErrorCode: 1157}
+		}
+	case LatestQuery, TodayQuery, RecentQuery:
+		//Nothing else needed
+	default:
+		return customerrors.InvalidData{Msg: "Not a valid query mode", InternalErrorCode: 1158}
+	}
+	return nil
+}
+
+//validateForProduct - checks that the window doesn't need more than MaximumChunks calls for the product
+//
+//	Errors:
+//	InvalidData - the window is too long
+func (object *QueryWindow) validateForProduct(dataProduct DataProduct) error {
+	resolved := object.resolveForProduct(dataProduct)
+	maximum := dataProduct.MaximumRequestWindow()
+	if resolved.Mode != DateRangeQuery || maximum <= 0 {
+		return nil
+	}
+	//Same count as splitTimeWindow without making the chunks
+	chunks := (resolved.EndDate.Sub(*resolved.BeginDate) + maximum - 1) / maximum
+	if chunks > MaximumChunks {
+		return customerrors.InvalidData{Msg: "The window is too long for " + dataProduct.String() + ".  It would take " + strconv.FormatInt(int64(chunks), 10) + " calls to Noaa and the most is " + strconv.Itoa(MaximumChunks), InternalErrorCode: 1176}
+	}
+	return nil
+}
+
+//resolveForProduct - a range that is longer than Noaa allows for the product gets converted over to a date range so that it can be chunked
+func (object *QueryWindow) resolveForProduct(dataProduct DataProduct) *QueryWindow {
+	maximum := dataProduct.MaximumRequestWindow()
+	rangeDuration := time.Duration(object.RangeInHours) * time.Hour
+	if object.Mode != RangeQuery || maximum <= 0 || rangeDuration <= maximum {
+		return object
+	}
+	endDate := time.Now()
+	if object.EndDate != nil {
+		endDate = *object.EndDate
+	}
+	beginDate := endDate.Add(-rangeDuration)
+	return NewDateRangeWindow(&beginDate, &endDate)
+}
+
+//chunksForProduct - splits the window up so that no chunk is longer than Noaa allows for the product
+func (object *QueryWindow) chunksForProduct(dataProduct DataProduct) []*QueryWindow {
+	resolved := object.resolveForProduct(dataProduct)
+	//Only date ranges can be split
+	if resolved.Mode != DateRangeQuery {
+		return []*QueryWindow{resolved}
+	}
+	timeWindows := splitTimeWindow(*resolved.BeginDate, *resolved.EndDate, dataProduct.MaximumRequestWindow())
+	chunks := make([]*QueryWindow, 0, len(timeWindows))
+	for index := range timeWindows {
+		chunks = append(chunks, NewDateRangeWindow(&timeWindows[index].start, &timeWindows[index].end))
+	}
+	return chunks
+}
+
+//addQueryValues - adds the url params for the window.  Noaa reads the dates in the time zone that is asked for so they are formatted in the location.  Nil is gmt
+func (object *QueryWindow) addQueryValues(values url.Values, location *time.Location) {
+	if location == nil {
+		location = time.UTC
+	}
+	switch object.Mode {
+	case DateRangeQuery:
+		values.Set("begin_date", utils.ConvertTimeToyyyyMMddHHmm(object.BeginDate.In(location)))
+		values.Set("end_date", utils.ConvertTimeToyyyyMMddHHmm(object.EndDate.In(location)))
+	case RangeQuery:
+		values.Set("range", strconv.Itoa(object.RangeInHours))
+		if object.EndDate != nil {
+			values.Set("end_date", utils.ConvertTimeToyyyyMMddHHmm(object.EndDate.In(location)))
+		}
+	default:
+		values.Set("date", object.Mode.String())
+	}
+}
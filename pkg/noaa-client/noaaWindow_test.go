@@ -0,0 +1,105 @@
+package noaaclient
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+)
+
+//TestWindowQueryParams - each query mode should send the right params to Noaa
+func TestWindowQueryParams(t *testing.T) {
+	endTime := time.Date(2021, time.August, 20, 15, 6, 0, 0, time.UTC)
+	startTime := endTime.Add(-2 * time.Hour)
+
+	tests := []struct {
+		window   *QueryWindow
+		expected string
+	}{
+		{NewDateRangeWindow(&startTime, &endTime), "begin_date=20210820+13%3A06&end_date=20210820+15%3A06"},
+		{NewQueryModeWindow(LatestQuery), "date=latest"},
+		{NewQueryModeWindow(TodayQuery), "date=today"},
+		{NewQueryModeWindow(RecentQuery), "date=recent"},
+		{NewRangeWindow(6), "range=6"},
+		{&QueryWindow{Mode: RangeQuery, RangeInHours: 6, EndDate: &endTime}, "end_date=20210820+15%3A06&range=6"},
+	}
+	for _, test := range tests {
+		if err := test.window.Validate(); err != nil {
+			t.Error(err.Error())
+		}
+		if params := encodeWindow(test.window); params != test.expected {
+			t.Error("Expected " + test.expected + " but got " + params)
+		}
+	}
+	//Times in other zones are sent in gmt
+	eastern := time.FixedZone("EDT", -4*60*60)
+	localStart := startTime.In(eastern)
+	localEnd := endTime.In(eastern)
+	if params := encodeWindow(NewDateRangeWindow(&localStart, &localEnd)); params != tests[0].expected {
+		t.Error("Expected the dates in gmt but got " + params)
+	}
+}
+
+//TestWindowValidate - bad windows should not make it to Noaa
+func TestWindowValidate(t *testing.T) {
+	endTime := time.Now()
+	startTime := endTime.Add(time.Hour)
+	if NewDateRangeWindow(&startTime, &endTime).Validate() == nil {
+		t.Error("Expected the end before the start to fail")
+	}
+	if NewDateRangeWindow(nil, &endTime).Validate() == nil {
+		t.Error("Expected a missing start to fail")
+	}
+	if NewRangeWindow(0).Validate() == nil {
+		t.Error("Expected a range of 0 to fail")
+	}
+	if NewQueryModeWindow(QueryMode(42)).Validate() == nil {
+		t.Error("Expected a bad mode to fail")
+	}
+}
+
+//TestWindowChunks - long ranges are converted to dates so they can be chunked and everything else is left alone
+func TestWindowChunks(t *testing.T) {
+	if chunks := NewRangeWindow(24 * 60).chunksForProduct(WaterLevel); len(chunks) != 2 || chunks[0].Mode != DateRangeQuery {
+		t.Error("Expected a 60 day range to be split into date ranges")
+	}
+	if chunks := NewRangeWindow(24).chunksForProduct(WaterLevel); len(chunks) != 1 || chunks[0].Mode != RangeQuery {
+		t.Error("Expected a short range to be left alone")
+	}
+	if chunks := NewQueryModeWindow(RecentQuery).chunksForProduct(OneMinuteWaterLevel); len(chunks) != 1 || chunks[0].Mode != RecentQuery {
+		t.Error("Expected recent to be left alone")
+	}
+}
+
+//TestWindowMaximumChunks - windows that would take too many calls to Noaa are turned away before any are made
+func TestWindowMaximumChunks(t *testing.T) {
+	endTime := time.Date(2021, time.August, 20, 15, 0, 0, 0, time.UTC)
+	startTime := endTime.Add(-MaximumChunks * WaterLevel.MaximumRequestWindow())
+	if err := NewDateRangeWindow(&startTime, &endTime).validateForProduct(WaterLevel); err != nil {
+		t.Errorf("Expected %d chunks to be allowed but got %v", MaximumChunks, err)
+	}
+	startTime = startTime.Add(-time.Minute)
+	if !isInvalidData(NewDateRangeWindow(&startTime, &endTime).validateForProduct(WaterLevel)) {
+		t.Error("Expected one more chunk to fail")
+	}
+	//Longer products take longer windows
+	if err := NewDateRangeWindow(&startTime, &endTime).validateForProduct(HourlyHeight); err != nil {
+		t.Errorf("Expected hourly heights to be allowed but got %v", err)
+	}
+	//Long ranges are converted to dates so they count too
+	if !isInvalidData(NewRangeWindow(24 * 5 * 365).validateForProduct(OneMinuteWaterLevel)) {
+		t.Error("Expected a long range to fail")
+	}
+	stationID := "8454000"
+	client := NewNoaaClient(MLLW, "metric", WithBaseURL("http://noaa.invalid"), WithCircuitBreakers(nil))
+	if _, err := client.RetrieveDataForWindow(context.Background(), NewDateRangeWindow(&startTime, &endTime), GMT, WaterLevel, &stationID); !isInvalidData(err) {
+		t.Errorf("Expected the request to be turned away but got %v", err)
+	}
+}
+
+//encodeWindow - just the window params, encoded the same way they go to Noaa
+func encodeWindow(window *QueryWindow) string {
+	values := url.Values{}
+	window.addQueryValues(values, nil)
+	return values.Encode()
+}
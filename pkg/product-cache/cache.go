@@ -0,0 +1,226 @@
+package productcache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
+	sledgconf_demo_proto_v1 "github.com/mornindew/sledgeconf2021/pkg/grpc-service/genProto"
+	noaaclient "github.com/mornindew/sledgeconf2021/pkg/noaa-client"
+)
+
+//DefaultMaxBytes - how big the cache can get before the least recently used data is thrown away
+const DefaultMaxBytes int64 = 256 << 20
+
+//TTLPolicy - how long data is kept depending on the product and how old the window is
+type TTLPolicy struct {
+	//Live - windows that move with the clock (latest, today, recent and ranges without an end).  Noaa adds a point every 6 minutes
+	Live time.Duration
+	//Recent - windows that ended less than HistoricalAge ago.  Noaa can still fill in or correct the data
+	Recent time.Duration
+	//Preliminary - older windows with water levels that haven't been verified yet
+	Preliminary time.Duration
+	//Historical - older windows with verified data.  It doesn't change
+	Historical time.Duration
+	//Predictions - predictions and datums are calculated so they don't change for a window
+	Predictions time.Duration
+	//HistoricalAge - how long ago a window has to end before its data is historical
+	HistoricalAge time.Duration
+}
+
+//DefaultTTLPolicy - what NewCache uses if the policy is empty
+var DefaultTTLPolicy = TTLPolicy{
+	Live:          time.Minute,
+	Recent:        10 * time.Minute,
+	Preliminary:   time.Hour,
+	Historical:    24 * time.Hour,
+	Predictions:   24 * time.Hour,
+	HistoricalAge: 48 * time.Hour,
+}
+
+//Stats - how the cache is doing
+type Stats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	//Bypassed - calls that skipped the cache (see WithBypass)
+	Bypassed int64 `json:"bypassed"`
+	//Evictions - data thrown away to stay under the max size
+	Evictions int64 `json:"evictions"`
+	Entries   int   `json:"entries"`
+	Bytes     int64 `json:"bytes"`
+}
+
+//Cache - keeps the data for requests in memory so the same window doesn't have to come from Noaa again.  Keyed on the request's Key (all the params)
+//
+//The size is bounded in bytes and the least recently used data goes first.  Expired data is kept until it is replaced or evicted so it can still be served when Noaa is down (see LocalData).  Errors aren't cached.  Safe to use from many go routines at once
+type Cache struct {
+	fetcher  noaaclient.DataFetcher
+	maxBytes int64
+	policy   TTLPolicy
+	lock     sync.Mutex
+	//entries - the list element for each key.  The front of the list is the most recently used
+	entries map[string]*list.Element
+	order   *list.List
+	bytes   int64
+	stats   Stats
+	//now - swapped out in the tests
+	now func() time.Time
+}
+
+//NewCache - Constructor.  Wraps the fetcher that the misses go to (e.g. a NoaaClient).  Anything less than 1 for the max size uses the default and an empty policy uses the DefaultTTLPolicy
+func NewCache(fetcher noaaclient.DataFetcher, maxBytes int64, policy TTLPolicy) *Cache {
+	if maxBytes < 1 {
+		maxBytes = DefaultMaxBytes
+	}
+	if policy == (TTLPolicy{}) {
+		policy = DefaultTTLPolicy
+	}
+	return &Cache{fetcher: fetcher, maxBytes: maxBytes, policy: policy, entries: make(map[string]*list.Element), order: list.New(), now: time.Now}
+}
+
+//WithBypass - calls made with the context skip the cache and go to the fetcher.  What comes back still replaces what was cached
+func WithBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassKey{}, true)
+}
+
+//RetrieveData - returns a copy of the cached data if it hasn't expired, otherwise gets it from the fetcher and caches it
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	Anything that the fetcher returns
+func (object *Cache) RetrieveData(ctx context.Context, request *noaaclient.DataRequest) (*sledgconf_demo_proto_v1.ProductDataValues, error) {
+	//Precondition
+	if ctx == nil || request == nil {
+		return nil, customerrors.PreconditionError{Msg: "Empty Mandatory Values"}
+	}
+	key := request.Key()
+	if bypassed(ctx) {
+		object.lock.Lock()
+		object.stats.Bypassed++
+		object.lock.Unlock()
+	} else if productData := object.lookup(key); productData != nil {
+		return productData, nil
+	}
+	productData, err := object.fetcher.RetrieveData(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	object.store(key, request, productData)
+	return productData, nil
+}
+
+//LocalData - a copy of whatever is cached for the request even if it has expired along with when it came from the fetcher.  Nil if nothing is cached.  Doesn't count as a hit or a miss
+func (object *Cache) LocalData(request *noaaclient.DataRequest) (*sledgconf_demo_proto_v1.ProductDataValues, time.Time, error) {
+	//Precondition
+	if request == nil {
+		return nil, time.Time{}, customerrors.PreconditionError{Msg: "Empty Mandatory Values"}
+	}
+	object.lock.Lock()
+	defer object.lock.Unlock()
+	element, ok := object.entries[request.Key()]
+	if !ok {
+		return nil, time.Time{}, nil
+	}
+	entry := element.Value.(*cacheEntry)
+	return proto.Clone(entry.productData).(*sledgconf_demo_proto_v1.ProductDataValues), entry.fetched, nil
+}
+
+//Stats - the counts since the cache was made
+func (object *Cache) Stats() Stats {
+	object.lock.Lock()
+	defer object.lock.Unlock()
+	stats := object.stats
+	stats.Entries = len(object.entries)
+	stats.Bytes = object.bytes
+	return stats
+}
+
+//TTL - how long the data for the request is kept.  Zero means it isn't cached
+func (object TTLPolicy) TTL(request *noaaclient.DataRequest, productData *sledgconf_demo_proto_v1.ProductDataValues, now time.Time) time.Duration {
+	window := request.Window
+	//Windows without an end move with the clock so what comes back keeps changing
+	if window == nil || window.EndDate == nil || (window.Mode != noaaclient.DateRangeQuery && window.Mode != noaaclient.RangeQuery) {
+		return object.Live
+	}
+	switch request.Product {
+	case noaaclient.Preditions, noaaclient.CurrentsPredictions, noaaclient.Datums:
+		return object.Predictions
+	}
+	if now.Sub(*window.EndDate) < object.HistoricalAge {
+		return object.Recent
+	}
+	//Water levels say if they have been verified (v) or are still preliminary (p)
+	for _, dataPoint := range productData.Data {
+		if dataPoint.Q == "p" {
+			return object.Preliminary
+		}
+	}
+	return object.Historical
+}
+
+///INTERNAL FUNCTIONS
+
+type bypassKey struct{}
+
+//bypassed - whether the context was made with WithBypass
+func bypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassKey{}).(bool)
+	return bypass
+}
+
+//cacheEntry - the data for a key, when it came from the fetcher and when it expires
+type cacheEntry struct {
+	key         string
+	productData *sledgconf_demo_proto_v1.ProductDataValues
+	size        int64
+	fetched     time.Time
+	expires     time.Time
+}
+
+//lookup - a copy of the data if it is there and hasn't expired.  Nil on a miss
+func (object *Cache) lookup(key string) *sledgconf_demo_proto_v1.ProductDataValues {
+	object.lock.Lock()
+	defer object.lock.Unlock()
+	element, ok := object.entries[key]
+	if ok && object.now().Before(element.Value.(*cacheEntry).expires) {
+		object.stats.Hits++
+		object.order.MoveToFront(element)
+		//Callers get a copy so they cannot change what is cached
+		return proto.Clone(element.Value.(*cacheEntry).productData).(*sledgconf_demo_proto_v1.ProductDataValues)
+	}
+	//Expired data stays until the fresh data replaces it
+	object.stats.Misses++
+	return nil
+}
+
+//store - caches a copy of the data and throws away the least recently used data until it fits.  Data bigger than the whole cache isn't kept
+func (object *Cache) store(key string, request *noaaclient.DataRequest, productData *sledgconf_demo_proto_v1.ProductDataValues) {
+	now := object.now()
+	ttl := object.policy.TTL(request, productData, now)
+	size := int64(proto.Size(productData) + len(key))
+	if ttl <= 0 || size > object.maxBytes {
+		return
+	}
+	entry := &cacheEntry{key: key, productData: proto.Clone(productData).(*sledgconf_demo_proto_v1.ProductDataValues), size: size, fetched: now, expires: now.Add(ttl)}
+	object.lock.Lock()
+	defer object.lock.Unlock()
+	if element, ok := object.entries[key]; ok {
+		object.remove(element)
+	}
+	object.entries[key] = object.order.PushFront(entry)
+	object.bytes += size
+	for object.bytes > object.maxBytes {
+		object.remove(object.order.Back())
+		object.stats.Evictions++
+	}
+}
+
+//remove - takes the entry out of the cache.  Must hold the lock
+func (object *Cache) remove(element *list.Element) {
+	entry := object.order.Remove(element).(*cacheEntry)
+	delete(object.entries, entry.key)
+	object.bytes -= entry.size
+}
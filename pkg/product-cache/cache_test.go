@@ -0,0 +1,193 @@
+package productcache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	sledgconf_demo_proto_v1 "github.com/mornindew/sledgeconf2021/pkg/grpc-service/genProto"
+	noaaclient "github.com/mornindew/sledgeconf2021/pkg/noaa-client"
+)
+
+//fetcherFunc - lets a function stand in for the client
+type fetcherFunc func(ctx context.Context, request *noaaclient.DataRequest) (*sledgconf_demo_proto_v1.ProductDataValues, error)
+
+func (fetch fetcherFunc) RetrieveData(ctx context.Context, request *noaaclient.DataRequest) (*sledgconf_demo_proto_v1.ProductDataValues, error) {
+	return fetch(ctx, request)
+}
+
+//newCountingFetcher - a fetcher that counts its calls and sends back one data point with the quality flag
+func newCountingFetcher(calls *int, quality string) fetcherFunc {
+	return func(ctx context.Context, request *noaaclient.DataRequest) (*sledgconf_demo_proto_v1.ProductDataValues, error) {
+		*calls++
+		return &sledgconf_demo_proto_v1.ProductDataValues{DataType: request.Product.ConvertToGrpcEnum(), Data: []*sledgconf_demo_proto_v1.Data{{T: "2021-08-20 15:00", V: "1.234", Q: quality}}}, nil
+	}
+}
+
+//newRequest - a request for the six hours after the begin date
+func newRequest(t *testing.T, stationID string, dataProduct noaaclient.DataProduct, beginDate time.Time) *noaaclient.DataRequest {
+	endDate := beginDate.Add(6 * time.Hour)
+	request, err := noaaclient.NewDataRequestBuilder(stationID, dataProduct).Window(noaaclient.NewDateRangeWindow(&beginDate, &endDate)).Build()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return request
+}
+
+//TestCacheHitsAndMisses - the second call comes out of the cache as a copy.  Errors aren't cached and the bypass goes to the fetcher
+func TestCacheHitsAndMisses(t *testing.T) {
+	calls := 0
+	cache := NewCache(newCountingFetcher(&calls, "v"), 0, TTLPolicy{})
+	request := newRequest(t, "8454000", noaaclient.WaterLevel, time.Date(2021, 8, 20, 15, 0, 0, 0, time.UTC))
+
+	first, err := cache.RetrieveData(context.Background(), request)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	first.Data[0].V = "changed"
+	second, err := cache.RetrieveData(context.Background(), request)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if calls != 1 || second.Data[0].V != "1.234" {
+		t.Errorf("Expected an unchanged copy from the cache but got %d calls and %s", calls, second.Data[0].V)
+	}
+	_, err = cache.RetrieveData(WithBypass(context.Background()), request)
+	if err != nil || calls != 2 {
+		t.Errorf("Expected the bypass to call the fetcher but got %d calls and %v", calls, err)
+	}
+
+	failing := NewCache(fetcherFunc(func(ctx context.Context, request *noaaclient.DataRequest) (*sledgconf_demo_proto_v1.ProductDataValues, error) {
+		calls++
+		return nil, errors.New("Noaa is having a bad day")
+	}), 0, TTLPolicy{})
+	for index := 0; index < 2; index++ {
+		if _, err := failing.RetrieveData(context.Background(), request); err == nil {
+			t.Error("Expected the error")
+		}
+	}
+	if calls != 4 {
+		t.Errorf("Expected errors not to be cached but got %d calls", calls)
+	}
+
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Bypassed != 1 || stats.Entries != 1 || stats.Bytes <= 0 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+//TestCacheExpires - data is thrown away once its ttl is up
+func TestCacheExpires(t *testing.T) {
+	calls := 0
+	cache := NewCache(newCountingFetcher(&calls, "p"), 0, TTLPolicy{})
+	now := time.Date(2021, 8, 21, 0, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+	//Ended 3 hours before now so it is recent
+	request := newRequest(t, "8454000", noaaclient.WaterLevel, now.Add(-9*time.Hour))
+	cache.RetrieveData(context.Background(), request)
+	now = now.Add(DefaultTTLPolicy.Recent - time.Second)
+	cache.RetrieveData(context.Background(), request)
+	if calls != 1 {
+		t.Errorf("Expected the data to still be cached but got %d calls", calls)
+	}
+	now = now.Add(2 * time.Second)
+	cache.RetrieveData(context.Background(), request)
+	if calls != 2 {
+		t.Errorf("Expected the data to have expired but got %d calls", calls)
+	}
+}
+
+//TestCacheLocalData - expired data is still on hand along with when it was fetched
+func TestCacheLocalData(t *testing.T) {
+	calls := 0
+	cache := NewCache(newCountingFetcher(&calls, "v"), 0, TTLPolicy{})
+	fetched := time.Date(2021, 8, 21, 0, 0, 0, 0, time.UTC)
+	now := fetched
+	cache.now = func() time.Time { return now }
+	request := newRequest(t, "8454000", noaaclient.WaterLevel, now.Add(-9*time.Hour))
+	if productData, _, err := cache.LocalData(request); productData != nil || err != nil {
+		t.Errorf("Expected nothing on hand but got %v and %v", productData, err)
+	}
+	cache.RetrieveData(context.Background(), request)
+	now = now.Add(24 * time.Hour)
+	productData, when, err := cache.LocalData(request)
+	if err != nil || productData == nil || len(productData.Data) != 1 || !when.Equal(fetched) {
+		t.Errorf("Expected the expired data from %s but got %v at %s and %v", fetched, productData, when, err)
+	}
+	if stats := cache.Stats(); stats.Hits != 0 || stats.Misses != 1 {
+		t.Errorf("Expected local data not to be counted but got %+v", stats)
+	}
+}
+
+//TestCacheEviction - the least recently used data goes first once the cache is full
+func TestCacheEviction(t *testing.T) {
+	calls := 0
+	fetcher := newCountingFetcher(&calls, "v")
+	beginDate := time.Date(2021, 8, 20, 15, 0, 0, 0, time.UTC)
+	requests := []*noaaclient.DataRequest{newRequest(t, "8454000", noaaclient.WaterLevel, beginDate), newRequest(t, "8452944", noaaclient.WaterLevel, beginDate), newRequest(t, "8447386", noaaclient.WaterLevel, beginDate)}
+	//Room for two of them
+	productData, _ := fetcher.RetrieveData(context.Background(), requests[0])
+	size := int64(proto.Size(productData) + len(requests[0].Key()))
+	cache := NewCache(fetcher, 2*size+size/2, TTLPolicy{})
+	calls = 0
+
+	cache.RetrieveData(context.Background(), requests[0])
+	cache.RetrieveData(context.Background(), requests[1])
+	//Using the first one makes the second one the least recently used
+	cache.RetrieveData(context.Background(), requests[0])
+	cache.RetrieveData(context.Background(), requests[2])
+	if calls != 3 {
+		t.Fatalf("Expected 3 calls but got %d", calls)
+	}
+	cache.RetrieveData(context.Background(), requests[0])
+	cache.RetrieveData(context.Background(), requests[2])
+	if calls != 3 {
+		t.Errorf("Expected the first and last to be cached but got %d calls", calls)
+	}
+	cache.RetrieveData(context.Background(), requests[1])
+	if calls != 4 {
+		t.Errorf("Expected the second to have been evicted but got %d calls", calls)
+	}
+	stats := cache.Stats()
+	if stats.Evictions != 2 || stats.Entries != 2 || stats.Bytes > 2*size+size/2 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	//Something bigger than the whole cache isn't kept
+	tiny := NewCache(fetcher, 1, TTLPolicy{})
+	tiny.RetrieveData(context.Background(), requests[0])
+	if stats := tiny.Stats(); stats.Entries != 0 || stats.Bytes != 0 {
+		t.Errorf("Expected nothing to be cached but got %+v", stats)
+	}
+}
+
+//TestTTLPolicy - live windows are short, verified history and predictions are long
+func TestTTLPolicy(t *testing.T) {
+	now := time.Date(2021, 8, 30, 0, 0, 0, 0, time.UTC)
+	old := now.Add(-30 * 24 * time.Hour)
+	verified := &sledgconf_demo_proto_v1.ProductDataValues{Data: []*sledgconf_demo_proto_v1.Data{{Q: "v"}}}
+	preliminary := &sledgconf_demo_proto_v1.ProductDataValues{Data: []*sledgconf_demo_proto_v1.Data{{Q: "v"}, {Q: "p"}}}
+	latest, _ := noaaclient.NewDataRequestBuilder("8454000", noaaclient.WaterLevel).Window(noaaclient.NewQueryModeWindow(noaaclient.LatestQuery)).Build()
+	rangeWindow, _ := noaaclient.NewDataRequestBuilder("8454000", noaaclient.WaterLevel).Window(noaaclient.NewRangeWindow(6)).Build()
+	tests := []struct {
+		name        string
+		request     *noaaclient.DataRequest
+		productData *sledgconf_demo_proto_v1.ProductDataValues
+		expected    time.Duration
+	}{
+		{"latest", latest, verified, DefaultTTLPolicy.Live},
+		{"range without an end", rangeWindow, verified, DefaultTTLPolicy.Live},
+		{"recent", newRequest(t, "8454000", noaaclient.WaterLevel, now.Add(-12*time.Hour)), verified, DefaultTTLPolicy.Recent},
+		{"preliminary", newRequest(t, "8454000", noaaclient.WaterLevel, old), preliminary, DefaultTTLPolicy.Preliminary},
+		{"verified", newRequest(t, "8454000", noaaclient.WaterLevel, old), verified, DefaultTTLPolicy.Historical},
+		{"met", newRequest(t, "8454000", noaaclient.Wind, old), &sledgconf_demo_proto_v1.ProductDataValues{}, DefaultTTLPolicy.Historical},
+		{"predictions", newRequest(t, "8454000", noaaclient.Preditions, now), &sledgconf_demo_proto_v1.ProductDataValues{}, DefaultTTLPolicy.Predictions},
+	}
+	for _, test := range tests {
+		if ttl := DefaultTTLPolicy.TTL(test.request, test.productData, now); ttl != test.expected {
+			t.Errorf("%s: expected %s but got %s", test.name, test.expected, ttl)
+		}
+	}
+}
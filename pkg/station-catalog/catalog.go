@@ -0,0 +1,527 @@
+package stationcatalog
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
+	sledgconf_demo_proto_v1 "github.com/mornindew/sledgeconf2021/pkg/grpc-service/genProto"
+	noaaclient "github.com/mornindew/sledgeconf2021/pkg/noaa-client"
+)
+
+//DefaultLimit - how many stations come back from Nearest and Search when the limit isn't set
+const DefaultLimit = 10
+
+//DefaultRefreshInterval - how often the catalog is pulled again from Noaa.  Stations don't come and go very often
+const DefaultRefreshInterval = 7 * 24 * time.Hour
+
+//snapshot - the catalog that ships with the service so it works without calling Noaa.  It is only a subset of the stations (enough for the demo) and Run pulls the full lists when it starts
+//
+//go:embed stations.json
+var snapshot []byte
+
+//Lister - where the catalog is refreshed from (e.g. a NoaaClient)
+type Lister interface {
+	RetrieveStations(ctx context.Context, stationType noaaclient.StationType) ([]noaaclient.StationMetadata, error)
+}
+
+//Station - a station in the catalog and the products it has
+type Station struct {
+	ID        string                   `json:"id"`
+	Name      string                   `json:"name"`
+	State     string                   `json:"state"`
+	Latitude  float64                  `json:"lat"`
+	Longitude float64                  `json:"lng"`
+	Products  []noaaclient.DataProduct `json:"-"`
+}
+
+//Has - whether the station has every one of the products
+func (object *Station) Has(dataProducts ...noaaclient.DataProduct) bool {
+	for _, dataProduct := range dataProducts {
+		found := false
+		for _, product := range object.Products {
+			if product == dataProduct {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+//Result - a station that matched a query.  The distance is only set by Nearest and the score is only set by Search
+type Result struct {
+	Station      Station
+	DistanceInKm float64
+	Score        float64
+}
+
+//ConvertToGrpc - the result as the protobuf message
+func (object *Result) ConvertToGrpc() *sledgconf_demo_proto_v1.CatalogStation {
+	products := make([]sledgconf_demo_proto_v1.DataType, 0, len(object.Station.Products))
+	for _, product := range object.Station.Products {
+		products = append(products, product.ConvertToGrpcEnum())
+	}
+	return &sledgconf_demo_proto_v1.CatalogStation{
+		StationID:    object.Station.ID,
+		Name:         object.Station.Name,
+		State:        object.Station.State,
+		Latitude:     object.Station.Latitude,
+		Longitude:    object.Station.Longitude,
+		Products:     products,
+		DistanceInKm: object.DistanceInKm,
+		Score:        object.Score,
+	}
+}
+
+//ConvertResultsToGrpc - the results as the protobuf response
+func ConvertResultsToGrpc(results []Result) *sledgconf_demo_proto_v1.StationCatalogResponse {
+	response := &sledgconf_demo_proto_v1.StationCatalogResponse{Stations: make([]*sledgconf_demo_proto_v1.CatalogStation, 0, len(results))}
+	for index := range results {
+		response.Stations = append(response.Stations, results[index].ConvertToGrpc())
+	}
+	return response
+}
+
+//Catalog - every station that Noaa has along with where it is and what products it has.  Safe to use from many go routines at once
+type Catalog struct {
+	lock      sync.RWMutex
+	stations  []Station
+	refreshed time.Time
+	//now - swapped out in the tests
+	now func() time.Time
+}
+
+//New - Constructor.  Starts with the snapshot that is embedded in the service
+//
+//	Errors:
+//	BadFormat - the embedded snapshot couldn't be decoded
+func New() (*Catalog, error) {
+	catalog := &Catalog{now: time.Now}
+	if err := catalog.Load(bytes.NewReader(snapshot)); err != nil {
+		return nil, err
+	}
+	return catalog, nil
+}
+
+//Load - replaces the stations with a snapshot (the same format that Save writes)
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	BadFormat - the snapshot couldn't be decoded
+func (object *Catalog) Load(reader io.Reader) error {
+	//Precondition
+	if reader == nil {
+		return customerrors.PreconditionError{Msg: "Empty Mandatory Values"}
+	}
+	file := &snapshotFile{}
+	if err := json.NewDecoder(reader).Decode(file); err != nil {
+		return customerrors.BadFormat{Msg: "Unable to decode the station catalog: " + err.Error()}
+	}
+	stations := make([]Station, 0, len(file.Stations))
+	for _, row := range file.Stations {
+		station := row.Station
+		for _, productName := range row.Products {
+			product, err := noaaclient.ConvertStringDataProductToEnum(productName)
+			if err != nil {
+				return customerrors.BadFormat{Msg: "Unknown product " + productName + " for station " + station.ID}
+			}
+			station.Products = append(station.Products, product)
+		}
+		stations = append(stations, station)
+	}
+	object.replace(stations, file.Refreshed)
+	return nil
+}
+
+//Save - writes the stations out as a snapshot that Load (and the embedded snapshot) can read
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	Anything that the writer returns
+func (object *Catalog) Save(writer io.Writer) error {
+	//Precondition
+	if writer == nil {
+		return customerrors.PreconditionError{Msg: "Empty Mandatory Values"}
+	}
+	object.lock.RLock()
+	file := &snapshotFile{Refreshed: object.refreshed, Stations: make([]snapshotStation, 0, len(object.stations))}
+	for _, station := range object.stations {
+		row := snapshotStation{Station: station, Products: make([]string, 0, len(station.Products))}
+		for _, product := range station.Products {
+			row.Products = append(row.Products, product.String())
+		}
+		file.Stations = append(file.Stations, row)
+	}
+	object.lock.RUnlock()
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(file)
+}
+
+//Refreshed - when the stations were pulled from Noaa
+func (object *Catalog) Refreshed() time.Time {
+	object.lock.RLock()
+	defer object.lock.RUnlock()
+	return object.refreshed
+}
+
+//Len - how many stations are in the catalog
+func (object *Catalog) Len() int {
+	object.lock.RLock()
+	defer object.lock.RUnlock()
+	return len(object.stations)
+}
+
+//Station - looks up a station by its ID
+func (object *Catalog) Station(stationID string) (Station, bool) {
+	object.lock.RLock()
+	defer object.lock.RUnlock()
+	for _, station := range object.stations {
+		if station.ID == stationID {
+			return station, true
+		}
+	}
+	return Station{}, false
+}
+
+//Refresh - pulls every station list from Noaa and replaces the catalog.  The old catalog is kept if any of the lists fail so it is never half built
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	Anything that the lister returns
+func (object *Catalog) Refresh(ctx context.Context, lister Lister) error {
+	//Precondition
+	if ctx == nil || lister == nil {
+		return customerrors.PreconditionError{Msg: "Empty Mandatory Values"}
+	}
+	byID := make(map[string]*Station)
+	order := make([]string, 0)
+	for _, stationType := range stationTypes {
+		stations, err := lister.RetrieveStations(ctx, stationType.stationType)
+		if err != nil {
+			return err
+		}
+		for _, metadata := range stations {
+			station, ok := byID[metadata.ID]
+			if !ok {
+				station = &Station{ID: metadata.ID, Name: metadata.Name, State: metadata.State, Latitude: metadata.Latitude, Longitude: metadata.Longitude}
+				byID[metadata.ID] = station
+				order = append(order, metadata.ID)
+			}
+			station.Products = append(station.Products, stationType.products(&metadata)...)
+		}
+	}
+	stations := make([]Station, 0, len(order))
+	for _, stationID := range order {
+		station := byID[stationID]
+		station.Products = uniqueProducts(station.Products)
+		stations = append(stations, *station)
+	}
+	object.replace(stations, object.now())
+	return nil
+}
+
+//Run - refreshes the catalog right away and then on the interval until the context is done.  Anything less than 1 for the interval uses the DefaultRefreshInterval.  Blocks so call it in its own go routine
+func (object *Catalog) Run(ctx context.Context, lister Lister, interval time.Duration) {
+	if interval < 1 {
+		interval = DefaultRefreshInterval
+	}
+	//The snapshot is a subset so don't wait a whole interval for the rest of the stations
+	object.Refresh(ctx, lister)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			object.Refresh(ctx, lister)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+//Nearest - the stations closest to the point (closest first) that have every one of the products.  Anything less than 1 for the limit uses the DefaultLimit
+//
+//	Errors:
+//	InvalidData - the point isn't a real latitude and longitude
+func (object *Catalog) Nearest(latitude, longitude float64, limit int, dataProducts ...noaaclient.DataProduct) ([]Result, error) {
+	//Precondition
+	if !validLatitude(latitude) || !validLongitude(longitude) {
+		return nil, customerrors.InvalidData{Msg: "Not a valid latitude and longitude"}
+	}
+	if limit < 1 {
+		limit = DefaultLimit
+	}
+	results := object.filter(func(station *Station) (Result, bool) {
+		if !station.Has(dataProducts...) {
+			return Result{}, false
+		}
+		return Result{Station: *station, DistanceInKm: distanceInKm(latitude, longitude, station.Latitude, station.Longitude)}, true
+	})
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].DistanceInKm < results[j].DistanceInKm
+	})
+	return truncate(results, limit), nil
+}
+
+//WithinBox - the stations inside the box (sorted by ID) that have every one of the products.  A minimum longitude bigger than the maximum is a box that crosses the antimeridian
+//
+//	Errors:
+//	InvalidData - the corners aren't real latitudes and longitudes or the minimum latitude is above the maximum
+func (object *Catalog) WithinBox(minLatitude, minLongitude, maxLatitude, maxLongitude float64, dataProducts ...noaaclient.DataProduct) ([]Result, error) {
+	//Precondition
+	if !validLatitude(minLatitude) || !validLatitude(maxLatitude) || !validLongitude(minLongitude) || !validLongitude(maxLongitude) || minLatitude > maxLatitude {
+		return nil, customerrors.InvalidData{Msg: "Not a valid bounding box"}
+	}
+	results := object.filter(func(station *Station) (Result, bool) {
+		if station.Latitude < minLatitude || station.Latitude > maxLatitude || !station.Has(dataProducts...) {
+			return Result{}, false
+		}
+		if minLongitude <= maxLongitude {
+			if station.Longitude < minLongitude || station.Longitude > maxLongitude {
+				return Result{}, false
+			}
+		} else if station.Longitude < minLongitude && station.Longitude > maxLongitude {
+			//Crosses the antimeridian so it is outside only when it is between the two
+			return Result{}, false
+		}
+		return Result{Station: *station}, true
+	})
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Station.ID < results[j].Station.ID
+	})
+	return results, nil
+}
+
+//Search - the stations whose name (or ID) is close to the query (best match first) that have every one of the products.  Typos are OK (e.g. providnce).  Anything less than 1 for the limit uses the DefaultLimit
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+func (object *Catalog) Search(query string, limit int, dataProducts ...noaaclient.DataProduct) ([]Result, error) {
+	queryTokens := tokenize(query)
+	//Precondition
+	if len(queryTokens) == 0 {
+		return nil, customerrors.PreconditionError{Msg: "Empty Mandatory Values"}
+	}
+	if limit < 1 {
+		limit = DefaultLimit
+	}
+	results := object.filter(func(station *Station) (Result, bool) {
+		if !station.Has(dataProducts...) {
+			return Result{}, false
+		}
+		score := matchScore(queryTokens, tokenize(station.Name))
+		if strings.EqualFold(strings.TrimSpace(query), station.ID) {
+			score = 1
+		}
+		if score < minimumScore {
+			return Result{}, false
+		}
+		return Result{Station: *station, Score: score}, true
+	})
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		//Shorter names match more of the query
+		return len(results[i].Station.Name) < len(results[j].Station.Name)
+	})
+	return truncate(results, limit), nil
+}
+
+///INTERNAL FUNCTIONS
+
+//earthRadiusInKm - mean radius of the earth
+const earthRadiusInKm = 6371.0088
+
+//minimumScore - how close a name has to be to the query to be a match
+const minimumScore = 0.75
+
+//snapshotFile - the format of the embedded snapshot.  Products are Noaa's names so the file is readable
+type snapshotFile struct {
+	Refreshed time.Time         `json:"refreshed"`
+	Stations  []snapshotStation `json:"stations"`
+}
+
+type snapshotStation struct {
+	Station
+	Products []string `json:"products"`
+}
+
+//stationType - a station list from Noaa and the products that being on the list means a station has
+type stationType struct {
+	stationType noaaclient.StationType
+	products    func(station *noaaclient.StationMetadata) []noaaclient.DataProduct
+}
+
+//only - a station list that always means the same products
+func only(dataProducts ...noaaclient.DataProduct) func(station *noaaclient.StationMetadata) []noaaclient.DataProduct {
+	return func(station *noaaclient.StationMetadata) []noaaclient.DataProduct {
+		return dataProducts
+	}
+}
+
+//stationTypes - the lists that the catalog is built from.  The water level list is first as it has the most stations
+var stationTypes = []stationType{
+	{noaaclient.WaterLevelStations, func(station *noaaclient.StationMetadata) []noaaclient.DataProduct {
+		dataProducts := []noaaclient.DataProduct{noaaclient.WaterLevel, noaaclient.HourlyHeight, noaaclient.MonthlyMean}
+		//High and low tides only happen on tidal stations and daily means are only for the great lakes
+		if station.Tidal {
+			dataProducts = append(dataProducts, noaaclient.HighLow)
+		}
+		if station.GreatLakes {
+			dataProducts = append(dataProducts, noaaclient.DailyMean)
+		}
+		return dataProducts
+	}},
+	{noaaclient.OneMinuteWaterLevelStations, only(noaaclient.OneMinuteWaterLevel)},
+	{noaaclient.TidePredictionStations, only(noaaclient.Preditions)},
+	{noaaclient.DatumStations, only(noaaclient.Datums)},
+	{noaaclient.MetStations, only(noaaclient.AirTemperature, noaaclient.Wind, noaaclient.AirPressure)},
+	{noaaclient.WaterTemperatureStations, only(noaaclient.WaterTemperature)},
+	{noaaclient.ConductivityStations, only(noaaclient.Conductivity, noaaclient.Salinity)},
+	{noaaclient.VisibilityStations, only(noaaclient.Visibility)},
+	{noaaclient.AirGapStations, only(noaaclient.AirGap)},
+	{noaaclient.CurrentStations, only(noaaclient.Currents)},
+	{noaaclient.CurrentPredictionStations, only(noaaclient.CurrentsPredictions)},
+}
+
+//replace - swaps in the new stations
+func (object *Catalog) replace(stations []Station, refreshed time.Time) {
+	object.lock.Lock()
+	object.stations = stations
+	object.refreshed = refreshed
+	object.lock.Unlock()
+}
+
+//filter - the results for the stations that the match function keeps
+func (object *Catalog) filter(match func(station *Station) (Result, bool)) []Result {
+	object.lock.RLock()
+	defer object.lock.RUnlock()
+	results := make([]Result, 0)
+	for index := range object.stations {
+		if result, ok := match(&object.stations[index]); ok {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+//truncate - the first limit results
+func truncate(results []Result, limit int) []Result {
+	if len(results) > limit {
+		return results[:limit]
+	}
+	return results
+}
+
+//uniqueProducts - the products in order without any repeats
+func uniqueProducts(dataProducts []noaaclient.DataProduct) []noaaclient.DataProduct {
+	sort.Slice(dataProducts, func(i, j int) bool {
+		return dataProducts[i] < dataProducts[j]
+	})
+	unique := dataProducts[:0]
+	for index, dataProduct := range dataProducts {
+		if index == 0 || dataProduct != dataProducts[index-1] {
+			unique = append(unique, dataProduct)
+		}
+	}
+	return unique
+}
+
+func validLatitude(latitude float64) bool {
+	return latitude >= -90 && latitude <= 90
+}
+
+func validLongitude(longitude float64) bool {
+	return longitude >= -180 && longitude <= 180
+}
+
+//distanceInKm - great circle distance between two points with the haversine formula
+func distanceInKm(fromLatitude, fromLongitude, toLatitude, toLongitude float64) float64 {
+	radians := math.Pi / 180
+	deltaLatitude := (toLatitude - fromLatitude) * radians
+	deltaLongitude := (toLongitude - fromLongitude) * radians
+	a := math.Sin(deltaLatitude/2)*math.Sin(deltaLatitude/2) + math.Cos(fromLatitude*radians)*math.Cos(toLatitude*radians)*math.Sin(deltaLongitude/2)*math.Sin(deltaLongitude/2)
+	return 2 * earthRadiusInKm * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+//tokenize - lower case words in the text.  Punctuation (e.g. the commas in "Hilo, Hilo Bay") is dropped
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9')
+	})
+}
+
+//matchScore - how well the name matches the query from 0 to 1.  Every query word is scored against its best name word and the scores are averaged
+func matchScore(queryTokens, nameTokens []string) float64 {
+	if len(nameTokens) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, queryToken := range queryTokens {
+		best := 0.0
+		for _, nameToken := range nameTokens {
+			best = math.Max(best, tokenScore(queryToken, nameToken))
+		}
+		total += best
+	}
+	return total / float64(len(queryTokens))
+}
+
+//tokenScore - exact words score 1, then prefixes (e.g. prov), then words inside other words.  Everything else scores on how many edits apart they are
+func tokenScore(queryToken, nameToken string) float64 {
+	switch {
+	case queryToken == nameToken:
+		return 1
+	case strings.HasPrefix(nameToken, queryToken):
+		return 0.9
+	case strings.Contains(nameToken, queryToken):
+		return 0.8
+	}
+	longest := math.Max(float64(len(queryToken)), float64(len(nameToken)))
+	return 1 - float64(levenshtein(queryToken, nameToken))/longest
+}
+
+//levenshtein - the number of single character edits to turn one word into the other
+func levenshtein(from, to string) int {
+	previous := make([]int, len(to)+1)
+	current := make([]int, len(to)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(from); i++ {
+		current[0] = i
+		for j := 1; j <= len(to); j++ {
+			cost := 1
+			if from[i-1] == to[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(to)]
+}
+
+func minInt(values ...int) int {
+	smallest := values[0]
+	for _, value := range values[1:] {
+		if value < smallest {
+			smallest = value
+		}
+	}
+	return smallest
+}
@@ -0,0 +1,255 @@
+package stationcatalog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
+	noaaclient "github.com/mornindew/sledgeconf2021/pkg/noaa-client"
+)
+
+//fakeLister - sends back the stations for each type and counts the calls
+type fakeLister struct {
+	stations map[noaaclient.StationType][]noaaclient.StationMetadata
+	err      error
+	calls    int
+}
+
+func (object *fakeLister) RetrieveStations(ctx context.Context, stationType noaaclient.StationType) ([]noaaclient.StationMetadata, error) {
+	object.calls++
+	if object.err != nil {
+		return nil, object.err
+	}
+	return object.stations[stationType], nil
+}
+
+//newTestCatalog - the embedded catalog
+func newTestCatalog(t *testing.T) *Catalog {
+	catalog, err := New()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return catalog
+}
+
+//TestNew - the embedded snapshot loads with the products
+func TestNew(t *testing.T) {
+	catalog := newTestCatalog(t)
+	if catalog.Len() < 50 || catalog.Refreshed().IsZero() {
+		t.Fatalf("Expected the embedded snapshot but got %d stations refreshed %s", catalog.Len(), catalog.Refreshed())
+	}
+	providence, ok := catalog.Station("8454000")
+	if !ok || providence.Name != "Providence" || providence.State != "RI" || !providence.Has(noaaclient.WaterLevel, noaaclient.Conductivity) || providence.Has(noaaclient.DailyMean) {
+		t.Errorf("Unexpected station %+v", providence)
+	}
+	if _, ok := catalog.Station("1234567"); ok {
+		t.Error("Expected the station not to be in the catalog")
+	}
+
+	//What is saved loads back the same
+	var buffer bytes.Buffer
+	if err := catalog.Save(&buffer); err != nil {
+		t.Fatal(err.Error())
+	}
+	loaded := &Catalog{now: time.Now}
+	if err := loaded.Load(&buffer); err != nil {
+		t.Fatal(err.Error())
+	}
+	reloaded, _ := loaded.Station("8454000")
+	if loaded.Len() != catalog.Len() || !loaded.Refreshed().Equal(catalog.Refreshed()) || len(reloaded.Products) != len(providence.Products) {
+		t.Errorf("Expected the same catalog back but got %d stations and %+v", loaded.Len(), reloaded)
+	}
+
+	if err := loaded.Load(bytes.NewReader([]byte(`{"stations":[{"id":"1","products":["tides"]}]}`))); !isBadFormat(err) {
+		t.Errorf("Expected a bad format but got %v", err)
+	}
+}
+
+//TestNearest - closest first with the product filter
+func TestNearest(t *testing.T) {
+	catalog := newTestCatalog(t)
+
+	//Downtown Providence
+	results, err := catalog.Nearest(41.824, -71.4128, 3)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(results) != 3 || results[0].Station.ID != "8454000" || results[0].DistanceInKm > 3 || results[1].Station.ID != "8453662" {
+		t.Fatalf("Unexpected nearest stations %+v", results)
+	}
+	for index := 1; index < len(results); index++ {
+		if results[index].DistanceInKm < results[index-1].DistanceInKm {
+			t.Errorf("Expected closest first but got %+v", results)
+		}
+	}
+
+	//Visibility only stations don't have water levels
+	results, err = catalog.Nearest(41.824, -71.4128, 0, noaaclient.WaterLevel, noaaclient.Wind)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(results) != DefaultLimit || results[1].Station.ID != "8452944" {
+		t.Errorf("Unexpected nearest stations %+v", results)
+	}
+
+	//Newport to Boston is about 99km
+	results, _ = catalog.Nearest(41.504333, -71.326139, 1, noaaclient.Datums)
+	if results[0].Station.ID != "8452660" || results[0].DistanceInKm != 0 {
+		t.Errorf("Unexpected nearest stations %+v", results)
+	}
+	if distance := distanceInKm(41.504333, -71.326139, 42.353056, -71.050278); distance < 95 || distance > 100 {
+		t.Errorf("Expected about 97km but got %f", distance)
+	}
+
+	if _, err := catalog.Nearest(91, 0, 1); !isInvalidData(err) {
+		t.Errorf("Expected invalid data but got %v", err)
+	}
+}
+
+//TestWithinBox - the stations inside the box including one across the antimeridian
+func TestWithinBox(t *testing.T) {
+	catalog := newTestCatalog(t)
+
+	//Narragansett Bay
+	results, err := catalog.WithinBox(41.4, -71.5, 41.9, -71.2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(results) != 7 || results[0].Station.ID != "8452314" {
+		t.Errorf("Unexpected stations %+v", results)
+	}
+	results, _ = catalog.WithinBox(41.4, -71.5, 41.9, -71.2, noaaclient.Visibility)
+	if len(results) != 2 {
+		t.Errorf("Expected the visibility stations but got %+v", results)
+	}
+
+	//Adak and Guam are on either side of the antimeridian
+	results, err = catalog.WithinBox(10, 140, 55, -170)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(results) != 2 || results[0].Station.ID != "1630000" || results[1].Station.ID != "9461380" {
+		t.Errorf("Unexpected stations %+v", results)
+	}
+
+	if _, err := catalog.WithinBox(42, -71, 41, -70); !isInvalidData(err) {
+		t.Errorf("Expected invalid data but got %v", err)
+	}
+}
+
+//TestSearch - typos, partial names and IDs
+func TestSearch(t *testing.T) {
+	catalog := newTestCatalog(t)
+
+	for query, stationID := range map[string]string{"providnce": "8454000", "Providence": "8454000", "quonset": "8454049", "fall river": "8447386", "8452660": "8452660", "galvston pier": "8771450", "prov": "8454000"} {
+		results, err := catalog.Search(query, 3)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if len(results) == 0 || results[0].Station.ID != stationID {
+			t.Errorf("Expected %s for %s but got %+v", stationID, query, results)
+		}
+	}
+
+	//Only the Providence station with water levels
+	results, _ := catalog.Search("providence", 0, noaaclient.WaterLevel)
+	if len(results) != 1 || results[0].Score != 1 {
+		t.Errorf("Unexpected stations %+v", results)
+	}
+	results, _ = catalog.Search("zzzzzz", 0)
+	if len(results) != 0 {
+		t.Errorf("Expected nothing but got %+v", results)
+	}
+	if _, err := catalog.Search(" , ", 0); err == nil {
+		t.Error("Expected a precondition error")
+	}
+}
+
+//TestRefresh - the lists are merged into stations and a failure keeps the old catalog
+func TestRefresh(t *testing.T) {
+	catalog := newTestCatalog(t)
+	refreshed := time.Date(2021, 9, 1, 0, 0, 0, 0, time.UTC)
+	catalog.now = func() time.Time { return refreshed }
+	providence := noaaclient.StationMetadata{ID: "8454000", Name: "Providence", State: "RI", Latitude: 41.807167, Longitude: -71.4012, Tidal: true}
+	buffalo := noaaclient.StationMetadata{ID: "9063020", Name: "Buffalo", State: "NY", Latitude: 42.877389, Longitude: -78.890528, GreatLakes: true}
+	lister := &fakeLister{stations: map[noaaclient.StationType][]noaaclient.StationMetadata{
+		noaaclient.WaterLevelStations:     {providence, buffalo},
+		noaaclient.MetStations:            {providence},
+		noaaclient.ConductivityStations:   {providence},
+		noaaclient.TidePredictionStations: {providence},
+		noaaclient.CurrentStations:        {{ID: "cb0102", Name: "Cape Henry LB 2CH", Latitude: 36.9594, Longitude: -76.0128}},
+	}}
+
+	lister.err = customerrors.ServiceUnavailableError{Msg: "Down"}
+	if err := catalog.Refresh(context.Background(), lister); err == nil || catalog.Len() < 50 {
+		t.Fatalf("Expected the old catalog to be kept but got %d stations and %v", catalog.Len(), err)
+	}
+
+	lister.err = nil
+	lister.calls = 0
+	if err := catalog.Refresh(context.Background(), lister); err != nil {
+		t.Fatal(err.Error())
+	}
+	if lister.calls != len(stationTypes) || catalog.Len() != 3 || !catalog.Refreshed().Equal(refreshed) {
+		t.Fatalf("Expected 3 stations from %d lists but got %d from %d", len(stationTypes), catalog.Len(), lister.calls)
+	}
+	station, _ := catalog.Station("8454000")
+	expected := []noaaclient.DataProduct{noaaclient.WaterLevel, noaaclient.AirTemperature, noaaclient.Wind, noaaclient.AirPressure, noaaclient.Conductivity, noaaclient.Salinity, noaaclient.HourlyHeight, noaaclient.HighLow, noaaclient.MonthlyMean, noaaclient.Preditions}
+	if len(station.Products) != len(expected) {
+		t.Fatalf("Expected %v but got %v", expected, station.Products)
+	}
+	for index := range expected {
+		if station.Products[index] != expected[index] {
+			t.Errorf("Expected %v but got %v", expected, station.Products)
+		}
+	}
+	station, _ = catalog.Station("9063020")
+	if !station.Has(noaaclient.DailyMean) || station.Has(noaaclient.HighLow) {
+		t.Errorf("Expected the great lakes products but got %v", station.Products)
+	}
+	results, _ := catalog.Nearest(37, -76, 1, noaaclient.Currents)
+	if len(results) != 1 || results[0].Station.ID != "cb0102" {
+		t.Errorf("Unexpected stations %+v", results)
+	}
+}
+
+//TestRun - the catalog is refreshed as soon as it starts instead of waiting for the interval
+func TestRun(t *testing.T) {
+	catalog := newTestCatalog(t)
+	lister := &fakeLister{stations: map[noaaclient.StationType][]noaaclient.StationMetadata{
+		noaaclient.WaterLevelStations: {{ID: "8454000", Name: "Providence", State: "RI", Latitude: 41.807167, Longitude: -71.4012, Tidal: true}},
+	}}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		catalog.Run(ctx, lister, time.Hour)
+		close(done)
+	}()
+	deadline := time.Now().Add(2 * time.Second)
+	for catalog.Len() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the catalog to be refreshed but it has %d stations", catalog.Len())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("Expected run to stop")
+	}
+}
+
+//isBadFormat - whether the error is a BadFormat
+func isBadFormat(err error) bool {
+	_, ok := err.(customerrors.BadFormat)
+	return ok
+}
+
+//isInvalidData - whether the error is an InvalidData
+func isInvalidData(err error) bool {
+	_, ok := err.(customerrors.InvalidData)
+	return ok
+}
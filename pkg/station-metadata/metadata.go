@@ -0,0 +1,257 @@
+package stationmetadata
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
+	noaaclient "github.com/mornindew/sledgeconf2021/pkg/noaa-client"
+)
+
+//DefaultRefreshInterval - how often the metadata for a station is pulled again.  Stations don't get new sensors very often
+const DefaultRefreshInterval = 24 * time.Hour
+
+//DefaultFailureInterval - how long a failed pull is remembered before asking the metadata API again.  Keeps every request from going to it while it is down
+const DefaultFailureInterval = time.Minute
+
+//Fetcher - what the metadata comes from (e.g. a NoaaClient)
+type Fetcher interface {
+	RetrieveStation(ctx context.Context, stationID string) (*noaaclient.StationMetadata, error)
+	RetrieveStationProducts(ctx context.Context, stationID string) ([]noaaclient.StationProduct, error)
+	RetrieveStationSensors(ctx context.Context, stationID string) ([]noaaclient.StationSensor, error)
+}
+
+//Capabilities - the data products that a station has according to its metadata
+type Capabilities struct {
+	StationID string
+	Products  map[noaaclient.DataProduct]bool
+	//Refreshed - when the metadata was pulled
+	Refreshed time.Time
+}
+
+//Supports - whether the station has the product
+func (object *Capabilities) Supports(dataProduct noaaclient.DataProduct) bool {
+	return object.Products[dataProduct]
+}
+
+//NewCapabilities - works out the products from the station, the product pages Noaa has for it and its sensors
+//
+//The product pages say which groups of products there are (water levels, predictions, met, etc.).  The sensors say which of the met products there are
+func NewCapabilities(station *noaaclient.StationMetadata, products []noaaclient.StationProduct, sensors []noaaclient.StationSensor) *Capabilities {
+	capabilities := &Capabilities{StationID: station.ID, Products: make(map[noaaclient.DataProduct]bool)}
+	add := func(dataProducts ...noaaclient.DataProduct) {
+		for _, dataProduct := range dataProducts {
+			capabilities.Products[dataProduct] = true
+		}
+	}
+	for _, product := range products {
+		name := strings.ToLower(product.Name)
+		switch {
+		case strings.Contains(name, "water level"):
+			add(noaaclient.WaterLevel, noaaclient.HourlyHeight, noaaclient.MonthlyMean, noaaclient.OneMinuteWaterLevel)
+			//High and low tides only happen on tidal stations and daily means are only for the great lakes
+			if station.Tidal {
+				add(noaaclient.HighLow)
+			}
+			if station.GreatLakes {
+				add(noaaclient.DailyMean)
+			}
+		case strings.Contains(name, "tide prediction"):
+			add(noaaclient.Preditions)
+		case strings.Contains(name, "datum"):
+			add(noaaclient.Datums)
+		case strings.Contains(name, "current prediction"):
+			add(noaaclient.CurrentsPredictions)
+		case strings.Contains(name, "current"):
+			add(noaaclient.Currents)
+		}
+	}
+	for _, sensor := range sensors {
+		for keyword, dataProducts := range sensorProducts {
+			if strings.Contains(strings.ToLower(sensor.Name), keyword) {
+				add(dataProducts...)
+			}
+		}
+	}
+	return capabilities
+}
+
+//Cache - keeps the capabilities of each station so the metadata is only pulled once per refresh interval.  Safe to use from many go routines at once
+//
+//Everyone asking for a station while it is being pulled shares the pull and a failed pull is remembered for the failure interval
+type Cache struct {
+	fetcher         Fetcher
+	interval        time.Duration
+	failureInterval time.Duration
+	lock            sync.Mutex
+	stations        map[string]*capabilitiesEntry
+	//now - swapped out in the tests
+	now func() time.Time
+}
+
+//NewCache - Constructor.  Anything less than 1 for the interval uses the DefaultRefreshInterval.  Failures are remembered for the DefaultFailureInterval
+func NewCache(fetcher Fetcher, interval time.Duration) *Cache {
+	if interval < 1 {
+		interval = DefaultRefreshInterval
+	}
+	return &Cache{fetcher: fetcher, interval: interval, failureInterval: DefaultFailureInterval, stations: make(map[string]*capabilitiesEntry), now: time.Now}
+}
+
+//Capabilities - the station's capabilities (shared so don't change them).  They are pulled if they aren't cached or are older than the refresh interval.  When the pull fails the old ones are used if there are any
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	Anything that the fetcher returns (remembered for the failure interval)
+func (object *Cache) Capabilities(ctx context.Context, stationID string) (*Capabilities, error) {
+	//Precondition
+	if ctx == nil || stationID == "" {
+		return nil, customerrors.PreconditionError{Msg: "Empty Mandatory Values"}
+	}
+	return object.pull(ctx, stationID, false)
+}
+
+//Refresh - pulls the metadata again for every station in the cache.  Stations that fail keep what they had
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	The first error that the fetcher returns
+func (object *Cache) Refresh(ctx context.Context) error {
+	//Precondition
+	if ctx == nil {
+		return customerrors.PreconditionError{Msg: "Empty Mandatory Values"}
+	}
+	object.lock.Lock()
+	stationIDs := make([]string, 0, len(object.stations))
+	for stationID := range object.stations {
+		stationIDs = append(stationIDs, stationID)
+	}
+	object.lock.Unlock()
+	var firstErr error
+	for _, stationID := range stationIDs {
+		if _, err := object.pull(ctx, stationID, true); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	return firstErr
+}
+
+//Run - refreshes every station on the interval until the context is done so requests don't wait on the metadata.  Blocks so call it in its own go routine
+func (object *Cache) Run(ctx context.Context) {
+	ticker := time.NewTicker(object.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			object.Refresh(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+///INTERNAL FUNCTIONS
+
+//sensorProducts - the products that a sensor's name means the station has
+var sensorProducts = map[string][]noaaclient.DataProduct{
+	"wind":              {noaaclient.Wind},
+	"air temperature":   {noaaclient.AirTemperature},
+	"water temperature": {noaaclient.WaterTemperature},
+	"barometric":        {noaaclient.AirPressure},
+	"conductivity":      {noaaclient.Conductivity, noaaclient.Salinity},
+	"humidity":          {noaaclient.Humidity},
+	"visibility":        {noaaclient.Visibility},
+	"air gap":           {noaaclient.AirGap},
+}
+
+//capabilitiesEntry - a station's capabilities.  Done is closed once the pull has finished so everyone asking at the same time shares it.  A failed pull keeps the old capabilities (if any) along with the error
+type capabilitiesEntry struct {
+	done         chan struct{}
+	capabilities *Capabilities
+	err          error
+	//pulled - when the last pull finished.  Zero when it shouldn't be remembered (e.g. the caller gave up)
+	pulled time.Time
+}
+
+//result - what the callers get.  The old capabilities win over the error
+func (object *capabilitiesEntry) result() (*Capabilities, error) {
+	if object.capabilities != nil {
+		return object.capabilities, nil
+	}
+	return nil, object.err
+}
+
+//pull - the station's capabilities from the cache or the metadata API.  Unless forced the cached ones are used while they are fresh and a failure is used until the failure interval is up
+func (object *Cache) pull(ctx context.Context, stationID string, force bool) (*Capabilities, error) {
+	object.lock.Lock()
+	entry, ok := object.stations[stationID]
+	if ok {
+		select {
+		case <-entry.done:
+			if !force && entry.err == nil && object.now().Sub(entry.capabilities.Refreshed) < object.interval {
+				object.lock.Unlock()
+				return entry.capabilities, nil
+			}
+			if !force && entry.err != nil && !entry.pulled.IsZero() && object.now().Sub(entry.pulled) < object.failureInterval {
+				object.lock.Unlock()
+				return entry.result()
+			}
+		default:
+			//Someone else is pulling it
+			object.lock.Unlock()
+			return object.wait(ctx, entry)
+		}
+	}
+	previous := entry
+	entry = &capabilitiesEntry{done: make(chan struct{})}
+	object.stations[stationID] = entry
+	object.lock.Unlock()
+
+	entry.capabilities, entry.err = object.refresh(ctx, stationID)
+	entry.pulled = object.now()
+	if entry.err != nil {
+		//Keep the old ones until the pull works again
+		if previous != nil {
+			entry.capabilities = previous.capabilities
+		}
+		//The metadata API didn't fail if the caller gave up so the next caller tries again
+		if ctx.Err() != nil {
+			entry.pulled = time.Time{}
+		}
+	}
+	close(entry.done)
+	return entry.result()
+}
+
+//wait - waits on a pull that someone else started
+func (object *Cache) wait(ctx context.Context, entry *capabilitiesEntry) (*Capabilities, error) {
+	select {
+	case <-entry.done:
+		return entry.result()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+//refresh - pulls the station's metadata and works out the capabilities
+func (object *Cache) refresh(ctx context.Context, stationID string) (*Capabilities, error) {
+	station, err := object.fetcher.RetrieveStation(ctx, stationID)
+	if err != nil {
+		return nil, err
+	}
+	products, err := object.fetcher.RetrieveStationProducts(ctx, stationID)
+	if err != nil {
+		return nil, err
+	}
+	sensors, err := object.fetcher.RetrieveStationSensors(ctx, stationID)
+	if err != nil {
+		return nil, err
+	}
+	capabilities := NewCapabilities(station, products, sensors)
+	capabilities.Refreshed = object.now()
+	return capabilities, nil
+}
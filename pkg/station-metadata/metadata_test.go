@@ -0,0 +1,218 @@
+package stationmetadata
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	noaaclient "github.com/mornindew/sledgeconf2021/pkg/noaa-client"
+)
+
+//fakeFetcher - sends back the same metadata for every station and counts the stations it was asked about.  Fails when err is set
+type fakeFetcher struct {
+	lock    sync.Mutex
+	calls   int
+	err     error
+	sensors []noaaclient.StationSensor
+}
+
+func (object *fakeFetcher) RetrieveStation(ctx context.Context, stationID string) (*noaaclient.StationMetadata, error) {
+	object.lock.Lock()
+	defer object.lock.Unlock()
+	object.calls++
+	if object.err != nil {
+		return nil, object.err
+	}
+	return &noaaclient.StationMetadata{ID: stationID, Tidal: true}, nil
+}
+
+func (object *fakeFetcher) RetrieveStationProducts(ctx context.Context, stationID string) ([]noaaclient.StationProduct, error) {
+	return []noaaclient.StationProduct{{Name: "Tides/Water Levels"}, {Name: "Meteorological Observations"}}, nil
+}
+
+func (object *fakeFetcher) RetrieveStationSensors(ctx context.Context, stationID string) ([]noaaclient.StationSensor, error) {
+	object.lock.Lock()
+	defer object.lock.Unlock()
+	return object.sensors, nil
+}
+
+//TestNewCapabilities - the product pages and sensors decide the products
+func TestNewCapabilities(t *testing.T) {
+	products := []noaaclient.StationProduct{{Name: "Tides/Water Levels"}, {Name: "Meteorological Observations"}, {Name: "Datums"}, {Name: "Tide Predictions"}, {Name: "Sea Level Trends"}}
+	sensors := []noaaclient.StationSensor{{Name: "Aquatrak"}, {Name: "Wind"}, {Name: "Air Temperature"}, {Name: "Conductivity"}}
+	capabilities := NewCapabilities(&noaaclient.StationMetadata{ID: "8454000", Tidal: true}, products, sensors)
+	expected := []noaaclient.DataProduct{noaaclient.WaterLevel, noaaclient.HourlyHeight, noaaclient.HighLow, noaaclient.MonthlyMean, noaaclient.OneMinuteWaterLevel, noaaclient.Datums, noaaclient.Preditions, noaaclient.Wind, noaaclient.AirTemperature, noaaclient.Conductivity, noaaclient.Salinity}
+	for _, dataProduct := range expected {
+		if !capabilities.Supports(dataProduct) {
+			t.Errorf("Expected the station to have %s", dataProduct)
+		}
+	}
+	if len(capabilities.Products) != len(expected) {
+		t.Errorf("Expected %d products but got %v", len(expected), capabilities.Products)
+	}
+
+	//The great lakes have daily means but no tides.  Current stations only have currents
+	lakes := NewCapabilities(&noaaclient.StationMetadata{ID: "9063020", GreatLakes: true}, []noaaclient.StationProduct{{Name: "Water Levels"}}, nil)
+	if !lakes.Supports(noaaclient.DailyMean) || lakes.Supports(noaaclient.HighLow) || lakes.Supports(noaaclient.Wind) {
+		t.Errorf("Unexpected great lakes products %v", lakes.Products)
+	}
+	currents := NewCapabilities(&noaaclient.StationMetadata{ID: "cb1101"}, []noaaclient.StationProduct{{Name: "Currents"}, {Name: "Current Predictions"}}, nil)
+	if len(currents.Products) != 2 || !currents.Supports(noaaclient.Currents) || !currents.Supports(noaaclient.CurrentsPredictions) {
+		t.Errorf("Unexpected current products %v", currents.Products)
+	}
+}
+
+//TestCacheRefresh - the metadata is only pulled again once it is older than the interval.  A failed pull keeps what was there
+func TestCacheRefresh(t *testing.T) {
+	fetcher := &fakeFetcher{sensors: []noaaclient.StationSensor{{Name: "Wind"}}}
+	cache := NewCache(fetcher, time.Hour)
+	now := time.Date(2021, 8, 20, 12, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	for index := 0; index < 2; index++ {
+		capabilities, err := cache.Capabilities(context.Background(), "8454000")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if !capabilities.Supports(noaaclient.Wind) || capabilities.Supports(noaaclient.AirGap) || !capabilities.Refreshed.Equal(now) {
+			t.Errorf("Unexpected capabilities %+v", capabilities)
+		}
+	}
+	if fetcher.calls != 1 {
+		t.Errorf("Expected the second call to be cached but got %d calls", fetcher.calls)
+	}
+
+	//Old metadata is kept when Noaa is down
+	now = now.Add(time.Hour)
+	fetcher.err = errors.New("Noaa is having a bad day")
+	capabilities, err := cache.Capabilities(context.Background(), "8454000")
+	if err != nil || !capabilities.Supports(noaaclient.Wind) || fetcher.calls != 2 {
+		t.Errorf("Expected the old capabilities after %d calls but got %v", fetcher.calls, err)
+	}
+	if _, err := cache.Capabilities(context.Background(), "8452944"); err == nil {
+		t.Error("Expected the error without anything cached")
+	}
+
+	//A refresh picks up the new sensor for every station that has been asked for (even the ones that failed)
+	fetcher.err = nil
+	fetcher.sensors = append(fetcher.sensors, noaaclient.StationSensor{Name: "Air Gap"})
+	if err := cache.Refresh(context.Background()); err != nil {
+		t.Fatal(err.Error())
+	}
+	capabilities, _ = cache.Capabilities(context.Background(), "8454000")
+	if !capabilities.Supports(noaaclient.AirGap) || fetcher.calls != 5 {
+		t.Errorf("Expected the air gap after 5 calls but got %d calls and %v", fetcher.calls, capabilities.Products)
+	}
+	if _, err := cache.Capabilities(context.Background(), "8452944"); err != nil || fetcher.calls != 5 {
+		t.Errorf("Expected the failed station to be refreshed but got %d calls and %v", fetcher.calls, err)
+	}
+}
+
+//TestCacheFailure - a failed pull is remembered for the failure interval so the metadata API isn't asked on every request while it is down
+func TestCacheFailure(t *testing.T) {
+	fetcher := &fakeFetcher{err: errors.New("Noaa is having a bad day")}
+	cache := NewCache(fetcher, time.Hour)
+	now := time.Date(2021, 8, 20, 12, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	for index := 0; index < 3; index++ {
+		if _, err := cache.Capabilities(context.Background(), "8454000"); err != fetcher.err {
+			t.Errorf("Expected the failure but got %v", err)
+		}
+	}
+	if fetcher.calls != 1 {
+		t.Errorf("Expected the failure to be remembered but got %d calls", fetcher.calls)
+	}
+	now = now.Add(DefaultFailureInterval)
+	fetcher.err = nil
+	if _, err := cache.Capabilities(context.Background(), "8454000"); err != nil || fetcher.calls != 2 {
+		t.Errorf("Expected another pull once the failure interval is up but got %d calls and %v", fetcher.calls, err)
+	}
+
+	//Giving up isn't a failure of the metadata API so the next caller pulls it
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	fetcher.err = context.Canceled
+	if _, err := cache.Capabilities(ctx, "8452944"); err != context.Canceled {
+		t.Errorf("Expected the context's error but got %v", err)
+	}
+	fetcher.err = nil
+	if _, err := cache.Capabilities(context.Background(), "8452944"); err != nil || fetcher.calls != 4 {
+		t.Errorf("Expected the station to be pulled again but got %d calls and %v", fetcher.calls, err)
+	}
+}
+
+//TestCacheSharedPull - everyone asking for a station while it is being pulled shares the pull
+func TestCacheSharedPull(t *testing.T) {
+	release := make(chan struct{})
+	fetcher := &blockingFetcher{fakeFetcher: &fakeFetcher{}, release: release}
+	cache := NewCache(fetcher, time.Hour)
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for index := range errs {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			_, errs[index] = cache.Capabilities(context.Background(), "8454000")
+		}(index)
+	}
+	//Let everyone get in line before the pull finishes
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Error(err.Error())
+		}
+	}
+	if fetcher.calls != 1 {
+		t.Errorf("Expected one pull but got %d", fetcher.calls)
+	}
+}
+
+//blockingFetcher - holds the station call until it is released
+type blockingFetcher struct {
+	*fakeFetcher
+	release chan struct{}
+}
+
+func (object *blockingFetcher) RetrieveStation(ctx context.Context, stationID string) (*noaaclient.StationMetadata, error) {
+	<-object.release
+	return object.fakeFetcher.RetrieveStation(ctx, stationID)
+}
+
+//TestCacheRun - the stations are refreshed on the interval until the context is done
+func TestCacheRun(t *testing.T) {
+	fetcher := &fakeFetcher{}
+	cache := NewCache(fetcher, 10*time.Millisecond)
+	if _, err := cache.Capabilities(context.Background(), "8454000"); err != nil {
+		t.Fatal(err.Error())
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		cache.Run(ctx)
+		close(done)
+	}()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		fetcher.lock.Lock()
+		calls := fetcher.calls
+		fetcher.lock.Unlock()
+		if calls >= 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the station to be refreshed but got %d calls", calls)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("Expected run to stop")
+	}
+}
@@ -0,0 +1,472 @@
+package stationstore
+
+import (
+	"context"
+	"encoding/binary"
+	"sort"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
+	sledgconf_demo_proto_v1 "github.com/mornindew/sledgeconf2021/pkg/grpc-service/genProto"
+	noaaclient "github.com/mornindew/sledgeconf2021/pkg/noaa-client"
+	bolt "go.etcd.io/bbolt"
+)
+
+//DefaultSettleTime - Noaa keeps filling in and correcting recent data so only the part of a window that ended longer ago than this is marked as stored.  Anything newer is fetched every time (and still written)
+const DefaultSettleTime = 48 * time.Hour
+
+//Bucket layout - a bucket for each series (keyed on the request's SeriesKey) under the series bucket.  Each series has its points in gmt keyed on time (and the bin and name for currents), the time ranges that have been stored, the metadata, and when it was last written
+var (
+	seriesBucket   = []byte("series")
+	pointsBucket   = []byte("points")
+	coverageBucket = []byte("coverage")
+	metadataKey    = []byte("metadata")
+	updatedKey     = []byte("updated")
+)
+
+//Range - a window of time that has been stored for a series.  Both ends are included and are to the minute
+type Range struct {
+	Begin time.Time
+	End   time.Time
+}
+
+//Store - keeps the data that has come back from Noaa on disk along with the time ranges that it covers so the same data doesn't have to be fetched again.  Survives restarts
+//
+//Safe to use from many go routines at once.  Only one process can have the file open at a time
+type Store struct {
+	db         *bolt.DB
+	settleTime time.Duration
+	//now - swapped out in the tests
+	now func() time.Time
+}
+
+//Open - opens the store at the path and creates it if it isn't there
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	InternalServerError - the file couldn't be opened (e.g. another process has it open)
+func Open(path string) (*Store, error) {
+	//Precondition
+	if path == "" {
+		return nil, customerrors.PreconditionError{Msg: "Missing Mandatory Data"}
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, customerrors.InternalServerError{Msg: "Unable to open the station store: " + err.Error()}
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(seriesBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, customerrors.InternalServerError{Msg: "Unable to set up the station store: " + err.Error()}
+	}
+	return &Store{db: db, settleTime: DefaultSettleTime, now: time.Now}, nil
+}
+
+//Close - closes the file.  The store cannot be used after
+func (object *Store) Close() error {
+	return object.db.Close()
+}
+
+//Coverage - the time ranges of the request's series that have been stored and won't be fetched again (oldest first)
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	InternalServerError - the store couldn't be read
+func (object *Store) Coverage(request *noaaclient.DataRequest) ([]Range, error) {
+	//Precondition
+	if request == nil {
+		return nil, customerrors.PreconditionError{Msg: "Missing Mandatory Data"}
+	}
+	covered, err := object.coverage([]byte(request.SeriesKey()))
+	if err != nil {
+		return nil, err
+	}
+	ranges := make([]Range, 0, len(covered))
+	for _, coveredInterval := range covered {
+		ranges = append(ranges, Range{Begin: fromMinutes(coveredInterval.begin), End: fromMinutes(coveredInterval.end)})
+	}
+	return ranges, nil
+}
+
+//LocalData - whatever is stored for the request's window without going to Noaa along with when the series was last written.  Nil if nothing is stored (or the request isn't a date range that can be stored).  The times are in gmt no matter the request's time zone (see Fetcher.LocalData)
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	InternalServerError - the store couldn't be read
+func (object *Store) LocalData(request *noaaclient.DataRequest) (*sledgconf_demo_proto_v1.ProductDataValues, time.Time, error) {
+	//Precondition
+	if request == nil || request.Window == nil {
+		return nil, time.Time{}, customerrors.PreconditionError{Msg: "Missing Mandatory Data"}
+	}
+	if !storable(request) || request.Window.BeginDate == nil || request.Window.EndDate == nil {
+		return nil, time.Time{}, nil
+	}
+	seriesKey := []byte(request.SeriesKey())
+	productData, err := object.read(seriesKey, request.Product, interval{begin: toMinutes(*request.Window.BeginDate), end: toMinutes(*request.Window.EndDate)})
+	if err != nil || len(productData.Data) == 0 {
+		return nil, time.Time{}, err
+	}
+	var updated time.Time
+	object.db.View(func(tx *bolt.Tx) error {
+		if value := tx.Bucket(seriesBucket).Bucket(seriesKey).Get(updatedKey); value != nil {
+			updated = time.Unix(fromPointKey(value), 0)
+		}
+		return nil
+	})
+	return productData, updated, nil
+}
+
+//Localizer - moves the gmt data in the store over to the time zone that was asked for (e.g. a NoaaClient)
+type Localizer interface {
+	LocalizeProductData(ctx context.Context, productData *sledgconf_demo_proto_v1.ProductDataValues, stationID string, timeZone noaaclient.TimeZone) error
+}
+
+//Fetcher - reads what is in the store and only fetches the time ranges that are missing.  What gets fetched is written back
+//
+//Everything is fetched and stored in gmt so every time zone shares the same series.  It is moved to the zone that was asked for on the way out
+type Fetcher struct {
+	store     *Store
+	fetcher   noaaclient.DataFetcher
+	localizer Localizer
+}
+
+//NewFetcher - Constructor.  The fetcher is what gets the missing time ranges (e.g. a NoaaClient) and the localizer moves the data to the time zone that was asked for.  Without a localizer only gmt requests use the store
+func NewFetcher(store *Store, fetcher noaaclient.DataFetcher, localizer Localizer) *Fetcher {
+	return &Fetcher{store: store, fetcher: fetcher, localizer: localizer}
+}
+
+//RetrieveData - gets the data for the request out of the store after fetching and storing the time ranges that are missing
+//
+//Only date ranges of the products that have a time on each point are stored.  Everything else (e.g. latest or datums) goes straight to the fetcher
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	InvalidData - a param isn't valid for the product
+//	InternalServerError - the store couldn't be read or written
+//	Anything that the fetcher returns
+func (object *Fetcher) RetrieveData(ctx context.Context, request *noaaclient.DataRequest) (*sledgconf_demo_proto_v1.ProductDataValues, error) {
+	//Precondition
+	if ctx == nil || request == nil {
+		return nil, customerrors.PreconditionError{Msg: "Empty Mandatory Values"}
+	}
+	err := request.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if !storable(request) || (request.TimeZone != noaaclient.GMT && object.localizer == nil) {
+		return object.fetcher.RetrieveData(ctx, request)
+	}
+	gmtRequest := *request
+	gmtRequest.TimeZone = noaaclient.GMT
+	seriesKey := []byte(request.SeriesKey())
+	window := interval{begin: toMinutes(*request.Window.BeginDate), end: toMinutes(*request.Window.EndDate)}
+	covered, err := object.store.coverage(seriesKey)
+	if err != nil {
+		return nil, err
+	}
+	settled := toMinutes(object.store.now().Add(-object.store.settleTime))
+	for _, gap := range missing(covered, window) {
+		productData, err := object.fetcher.RetrieveData(ctx, gap.request(&gmtRequest))
+		if err != nil {
+			return nil, err
+		}
+		//Without the typed times there is nothing to key the points on so the whole window comes from the fetcher
+		if len(productData.TypedData) != len(productData.Data) {
+			return object.fetcher.RetrieveData(ctx, request)
+		}
+		err = object.store.write(seriesKey, productData, gap.settled(settled))
+		if err != nil {
+			return nil, err
+		}
+	}
+	productData, err := object.store.read(seriesKey, request.Product, window)
+	if err != nil {
+		return nil, err
+	}
+	err = object.localize(ctx, productData, request)
+	if err != nil {
+		return nil, err
+	}
+	return productData, nil
+}
+
+//LocalData - same as the store's LocalData with the times moved to the request's time zone.  Nil if the data can't be moved (e.g. the station's time zone isn't known)
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	InternalServerError - the store couldn't be read
+func (object *Fetcher) LocalData(request *noaaclient.DataRequest) (*sledgconf_demo_proto_v1.ProductDataValues, time.Time, error) {
+	//Precondition
+	if request == nil {
+		return nil, time.Time{}, customerrors.PreconditionError{Msg: "Missing Mandatory Data"}
+	}
+	if request.TimeZone != noaaclient.GMT && object.localizer == nil {
+		return nil, time.Time{}, nil
+	}
+	productData, updated, err := object.store.LocalData(request)
+	if err != nil || productData == nil {
+		return nil, time.Time{}, err
+	}
+	if object.localize(context.Background(), productData, request) != nil {
+		return nil, time.Time{}, nil
+	}
+	return productData, updated, nil
+}
+
+///INTERNAL FUNCTIONS
+
+//localize - moves the gmt data read from the store to the request's time zone
+func (object *Fetcher) localize(ctx context.Context, productData *sledgconf_demo_proto_v1.ProductDataValues, request *noaaclient.DataRequest) error {
+	if request.TimeZone == noaaclient.GMT || len(productData.Data) == 0 {
+		return nil
+	}
+	return object.localizer.LocalizeProductData(ctx, productData, request.StationID, request.TimeZone)
+}
+
+//storable - only date ranges are fixed in time and the daily and monthly means, and datums, don't have a time to the minute on their points
+func storable(request *noaaclient.DataRequest) bool {
+	if request.Window.Mode != noaaclient.DateRangeQuery {
+		return false
+	}
+	switch request.Product {
+	case noaaclient.Datums, noaaclient.DailyMean, noaaclient.MonthlyMean:
+		return false
+	}
+	return true
+}
+
+//interval - minutes since the epoch with both ends included
+type interval struct {
+	begin int64
+	end   int64
+}
+
+func toMinutes(value time.Time) int64 {
+	return value.Unix() / 60
+}
+
+func fromMinutes(value int64) time.Time {
+	return time.Unix(value*60, 0).UTC()
+}
+
+//missing - the parts of the window that the covered intervals (sorted and merged) don't have
+func missing(covered []interval, window interval) []interval {
+	gaps := make([]interval, 0)
+	next := window.begin
+	for _, coveredInterval := range covered {
+		if coveredInterval.end < next {
+			continue
+		}
+		if coveredInterval.begin > window.end {
+			break
+		}
+		if coveredInterval.begin > next {
+			gaps = append(gaps, interval{begin: next, end: coveredInterval.begin - 1})
+		}
+		next = coveredInterval.end + 1
+	}
+	if next <= window.end {
+		gaps = append(gaps, interval{begin: next, end: window.end})
+	}
+	return gaps
+}
+
+//merge - sorts the intervals and joins the ones that overlap or touch
+func merge(intervals []interval) []interval {
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].begin < intervals[j].begin })
+	merged := make([]interval, 0, len(intervals))
+	for _, current := range intervals {
+		last := len(merged) - 1
+		if last >= 0 && current.begin <= merged[last].end+1 {
+			if current.end > merged[last].end {
+				merged[last].end = current.end
+			}
+			continue
+		}
+		merged = append(merged, current)
+	}
+	return merged
+}
+
+//request - copy of the request for just the interval.  Noaa needs the end to be after the begin so a single minute asks for two
+func (object interval) request(request *noaaclient.DataRequest) *noaaclient.DataRequest {
+	beginDate := fromMinutes(object.begin)
+	endDate := fromMinutes(object.end)
+	if object.end == object.begin {
+		endDate = fromMinutes(object.end + 1)
+	}
+	gapRequest := *request
+	gapRequest.Window = noaaclient.NewDateRangeWindow(&beginDate, &endDate)
+	return &gapRequest
+}
+
+//settled - the part of the interval that ended before the settled minute.  Nil if none of it has
+func (object interval) settled(settled int64) *interval {
+	if object.begin > settled {
+		return nil
+	}
+	if object.end > settled {
+		object.end = settled
+	}
+	return &object
+}
+
+//pointKey - the time as a big endian number so the points sort by time.  Flipping the sign bit keeps the times before 1970 in order
+func pointKey(epochInSeconds int64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(epochInSeconds)^(1<<63))
+	return key
+}
+
+//dataPointKey - the point's time followed by its bin and name when it has them.  Currents have a point for each bin at the same time so the time alone would have them overwrite each other
+func dataPointKey(typedData *sledgconf_demo_proto_v1.TypedData, dataPoint *sledgconf_demo_proto_v1.Data) []byte {
+	key := pointKey(typedData.TimeEpochInSeconds)
+	if dataPoint.B == "" && dataPoint.N == "" {
+		return key
+	}
+	return append(key, []byte("|"+dataPoint.B+"|"+dataPoint.N)...)
+}
+
+//fromPointKey - undoes pointKey
+func fromPointKey(key []byte) int64 {
+	return int64(binary.BigEndian.Uint64(key) ^ (1 << 63))
+}
+
+//coverage - the intervals that have been stored for the series
+func (object *Store) coverage(seriesKey []byte) ([]interval, error) {
+	covered := make([]interval, 0)
+	err := object.db.View(func(tx *bolt.Tx) error {
+		series := tx.Bucket(seriesBucket).Bucket(seriesKey)
+		if series == nil {
+			return nil
+		}
+		coverage := series.Bucket(coverageBucket)
+		if coverage == nil {
+			return nil
+		}
+		return coverage.ForEach(func(key, value []byte) error {
+			covered = append(covered, interval{begin: fromPointKey(key), end: fromPointKey(value)})
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, customerrors.InternalServerError{Msg: "Unable to read the station store: " + err.Error()}
+	}
+	return covered, nil
+}
+
+//write - stores the points and the metadata and marks the interval as covered in one transaction so the coverage never gets ahead of the points.  Nil doesn't mark anything
+//
+//Each point is stored as a ProductDataValues with the one data point and its typed version
+func (object *Store) write(seriesKey []byte, productData *sledgconf_demo_proto_v1.ProductDataValues, coveredInterval *interval) error {
+	err := object.db.Update(func(tx *bolt.Tx) error {
+		series, err := tx.Bucket(seriesBucket).CreateBucketIfNotExists(seriesKey)
+		if err != nil {
+			return err
+		}
+		points, err := series.CreateBucketIfNotExists(pointsBucket)
+		if err != nil {
+			return err
+		}
+		for index, dataPoint := range productData.Data {
+			typedData := productData.TypedData[index]
+			value, err := proto.Marshal(&sledgconf_demo_proto_v1.ProductDataValues{Data: []*sledgconf_demo_proto_v1.Data{dataPoint}, TypedData: []*sledgconf_demo_proto_v1.TypedData{typedData}})
+			if err != nil {
+				return err
+			}
+			err = points.Put(dataPointKey(typedData, dataPoint), value)
+			if err != nil {
+				return err
+			}
+		}
+		if productData.Metadata != nil {
+			value, err := proto.Marshal(productData.Metadata)
+			if err != nil {
+				return err
+			}
+			err = series.Put(metadataKey, value)
+			if err != nil {
+				return err
+			}
+		}
+		err = series.Put(updatedKey, pointKey(object.now().Unix()))
+		if err != nil {
+			return err
+		}
+		if coveredInterval == nil {
+			return nil
+		}
+		return addCoverage(series, *coveredInterval)
+	})
+	if err != nil {
+		return customerrors.InternalServerError{Msg: "Unable to write to the station store: " + err.Error()}
+	}
+	return nil
+}
+
+//addCoverage - merges the interval into the series' coverage.  The coverage is small so it is rewritten
+func addCoverage(series *bolt.Bucket, coveredInterval interval) error {
+	covered := []interval{coveredInterval}
+	coverage := series.Bucket(coverageBucket)
+	if coverage != nil {
+		err := coverage.ForEach(func(key, value []byte) error {
+			covered = append(covered, interval{begin: fromPointKey(key), end: fromPointKey(value)})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		err = series.DeleteBucket(coverageBucket)
+		if err != nil {
+			return err
+		}
+	}
+	coverage, err := series.CreateBucket(coverageBucket)
+	if err != nil {
+		return err
+	}
+	for _, mergedInterval := range merge(covered) {
+		err = coverage.Put(pointKey(mergedInterval.begin), pointKey(mergedInterval.end))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//read - the points in the window (oldest first) with the series' metadata
+func (object *Store) read(seriesKey []byte, dataProduct noaaclient.DataProduct, window interval) (*sledgconf_demo_proto_v1.ProductDataValues, error) {
+	productData := &sledgconf_demo_proto_v1.ProductDataValues{DataType: dataProduct.ConvertToGrpcEnum()}
+	err := object.db.View(func(tx *bolt.Tx) error {
+		series := tx.Bucket(seriesBucket).Bucket(seriesKey)
+		if series == nil || series.Bucket(pointsBucket) == nil {
+			return nil
+		}
+		//Every second of the last minute is in the window.  Only the time part of the key is compared since currents have their bin after it
+		last := pointKey(window.end*60 + 59)
+		cursor := series.Bucket(pointsBucket).Cursor()
+		for key, value := cursor.Seek(pointKey(window.begin * 60)); key != nil && string(key[:len(last)]) <= string(last); key, value = cursor.Next() {
+			point := &sledgconf_demo_proto_v1.ProductDataValues{}
+			err := proto.Unmarshal(value, point)
+			if err != nil {
+				return err
+			}
+			productData.Data = append(productData.Data, point.Data...)
+			productData.TypedData = append(productData.TypedData, point.TypedData...)
+		}
+		//Same as the client - no data means no metadata
+		if len(productData.Data) == 0 || series.Get(metadataKey) == nil {
+			return nil
+		}
+		productData.Metadata = &sledgconf_demo_proto_v1.Metadata{}
+		return proto.Unmarshal(series.Get(metadataKey), productData.Metadata)
+	})
+	if err != nil {
+		return nil, customerrors.InternalServerError{Msg: "Unable to read the station store: " + err.Error()}
+	}
+	return productData, nil
+}
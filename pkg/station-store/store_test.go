@@ -0,0 +1,300 @@
+package stationstore
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	sledgconf_demo_proto_v1 "github.com/mornindew/sledgeconf2021/pkg/grpc-service/genProto"
+	noaaclient "github.com/mornindew/sledgeconf2021/pkg/noaa-client"
+)
+
+//fakeFetcher - sends back a point every 6 minutes (one for each bin when there are bins) for the window and keeps track of the windows and time zones that were asked for
+type fakeFetcher struct {
+	windows   []interval
+	timeZones []noaaclient.TimeZone
+	bins      []string
+}
+
+func (object *fakeFetcher) RetrieveData(ctx context.Context, request *noaaclient.DataRequest) (*sledgconf_demo_proto_v1.ProductDataValues, error) {
+	window := request.Window
+	if window.Mode == noaaclient.DateRangeQuery {
+		object.windows = append(object.windows, interval{begin: toMinutes(*window.BeginDate), end: toMinutes(*window.EndDate)})
+		object.timeZones = append(object.timeZones, request.TimeZone)
+	}
+	productData := &sledgconf_demo_proto_v1.ProductDataValues{DataType: request.Product.ConvertToGrpcEnum(), Metadata: &sledgconf_demo_proto_v1.Metadata{Id: request.StationID}}
+	if window.Mode != noaaclient.DateRangeQuery {
+		return productData, nil
+	}
+	//Noaa's windows include both ends
+	for pointTime := window.BeginDate.Truncate(6 * time.Minute); !pointTime.After(*window.EndDate); pointTime = pointTime.Add(6 * time.Minute) {
+		if pointTime.Before(*window.BeginDate) {
+			continue
+		}
+		if len(object.bins) == 0 {
+			productData.Data = append(productData.Data, &sledgconf_demo_proto_v1.Data{T: pointTime.Format("2006-01-02 15:04"), V: "1.234"})
+			productData.TypedData = append(productData.TypedData, &sledgconf_demo_proto_v1.TypedData{TimeEpochInSeconds: pointTime.Unix(), Value: 1.234})
+		}
+		for _, bin := range object.bins {
+			productData.Data = append(productData.Data, &sledgconf_demo_proto_v1.Data{T: pointTime.Format("2006-01-02 15:04"), S: "0.5", B: bin})
+			productData.TypedData = append(productData.TypedData, &sledgconf_demo_proto_v1.TypedData{TimeEpochInSeconds: pointTime.Unix(), Values: map[string]float64{"speed": 0.5}})
+		}
+	}
+	return productData, nil
+}
+
+//fakeLocalizer - the station is 5 hours behind gmt
+type fakeLocalizer struct{}
+
+func (object fakeLocalizer) LocalizeProductData(ctx context.Context, productData *sledgconf_demo_proto_v1.ProductDataValues, stationID string, timeZone noaaclient.TimeZone) error {
+	for _, dataPoint := range productData.Data {
+		pointTime, err := time.Parse("2006-01-02 15:04", dataPoint.T)
+		if err != nil {
+			return err
+		}
+		dataPoint.T = pointTime.Add(-5 * time.Hour).Format("2006-01-02 15:04")
+	}
+	productData.Metadata.TimeZone = timeZone.String()
+	return nil
+}
+
+//openTestStore - a store in the test's temp dir
+func openTestStore(t *testing.T, path string) *Store {
+	store, err := Open(path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return store
+}
+
+//newWindowRequest - water levels for the window
+func newWindowRequest(t *testing.T, beginDate, endDate time.Time) *noaaclient.DataRequest {
+	request, err := noaaclient.NewDataRequestBuilder("8454000", noaaclient.WaterLevel).Window(noaaclient.NewDateRangeWindow(&beginDate, &endDate)).Datum(noaaclient.MLLW).Units(noaaclient.Metric).Build()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return request
+}
+
+//TestStoreFetchesOnlyGaps - what is stored isn't fetched again and a wider window only fetches its edges
+func TestStoreFetchesOnlyGaps(t *testing.T) {
+	store := openTestStore(t, filepath.Join(t.TempDir(), "stations.db"))
+	defer store.Close()
+	upstream := &fakeFetcher{}
+	fetcher := NewFetcher(store, upstream, nil)
+	noon := time.Date(2021, 8, 20, 12, 0, 0, 0, time.UTC)
+
+	productData, err := fetcher.RetrieveData(context.Background(), newWindowRequest(t, noon, noon.Add(time.Hour)))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(productData.Data) != 11 || len(productData.TypedData) != 11 || productData.Metadata.Id != "8454000" {
+		t.Errorf("Expected 11 points with the metadata but got %d", len(productData.Data))
+	}
+	//Same window comes out of the store
+	_, err = fetcher.RetrieveData(context.Background(), newWindowRequest(t, noon, noon.Add(time.Hour)))
+	if err != nil || len(upstream.windows) != 1 {
+		t.Errorf("Expected the window to come out of the store but got %d fetches and %v", len(upstream.windows), err)
+	}
+
+	//A wider window fetches the edges
+	upstream.windows = nil
+	productData, err = fetcher.RetrieveData(context.Background(), newWindowRequest(t, noon.Add(-time.Hour), noon.Add(2*time.Hour)))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expected := []interval{{toMinutes(noon.Add(-time.Hour)), toMinutes(noon) - 1}, {toMinutes(noon.Add(time.Hour)) + 1, toMinutes(noon.Add(2 * time.Hour))}}
+	if len(upstream.windows) != 2 || upstream.windows[0] != expected[0] || upstream.windows[1] != expected[1] {
+		t.Errorf("Expected the edges %v but got %v", expected, upstream.windows)
+	}
+	if len(productData.Data) != 31 {
+		t.Fatalf("Expected 31 points but got %d", len(productData.Data))
+	}
+	for index := 1; index < len(productData.TypedData); index++ {
+		if productData.TypedData[index].TimeEpochInSeconds-productData.TypedData[index-1].TimeEpochInSeconds != 360 {
+			t.Fatal("Expected the points in order without any missing")
+		}
+	}
+	coverage, err := store.Coverage(newWindowRequest(t, noon, noon.Add(time.Hour)))
+	if err != nil || len(coverage) != 1 || !coverage[0].Begin.Equal(noon.Add(-time.Hour)) || !coverage[0].End.Equal(noon.Add(2*time.Hour)) {
+		t.Errorf("Expected the coverage to be merged but got %v", coverage)
+	}
+
+	//A different series doesn't use what is stored
+	upstream.windows = nil
+	english := newWindowRequest(t, noon, noon.Add(time.Hour))
+	units := noaaclient.English
+	english.Units = &units
+	fetcher.RetrieveData(context.Background(), english)
+	if len(upstream.windows) != 1 {
+		t.Errorf("Expected different units to be fetched but got %d fetches", len(upstream.windows))
+	}
+}
+
+//TestStoreSurvivesRestarts - the coverage and points are still there after the store is opened again
+func TestStoreSurvivesRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stations.db")
+	noon := time.Date(2021, 8, 20, 12, 0, 0, 0, time.UTC)
+	upstream := &fakeFetcher{}
+	store := openTestStore(t, path)
+	_, err := NewFetcher(store, upstream, nil).RetrieveData(context.Background(), newWindowRequest(t, noon, noon.Add(time.Hour)))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	store.Close()
+
+	store = openTestStore(t, path)
+	defer store.Close()
+	productData, err := NewFetcher(store, upstream, nil).RetrieveData(context.Background(), newWindowRequest(t, noon.Add(30*time.Minute), noon.Add(time.Hour)))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(upstream.windows) != 1 || len(productData.Data) != 6 {
+		t.Errorf("Expected 6 points from the store but got %d with %d fetches", len(productData.Data), len(upstream.windows))
+	}
+}
+
+//TestStoreRecentData - data that Noaa may still change is fetched every time.  Windows that aren't date ranges go straight through
+func TestStoreRecentData(t *testing.T) {
+	store := openTestStore(t, filepath.Join(t.TempDir(), "stations.db"))
+	defer store.Close()
+	now := time.Date(2021, 8, 20, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	upstream := &fakeFetcher{}
+	fetcher := NewFetcher(store, upstream, nil)
+
+	//Starts before the settle time and ends after it
+	request := newWindowRequest(t, now.Add(-DefaultSettleTime-time.Hour), now.Add(-DefaultSettleTime+time.Hour))
+	for index := 0; index < 2; index++ {
+		if _, err := fetcher.RetrieveData(context.Background(), request); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	settled := toMinutes(now.Add(-DefaultSettleTime))
+	if len(upstream.windows) != 2 || upstream.windows[1].begin != settled+1 {
+		t.Errorf("Expected the unsettled hour to be fetched again but got %v", upstream.windows)
+	}
+
+	latest, _ := noaaclient.NewDataRequestBuilder("8454000", noaaclient.WaterLevel).Window(noaaclient.NewQueryModeWindow(noaaclient.LatestQuery)).Build()
+	productData, err := fetcher.RetrieveData(context.Background(), latest)
+	if err != nil || productData.Metadata == nil {
+		t.Errorf("Expected latest to come from the fetcher but got %v", err)
+	}
+}
+
+//TestStoreLocalData - what is stored comes back without fetching along with when it was written
+func TestStoreLocalData(t *testing.T) {
+	store := openTestStore(t, filepath.Join(t.TempDir(), "stations.db"))
+	defer store.Close()
+	now := time.Date(2021, 8, 30, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	upstream := &fakeFetcher{}
+	noon := time.Date(2021, 8, 20, 12, 0, 0, 0, time.UTC)
+	if productData, _, err := store.LocalData(newWindowRequest(t, noon, noon.Add(time.Hour))); productData != nil || err != nil {
+		t.Errorf("Expected nothing stored but got %v and %v", productData, err)
+	}
+	if _, err := NewFetcher(store, upstream, nil).RetrieveData(context.Background(), newWindowRequest(t, noon, noon.Add(time.Hour))); err != nil {
+		t.Fatal(err.Error())
+	}
+	productData, updated, err := store.LocalData(newWindowRequest(t, noon.Add(30*time.Minute), noon.Add(2*time.Hour)))
+	if err != nil || productData == nil || len(productData.Data) != 6 || !updated.Equal(now) {
+		t.Errorf("Expected 6 stored points written at %s but got %v at %s and %v", now, productData, updated, err)
+	}
+	if len(upstream.windows) != 1 {
+		t.Errorf("Expected local data not to fetch but got %d fetches", len(upstream.windows))
+	}
+}
+
+//TestStoreCurrentBins - the bins that share a time are all kept
+func TestStoreCurrentBins(t *testing.T) {
+	store := openTestStore(t, filepath.Join(t.TempDir(), "stations.db"))
+	defer store.Close()
+	upstream := &fakeFetcher{bins: []string{"1", "2", "3"}}
+	fetcher := NewFetcher(store, upstream, nil)
+	noon := time.Date(2021, 8, 20, 12, 0, 0, 0, time.UTC)
+	endDate := noon.Add(time.Minute)
+	request, err := noaaclient.NewDataRequestBuilder("8454000", noaaclient.Currents).Window(noaaclient.NewDateRangeWindow(&noon, &endDate)).Build()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for index := 0; index < 2; index++ {
+		productData, err := fetcher.RetrieveData(context.Background(), request)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if len(productData.Data) != 3 || len(productData.TypedData) != 3 || productData.Data[0].B != "1" || productData.Data[2].B != "3" {
+			t.Errorf("%d: expected a point for each bin but got %v", index, productData.Data)
+		}
+	}
+	if len(upstream.windows) != 1 {
+		t.Errorf("Expected the bins to come out of the store but got %d fetches", len(upstream.windows))
+	}
+}
+
+//TestStoreTimeZones - every time zone shares the gmt series and is moved to its zone on the way out
+func TestStoreTimeZones(t *testing.T) {
+	store := openTestStore(t, filepath.Join(t.TempDir(), "stations.db"))
+	defer store.Close()
+	upstream := &fakeFetcher{}
+	fetcher := NewFetcher(store, upstream, fakeLocalizer{})
+	noon := time.Date(2021, 8, 20, 12, 0, 0, 0, time.UTC)
+	gmt := newWindowRequest(t, noon, noon.Add(time.Hour))
+	local := newWindowRequest(t, noon, noon.Add(time.Hour))
+	local.TimeZone = noaaclient.LST
+
+	productData, err := fetcher.RetrieveData(context.Background(), local)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if productData.Data[0].T != "2021-08-20 07:00" || productData.Metadata.TimeZone != noaaclient.LST.String() {
+		t.Errorf("Expected the data in the station's time but got %s", productData.Data[0].T)
+	}
+	productData, err = fetcher.RetrieveData(context.Background(), gmt)
+	if err != nil || productData.Data[0].T != "2021-08-20 12:00" {
+		t.Errorf("Expected the data in gmt but got %v and %v", productData, err)
+	}
+	if len(upstream.windows) != 1 || upstream.timeZones[0] != noaaclient.GMT {
+		t.Errorf("Expected one gmt fetch for both time zones but got %v", upstream.timeZones)
+	}
+	productData, _, err = fetcher.LocalData(local)
+	if err != nil || productData == nil || productData.Data[0].T != "2021-08-20 07:00" {
+		t.Errorf("Expected the local data in the station's time but got %v and %v", productData, err)
+	}
+
+	//Without a localizer only gmt uses the store
+	upstream.windows = nil
+	if _, err = NewFetcher(store, upstream, nil).RetrieveData(context.Background(), local); err != nil || len(upstream.windows) != 1 || upstream.timeZones[1] != noaaclient.LST {
+		t.Errorf("Expected the time zone to go straight to the fetcher but got %v and %v", upstream.timeZones, err)
+	}
+}
+
+//TestMissing - the gaps between what is covered
+func TestMissing(t *testing.T) {
+	tests := []struct {
+		covered  []interval
+		window   interval
+		expected []interval
+	}{
+		{nil, interval{10, 20}, []interval{{10, 20}}},
+		{[]interval{{0, 30}}, interval{10, 20}, []interval{}},
+		{[]interval{{12, 14}, {17, 18}}, interval{10, 20}, []interval{{10, 11}, {15, 16}, {19, 20}}},
+		{[]interval{{0, 5}, {25, 30}}, interval{10, 20}, []interval{{10, 20}}},
+		{[]interval{{10, 10}, {20, 20}}, interval{10, 20}, []interval{{11, 19}}},
+	}
+	for index, test := range tests {
+		gaps := missing(test.covered, test.window)
+		if len(gaps) != len(test.expected) {
+			t.Errorf("%d: expected %v but got %v", index, test.expected, gaps)
+			continue
+		}
+		for gapIndex := range gaps {
+			if gaps[gapIndex] != test.expected[gapIndex] {
+				t.Errorf("%d: expected %v but got %v", index, test.expected, gaps)
+			}
+		}
+	}
+	if merged := merge([]interval{{20, 30}, {0, 5}, {6, 10}, {25, 40}}); len(merged) != 2 || merged[0] != (interval{0, 10}) || merged[1] != (interval{20, 40}) {
+		t.Errorf("Unexpected merge %v", merged)
+	}
+}
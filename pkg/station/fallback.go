@@ -0,0 +1,114 @@
+package station
+
+import (
+	"context"
+	"time"
+
+	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
+	sledgconf_demo_proto_v1 "github.com/mornindew/sledgeconf2021/pkg/grpc-service/genProto"
+	noaaclient "github.com/mornindew/sledgeconf2021/pkg/noaa-client"
+)
+
+//ServingMode - enum for what happens when Noaa can't be reached
+//
+//	Online - every call goes to Noaa (through the cache and store) and failures are returned
+//	StaleFallback - when Noaa fails the newest data on hand in the cache or store is served instead and marked as stale
+//	Offline - Noaa is never called.  Only the data on hand is served and it is marked as stale
+type ServingMode int
+
+const (
+	Online ServingMode = iota
+	StaleFallback
+	Offline
+)
+
+//String - the SERVING_MODE setting.  Empty when it isn't one of the serving modes
+func (enum ServingMode) String() string {
+	if enum < Online || enum > Offline {
+		return ""
+	}
+	return []string{"online", "staleFallback", "offline"}[enum]
+}
+
+//ConvertStringServingModeToEnum - Helper Function to convert from string to serving mode.  An empty string is online
+func ConvertStringServingModeToEnum(val string) (ServingMode, error) {
+	switch val {
+	case "", "online":
+		return Online, nil
+	case "staleFallback":
+		return StaleFallback, nil
+	case "offline":
+		return Offline, nil
+	}
+	//Handle something not matching
+	return -1, customerrors.InvalidData{Msg: "Not a valid serving mode"}
+}
+
+///INTERNAL FUNCTIONS
+
+//localSource - somewhere that has data on hand without calling Noaa (the cache or the store)
+type localSource interface {
+	LocalData(request *noaaclient.DataRequest) (*sledgconf_demo_proto_v1.ProductDataValues, time.Time, error)
+}
+
+//fallbackFetcher - serves the data on hand when Noaa can't be reached (or always when offline).  What it serves is marked as stale with how old it is
+type fallbackFetcher struct {
+	fetcher noaaclient.DataFetcher
+	sources []localSource
+	mode    ServingMode
+	//now - swapped out in the tests
+	now func() time.Time
+}
+
+//RetrieveData - calls the fetcher unless offline.  Falls back to the local data when Noaa is down or sends back something unusable
+//
+//	Errors:
+//	ServiceUnavailableError - offline and there isn't any local data
+//	Anything that the fetcher returns when there isn't any local data to fall back on
+func (object *fallbackFetcher) RetrieveData(ctx context.Context, request *noaaclient.DataRequest) (*sledgconf_demo_proto_v1.ProductDataValues, error) {
+	if object.mode == Offline {
+		if productData := object.localData(request); productData != nil {
+			return productData, nil
+		}
+		return nil, customerrors.ServiceUnavailableError{Msg: "Offline and there isn't any local data for " + request.Product.String() + " at " + request.StationID}
+	}
+	productData, err := object.fetcher.RetrieveData(ctx, request)
+	if err == nil || !unreachable(ctx, err) {
+		return productData, err
+	}
+	if stale := object.localData(request); stale != nil {
+		return stale, nil
+	}
+	return nil, err
+}
+
+//unreachable - whether the error means Noaa is down or sending back junk.  Bad requests and the caller going away aren't something local data fixes
+func unreachable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	switch err.(type) {
+	case customerrors.ServiceUnavailableError, customerrors.InternalServerError, customerrors.BadFormat:
+		return true
+	}
+	return false
+}
+
+//localData - the newest data any of the sources has marked as stale.  Nil if none of them have anything
+func (object *fallbackFetcher) localData(request *noaaclient.DataRequest) *sledgconf_demo_proto_v1.ProductDataValues {
+	var newest *sledgconf_demo_proto_v1.ProductDataValues
+	var fetched time.Time
+	for _, source := range object.sources {
+		productData, sourceFetched, err := source.LocalData(request)
+		if err != nil || productData == nil || (newest != nil && !sourceFetched.After(fetched)) {
+			continue
+		}
+		newest, fetched = productData, sourceFetched
+	}
+	if newest == nil {
+		return nil
+	}
+	newest.Stale = true
+	newest.AgeInSeconds = int64(object.now().Sub(fetched) / time.Second)
+	return newest
+}
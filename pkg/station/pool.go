@@ -0,0 +1,224 @@
+package station
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
+)
+
+//Default limits for the worker pool
+const (
+	//DefaultGlobalConcurrency - products being fetched across every request
+	DefaultGlobalConcurrency = 32
+	//DefaultRequestConcurrency - products being fetched for a single request
+	DefaultRequestConcurrency = 8
+)
+
+//WorkerPool - limits how many products are being fetched from Noaa at once.  Safe to share between retrievers
+//
+//There is a global limit across every request and a limit for each request.  When the global limit is hit the requests that are waiting take turns so one big request cannot starve the small ones
+//
+//The limit is on tasks (one product for one station) and not on calls to Noaa.  A task can make more than one call: long windows are split into chunks (up to noaaclient.DefaultChunkConcurrency at once) and the station metadata, time zone and datum lookups are calls of their own.  The most calls in flight is roughly the global limit times the chunk concurrency
+type WorkerPool struct {
+	globalLimit  int
+	requestLimit int
+	lock         sync.Mutex
+	inUse        int
+	//waiting - requests that have a call waiting in the order they will be served
+	waiting []*poolSession
+}
+
+//DefaultWorkerPool - what the package level functions use
+var DefaultWorkerPool = NewWorkerPool(DefaultGlobalConcurrency, DefaultRequestConcurrency)
+
+//NewWorkerPool - Constructor.  Anything less than 1 uses the default
+func NewWorkerPool(globalLimit, requestLimit int) *WorkerPool {
+	if globalLimit < 1 {
+		globalLimit = DefaultGlobalConcurrency
+	}
+	if requestLimit < 1 {
+		requestLimit = DefaultRequestConcurrency
+	}
+	return &WorkerPool{globalLimit: globalLimit, requestLimit: requestLimit}
+}
+
+//ParseWorkerPoolLimits - makes a pool from the limits as strings (e.g. from the environment).  An empty string uses the default
+//
+//	Errors:
+//	InvalidData - a limit isn't a number above 0
+func ParseWorkerPoolLimits(globalLimit, requestLimit string) (*WorkerPool, error) {
+	limits := make([]int, 0, 2)
+	for _, limit := range []string{globalLimit, requestLimit} {
+		if limit == "" {
+			limits = append(limits, 0)
+			continue
+		}
+		parsedLimit, err := strconv.Atoi(limit)
+		if err != nil || parsedLimit < 1 {
+			return nil, customerrors.InvalidData{Msg: "Not a valid worker pool limit: " + limit}
+		}
+		limits = append(limits, parsedLimit)
+	}
+	return NewWorkerPool(limits[0], limits[1]), nil
+}
+
+//InUse - how many tasks are running
+func (object *WorkerPool) InUse() int {
+	object.lock.Lock()
+	defer object.lock.Unlock()
+	return object.inUse
+}
+
+//Waiting - how many tasks are waiting for a slot
+func (object *WorkerPool) Waiting() int {
+	object.lock.Lock()
+	defer object.lock.Unlock()
+	waiting := 0
+	for _, session := range object.waiting {
+		waiting += len(session.waiters)
+	}
+	return waiting
+}
+
+///INTERNAL FUNCTIONS
+
+//poolSession - a single request's place in the pool
+type poolSession struct {
+	pool *WorkerPool
+	//waiters - calls from this request that are waiting (oldest first).  Only touched while holding the pool lock
+	waiters []chan struct{}
+}
+
+//newSession - each request gets its own session so that it can take its turn
+func (object *WorkerPool) newSession() *poolSession {
+	return &poolSession{pool: object}
+}
+
+//run - runs the task for every index with at most the request limit running at once.  Every task takes a slot from the pool before it runs
+//
+//The first error cancels the context that the rest are using and is the error that gets returned.  Always waits for every go routine to finish so nothing is left behind
+func (object *WorkerPool) run(ctx context.Context, count int, task func(ctx context.Context, index int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	session := object.newSession()
+	workers := object.requestLimit
+	if count < workers {
+		workers = count
+	}
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	var next int32 = -1
+	recordErr := func(err error) {
+		//The siblings will fail with the cancel so only the first one is kept
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+	wg.Add(workers)
+	for worker := 0; worker < workers; worker++ {
+		go func() {
+			defer wg.Done()
+			for {
+				index := int(atomic.AddInt32(&next, 1))
+				if index >= count {
+					return
+				}
+				err := session.acquire(ctx)
+				if err != nil {
+					recordErr(err)
+					return
+				}
+				err = task(ctx, index)
+				session.release()
+				if err != nil {
+					recordErr(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+//acquire - waits for a slot.  Returns the context's error if it is done first
+func (object *poolSession) acquire(ctx context.Context) error {
+	pool := object.pool
+	pool.lock.Lock()
+	if pool.inUse < pool.globalLimit && len(pool.waiting) == 0 {
+		pool.inUse++
+		pool.lock.Unlock()
+		return nil
+	}
+	//Buffered so that release never blocks handing over the slot
+	granted := make(chan struct{}, 1)
+	if len(object.waiters) == 0 {
+		pool.waiting = append(pool.waiting, object)
+	}
+	object.waiters = append(object.waiters, granted)
+	pool.lock.Unlock()
+
+	select {
+	case <-granted:
+		return nil
+	case <-ctx.Done():
+		pool.lock.Lock()
+		removed := object.removeWaiter(granted)
+		pool.lock.Unlock()
+		if !removed {
+			//The slot was handed over while we were giving up so pass it along
+			pool.release()
+		}
+		return ctx.Err()
+	}
+}
+
+//release - gives the slot back
+func (object *poolSession) release() {
+	object.pool.release()
+}
+
+//release - hands the slot to the next request in line or puts it back.  The request that gets the slot goes to the back of the line
+func (object *WorkerPool) release() {
+	object.lock.Lock()
+	defer object.lock.Unlock()
+	if len(object.waiting) == 0 {
+		object.inUse--
+		return
+	}
+	session := object.waiting[0]
+	object.waiting = object.waiting[1:]
+	granted := session.waiters[0]
+	session.waiters = session.waiters[1:]
+	if len(session.waiters) > 0 {
+		object.waiting = append(object.waiting, session)
+	}
+	granted <- struct{}{}
+}
+
+//removeWaiter - takes a waiter out of line.  False if it already got a slot.  Must hold the pool lock
+func (object *poolSession) removeWaiter(granted chan struct{}) bool {
+	for index, waiter := range object.waiters {
+		if waiter != granted {
+			continue
+		}
+		object.waiters = append(object.waiters[:index], object.waiters[index+1:]...)
+		if len(object.waiters) == 0 {
+			//Nothing left waiting so the request leaves the line
+			pool := object.pool
+			for sessionIndex, session := range pool.waiting {
+				if session == object {
+					pool.waiting = append(pool.waiting[:sessionIndex], pool.waiting[sessionIndex+1:]...)
+					break
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+package station
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
+)
+
+//TestWorkerPoolFirstError - every task runs, the first error wins, and the rest see the cancel
+func TestWorkerPoolFirstError(t *testing.T) {
+	firstErr := errors.New("first")
+	var cancelled int32
+	pool := NewWorkerPool(10, 10)
+	err := pool.run(context.Background(), 10, func(ctx context.Context, index int) error {
+		if index == 3 {
+			return firstErr
+		}
+		<-ctx.Done()
+		atomic.AddInt32(&cancelled, 1)
+		return ctx.Err()
+	})
+	if err != firstErr {
+		t.Errorf("Expected the first error but got %v", err)
+	}
+	if cancelled != 9 {
+		t.Errorf("Expected 9 tasks to be cancelled but got %d", cancelled)
+	}
+	if pool.InUse() != 0 || pool.Waiting() != 0 {
+		t.Error("Expected every slot to be given back")
+	}
+}
+
+//TestWorkerPoolLimits - neither the global or the per request limit is ever passed
+func TestWorkerPoolLimits(t *testing.T) {
+	pool := NewWorkerPool(3, 2)
+	var globalRunning, globalMax int32
+	var wg sync.WaitGroup
+	for request := 0; request < 3; request++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var running, max int32
+			pool.run(context.Background(), 10, func(ctx context.Context, index int) error {
+				updateMax(&max, atomic.AddInt32(&running, 1))
+				updateMax(&globalMax, atomic.AddInt32(&globalRunning, 1))
+				time.Sleep(2 * time.Millisecond)
+				atomic.AddInt32(&globalRunning, -1)
+				atomic.AddInt32(&running, -1)
+				return nil
+			})
+			if max > 2 {
+				t.Errorf("Expected at most 2 for a request but got %d", max)
+			}
+		}()
+	}
+	wg.Wait()
+	if globalMax > 3 {
+		t.Errorf("Expected at most 3 overall but got %d", globalMax)
+	}
+}
+
+//TestWorkerPoolFairness - a small request that shows up after a big one doesn't have to wait for the big one to finish
+func TestWorkerPoolFairness(t *testing.T) {
+	pool := NewWorkerPool(1, 4)
+	var lock sync.Mutex
+	order := make([]string, 0)
+	task := func(name string) func(context.Context, int) error {
+		return func(ctx context.Context, index int) error {
+			time.Sleep(2 * time.Millisecond)
+			lock.Lock()
+			order = append(order, name)
+			lock.Unlock()
+			return nil
+		}
+	}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		pool.run(context.Background(), 20, task("big"))
+	}()
+	//Wait for the big request to be in line
+	for pool.Waiting() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	go func() {
+		defer wg.Done()
+		pool.run(context.Background(), 2, task("small"))
+	}()
+	wg.Wait()
+	lastSmall := 0
+	for index, name := range order {
+		if name == "small" {
+			lastSmall = index
+		}
+	}
+	//The requests take turns so the small one is done long before the big one
+	if lastSmall > 8 {
+		t.Errorf("Expected the small request to take turns with the big one but it finished at %d of %d", lastSmall, len(order))
+	}
+}
+
+//TestWorkerPoolCancelWhileWaiting - giving up while in line doesn't lose a slot
+func TestWorkerPoolCancelWhileWaiting(t *testing.T) {
+	pool := NewWorkerPool(1, 1)
+	holding := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		pool.run(context.Background(), 1, func(ctx context.Context, index int) error {
+			close(holding)
+			<-done
+			return nil
+		})
+	}()
+	<-holding
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := pool.run(ctx, 1, func(ctx context.Context, index int) error {
+		t.Error("Should not have gotten a slot")
+		return nil
+	})
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected the deadline to be exceeded but got %v", err)
+	}
+	close(done)
+	for pool.InUse() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if pool.Waiting() != 0 {
+		t.Error("Expected nothing to be waiting")
+	}
+}
+
+//TestParseWorkerPoolLimits - empty is the default and anything that isn't a number above 0 is rejected
+func TestParseWorkerPoolLimits(t *testing.T) {
+	pool, err := ParseWorkerPoolLimits("", "")
+	if err != nil || pool.globalLimit != DefaultGlobalConcurrency || pool.requestLimit != DefaultRequestConcurrency {
+		t.Errorf("Expected the defaults but got %+v %v", pool, err)
+	}
+	pool, err = ParseWorkerPoolLimits("16", "4")
+	if err != nil || pool.globalLimit != 16 || pool.requestLimit != 4 {
+		t.Errorf("Expected 16 and 4 but got %+v %v", pool, err)
+	}
+	for _, limits := range [][2]string{{"x", ""}, {"", "0"}, {"-1", "4"}} {
+		_, err := ParseWorkerPoolLimits(limits[0], limits[1])
+		if _, ok := err.(customerrors.InvalidData); !ok {
+			t.Errorf("Expected invalid data for %v but got %v", limits, err)
+		}
+	}
+}
+
+//updateMax - keeps the highest value seen
+func updateMax(max *int32, value int32) {
+	for {
+		current := atomic.LoadInt32(max)
+		if value <= current || atomic.CompareAndSwapInt32(max, current, value) {
+			return
+		}
+	}
+}
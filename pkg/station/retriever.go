@@ -0,0 +1,363 @@
+package station
+
+import (
+	"context"
+	"time"
+
+	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
+	sledgconf_demo_proto_v1 "github.com/mornindew/sledgeconf2021/pkg/grpc-service/genProto"
+	noaaclient "github.com/mornindew/sledgeconf2021/pkg/noaa-client"
+	productcache "github.com/mornindew/sledgeconf2021/pkg/product-cache"
+	stationmetadata "github.com/mornindew/sledgeconf2021/pkg/station-metadata"
+	stationstore "github.com/mornindew/sledgeconf2021/pkg/station-store"
+)
+
+//Retriever - pulls the data for stations from Noaa.  The package level functions use a Retriever with the default client settings
+type Retriever struct {
+	//fetcher - the cache in front of the store in front of the coalescer in front of one long lived client for every request.  The datum and units are set on each call
+	fetcher noaaclient.DataFetcher
+	//coalescer - identical calls that are in flight at the same time share one call to Noaa
+	coalescer *noaaclient.Coalescer
+	//store - nil when the data isn't kept on disk.  It holds gmt and the localizer moves it to the time zone that was asked for
+	store     *stationstore.Store
+	localizer stationstore.Localizer
+	//cache - nil when it is turned off
+	cache     *productcache.Cache
+	cacheSize int64
+	//capabilities - which products each station has so only those are asked for.  Nil asks every station for every product
+	capabilities *stationmetadata.Cache
+	//servingMode - whether the data on hand is served when Noaa can't be reached
+	servingMode ServingMode
+	//datums - where the station datums come from so the water levels are converted locally.  Nil has Noaa convert them
+	datums noaaclient.DatumSource
+	//pool - limits the calls to Noaa that are in flight
+	pool *WorkerPool
+}
+
+//defaultRetriever - what the package level functions use
+var defaultRetriever = NewRetriever()
+
+//NewRetriever - Constructor.  The options are used to make the NoaaClient (e.g. a different base url or http client).  Uses the DefaultWorkerPool
+//
+//Identical calls from requests that overlap share one call to Noaa and the data is cached (up to productcache.DefaultMaxBytes).  Only the products that a station's metadata says it has are asked for.  The water levels are fetched in one datum and converted to the others locally
+func NewRetriever(clientOptions ...noaaclient.ClientOption) *Retriever {
+	//The datum and metric are set on every call so the client's defaults don't matter
+	client := noaaclient.NewNoaaClient(noaaclient.MLLW, noaaclient.Metric.String(), clientOptions...)
+	retriever := &Retriever{coalescer: noaaclient.NewCoalescer(client), capabilities: stationmetadata.NewCache(client, stationmetadata.DefaultRefreshInterval), datums: client, localizer: client, cacheSize: productcache.DefaultMaxBytes, pool: DefaultWorkerPool}
+	retriever.buildFetcher()
+	return retriever
+}
+
+//SetCacheSize - replaces the cache with an empty one that holds up to max bytes.  Anything less than 1 turns the cache off.  Call it before the retriever is shared
+func (object *Retriever) SetCacheSize(maxBytes int64) {
+	object.cacheSize = maxBytes
+	object.buildFetcher()
+}
+
+//SetStore - keeps the data in the store so only the time ranges that are missing are fetched from Noaa.  Nil stops using a store.  The caller still owns the store and closes it.  Call it before the retriever is shared
+func (object *Retriever) SetStore(store *stationstore.Store) {
+	object.store = store
+	object.buildFetcher()
+}
+
+//SetServingMode - StaleFallback serves the data in the cache or store when Noaa can't be reached and Offline never calls Noaa.  Call it before the retriever is shared
+func (object *Retriever) SetServingMode(mode ServingMode) {
+	object.servingMode = mode
+	object.buildFetcher()
+}
+
+//SetCapabilities - where the products that each station has come from.  Nil asks every station for every product and leaves it to Noaa to send back nothing.  Call it before the retriever is shared
+func (object *Retriever) SetCapabilities(capabilities *stationmetadata.Cache) {
+	object.capabilities = capabilities
+}
+
+//SetDatumSource - where the station datums that the water levels are converted with come from.  Nil has Noaa convert them so every datum is its own call.  Call it before the retriever is shared
+func (object *Retriever) SetDatumSource(datums noaaclient.DatumSource) {
+	object.datums = datums
+	object.buildFetcher()
+}
+
+//RefreshCapabilities - pulls the metadata for the stations that have been asked for on the refresh interval until the context is done.  Blocks so call it in its own go routine.  Does nothing when offline
+func (object *Retriever) RefreshCapabilities(ctx context.Context) {
+	if object.capabilities == nil || object.servingMode == Offline {
+		return
+	}
+	object.capabilities.Run(ctx)
+}
+
+//CacheStats - hits, misses and size of the cache.  Empty if the cache is turned off
+func (object *Retriever) CacheStats() productcache.Stats {
+	if object.cache == nil {
+		return productcache.Stats{}
+	}
+	return object.cache.Stats()
+}
+
+//SetWorkerPool - sets the pool that limits the calls to Noaa.  Nil is ignored
+func (object *Retriever) SetWorkerPool(pool *WorkerPool) {
+	if pool == nil {
+		return
+	}
+	object.pool = pool
+}
+
+//CoalescingStats - how many calls went to Noaa and how many were saved by sharing a call that was already in flight
+func (object *Retriever) CoalescingStats() noaaclient.CoalescingStats {
+	return object.coalescer.Stats()
+}
+
+//RetrieveStationDataSync - gets every product for every station one call at a time
+//
+//Each station has the status of every product.  Products the station doesn't have aren't asked for and are not available.  With BestEffort a failed product is left out of the product data instead of failing the request
+//
+//Cancelling the context (or hitting its deadline) stops the calls to Noaa and returns the context's error
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	InvalidData - incorrect data
+//	InternalServerError - unhandled error
+func (object *Retriever) RetrieveStationDataSync(ctx context.Context, request *Request) (*map[string]*sledgconf_demo_proto_v1.Station, error) {
+	//Precondition check
+	if ctx == nil || request == nil {
+		return nil, customerrors.PreconditionError{Msg: "Missing Mandatory Data"}
+	}
+	err := request.Validate()
+	if err != nil {
+		return nil, err
+	}
+	mapToReturnOfAllStations := make(map[string]*sledgconf_demo_proto_v1.Station)
+	//Only one call at a time but it still counts against the pool
+	session := object.pool.newSession()
+
+	//Loop through all the station IDs
+	for _, val := range request.StationIDs {
+		//Construct the station object
+		stationData := &sledgconf_demo_proto_v1.Station{}
+		//Construc the station data
+		stationData.ProductData = make(map[string]*sledgconf_demo_proto_v1.ProductDataValues)
+		stationData.ProductStatus = make(map[string]*sledgconf_demo_proto_v1.ProductStatus)
+		err := session.acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		capabilities := object.stationCapabilities(ctx, val)
+		session.release()
+		//Loop through all the data and aggregate what is available
+		for _, productEnum := range request.products() {
+			//Keyed on the grpc enum name the same as the concurrent calls
+			productKey := productEnum.ConvertToGrpcEnum().String()
+			if capabilities != nil && !capabilities.Supports(productEnum) {
+				stationData.ProductStatus[productKey] = notAvailableStatus(productEnum)
+				continue
+			}
+			err := session.acquire(ctx)
+			if err != nil {
+				return nil, err
+			}
+			stationProductData, err := object.retrieveProduct(ctx, request, val, productEnum)
+			session.release()
+			if err != nil && !keepGoing(ctx, request) {
+				return nil, err
+			}
+			stationData.ProductStatus[productKey] = productStatus(productEnum, stationProductData, err, capabilities != nil)
+			if err != nil {
+				continue
+			}
+			//Append the product data
+			stationProductData.DataType = productEnum.ConvertToGrpcEnum()
+			stationData.ProductData[productKey] = stationProductData
+		}
+		//Append it to the full station map response
+		mapToReturnOfAllStations[val] = stationData
+	}
+	return &mapToReturnOfAllStations, nil
+}
+
+//RetrieveStationData - gets every product for every station with the calls running in parallel on the worker pool
+//
+//The first error cancels everything that is still running and every go routine has finished before this returns.  Cancelling the context (or hitting its deadline) does the same and returns the context's error
+//
+//Each station has the status of every product.  Products the station doesn't have aren't asked for and are not available.  With BestEffort a failed product is left out of the product data instead of failing the request
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	InvalidData - incorrect data
+//	InternalServerError - unhandled error
+func (object *Retriever) RetrieveStationData(ctx context.Context, request *Request) (*map[string]*sledgconf_demo_proto_v1.Station, error) {
+	//Precondition check
+	if ctx == nil || request == nil {
+		return nil, customerrors.PreconditionError{Msg: "Missing Mandatory Data"}
+	}
+	err := request.Validate()
+	if err != nil {
+		return nil, err
+	}
+	products := request.products()
+	//Work out which products each station has first so the rest aren't asked for
+	capabilities := make([]*stationmetadata.Capabilities, len(request.StationIDs))
+	err = object.pool.run(ctx, len(capabilities), func(ctx context.Context, index int) error {
+		capabilities[index] = object.stationCapabilities(ctx, request.StationIDs[index])
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	//Every product for every station is its own task.  Each task only writes to its own slot so there is no need for a lock
+	productData := make([]*sledgconf_demo_proto_v1.ProductDataValues, len(request.StationIDs)*len(products))
+	productStatuses := make([]*sledgconf_demo_proto_v1.ProductStatus, len(productData))
+	err = object.pool.run(ctx, len(productData), func(ctx context.Context, index int) error {
+		stationID := request.StationIDs[index/len(products)]
+		productEnum := products[index%len(products)]
+		stationCapabilities := capabilities[index/len(products)]
+		if stationCapabilities != nil && !stationCapabilities.Supports(productEnum) {
+			productStatuses[index] = notAvailableStatus(productEnum)
+			return nil
+		}
+		stationProductData, err := object.retrieveProduct(ctx, request, stationID, productEnum)
+		if err != nil && !keepGoing(ctx, request) {
+			return err
+		}
+		productStatuses[index] = productStatus(productEnum, stationProductData, err, stationCapabilities != nil)
+		if err != nil {
+			return nil
+		}
+		//Set the enum - since it doesn't come from teh webserivce
+		stationProductData.DataType = productEnum.ConvertToGrpcEnum()
+		productData[index] = stationProductData
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	mapToReturnOfAllStations := make(map[string]*sledgconf_demo_proto_v1.Station, len(request.StationIDs))
+	for stationIndex, stationID := range request.StationIDs {
+		start := stationIndex * len(products)
+		mapToReturnOfAllStations[stationID] = newStationData(stationID, productData[start:start+len(products)], productStatuses[start:start+len(products)])
+	}
+	return &mapToReturnOfAllStations, nil
+}
+
+///INTERNAL FUNCTIONS
+
+//buildFetcher - stacks the cache and store (when they are on) in front of the coalescer.  Unless online the fallback to the data they have goes in front of those and the datum conversion goes in front of everything so every datum shares the same data.  The cache starts out empty
+func (object *Retriever) buildFetcher() {
+	var fetcher noaaclient.DataFetcher = object.coalescer
+	var storeFetcher *stationstore.Fetcher
+	if object.store != nil {
+		storeFetcher = stationstore.NewFetcher(object.store, fetcher, object.localizer)
+		fetcher = storeFetcher
+	}
+	object.cache = nil
+	if object.cacheSize > 0 {
+		object.cache = productcache.NewCache(fetcher, object.cacheSize, productcache.DefaultTTLPolicy)
+		fetcher = object.cache
+	}
+	if object.servingMode != Online {
+		fallback := &fallbackFetcher{fetcher: fetcher, mode: object.servingMode, now: time.Now}
+		if object.cache != nil {
+			fallback.sources = append(fallback.sources, object.cache)
+		}
+		if object.store != nil {
+			fallback.sources = append(fallback.sources, storeFetcher)
+		}
+		fetcher = fallback
+	}
+	//Offline can't pull the datums so the data on hand is only served in the datum it came in
+	if object.datums != nil && object.servingMode != Offline {
+		fetcher = noaaclient.NewDatumConverter(fetcher, object.datums)
+	}
+	object.fetcher = fetcher
+}
+
+//stationCapabilities - the products that the station has.  Nil when every product should be asked for (the capabilities are off, we are offline, or the metadata couldn't be pulled)
+func (object *Retriever) stationCapabilities(ctx context.Context, stationID string) *stationmetadata.Capabilities {
+	if object.capabilities == nil || object.servingMode == Offline {
+		return nil
+	}
+	capabilities, err := object.capabilities.Capabilities(ctx, stationID)
+	if err != nil {
+		return nil
+	}
+	return capabilities
+}
+
+//retrieveProduct - builds the Noaa request for one product on a station and makes the call.  Everything is fetched in metric so every unit shares the same data and it is converted to the unit that was asked for here
+func (object *Retriever) retrieveProduct(ctx context.Context, request *Request, stationID string, dataProduct noaaclient.DataProduct) (*sledgconf_demo_proto_v1.ProductDataValues, error) {
+	dataRequest, err := noaaclient.NewDataRequestBuilder(stationID, dataProduct).
+		Window(request.Window).
+		TimeZone(request.TimeZone).
+		Datum(request.Datum).
+		Units(noaaclient.Metric).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+	if request.BypassCache {
+		ctx = productcache.WithBypass(ctx)
+	}
+	productData, err := object.fetcher.RetrieveData(ctx, dataRequest)
+	if err != nil {
+		return nil, err
+	}
+	//The data is a copy so it can be filtered and converted in place
+	if productData != nil {
+		err = noaaclient.FilterQuality(productData, request.QualityFilter)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if unit, ok := request.unitFor(dataProduct); ok && productData != nil {
+		err = noaaclient.ConvertProductUnits(productData, noaaclient.Metric, unit, noaaclient.PreferredMeasurementUnit(request.PreferredMetric))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return productData, nil
+}
+
+//newStationData - puts a station's products into the maps keyed on the grpc enum name.  Failed products only have a status
+func newStationData(stationID string, productData []*sledgconf_demo_proto_v1.ProductDataValues, productStatuses []*sledgconf_demo_proto_v1.ProductStatus) *sledgconf_demo_proto_v1.Station {
+	stationData := &sledgconf_demo_proto_v1.Station{StationID: stationID}
+	stationData.ProductData = make(map[string]*sledgconf_demo_proto_v1.ProductDataValues, len(productData))
+	stationData.ProductStatus = make(map[string]*sledgconf_demo_proto_v1.ProductStatus, len(productStatuses))
+	for index, status := range productStatuses {
+		stationData.ProductStatus[status.DataType.String()] = status
+		if productData[index] != nil {
+			stationData.ProductData[status.DataType.String()] = productData[index]
+		}
+	}
+	return stationData
+}
+
+//keepGoing - whether a failed product should be recorded instead of failing the request.  The caller going away always stops everything
+func keepGoing(ctx context.Context, request *Request) bool {
+	return request.FailureMode == BestEffort && ctx.Err() == nil
+}
+
+//notAvailableStatus - the station doesn't have the product so Noaa wasn't asked
+func notAvailableStatus(dataProduct noaaclient.DataProduct) *sledgconf_demo_proto_v1.ProductStatus {
+	return &sledgconf_demo_proto_v1.ProductStatus{DataType: dataProduct.ConvertToGrpcEnum(), Status: sledgconf_demo_proto_v1.ProductStatusCode_NotAvailable, Reason: "The station doesn't have " + dataProduct.String()}
+}
+
+//productStatus - ok if there is data, no data if Noaa didn't have anything, and failed with the reason if there was an error.  Stale data has the reason it is stale
+//
+//Planned is when the station's metadata says it has the product.  Noaa sending nothing back for those is still no data but its message is the reason since something may be wrong
+func productStatus(dataProduct noaaclient.DataProduct, productData *sledgconf_demo_proto_v1.ProductDataValues, err error, planned bool) *sledgconf_demo_proto_v1.ProductStatus {
+	status := &sledgconf_demo_proto_v1.ProductStatus{DataType: dataProduct.ConvertToGrpcEnum()}
+	switch {
+	case err != nil:
+		status.Status = sledgconf_demo_proto_v1.ProductStatusCode_Failed
+		status.Reason = err.Error()
+	case productData == nil || len(productData.Data) == 0:
+		status.Status = sledgconf_demo_proto_v1.ProductStatusCode_NoData
+		if planned && productData != nil && productData.NoDataReason != "" {
+			status.Reason = "The station should have " + dataProduct.String() + " but Noaa sent nothing back: " + productData.NoDataReason
+		}
+	default:
+		status.Status = sledgconf_demo_proto_v1.ProductStatusCode_OK
+	}
+	if err == nil && productData != nil && productData.Stale {
+		status.Reason = "Noaa wasn't called or couldn't be reached so the data is " + (time.Duration(productData.AgeInSeconds) * time.Second).String() + " old"
+	}
+	return status
+}
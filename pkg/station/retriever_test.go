@@ -0,0 +1,685 @@
+package station
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
+	sledgconf_demo_proto_v1 "github.com/mornindew/sledgeconf2021/pkg/grpc-service/genProto"
+	noaaclient "github.com/mornindew/sledgeconf2021/pkg/noaa-client"
+	productcache "github.com/mornindew/sledgeconf2021/pkg/product-cache"
+	stationmetadata "github.com/mornindew/sledgeconf2021/pkg/station-metadata"
+	stationstore "github.com/mornindew/sledgeconf2021/pkg/station-store"
+	"github.com/mornindew/sledgeconf2021/pkg/units"
+)
+
+//newTestRetriever - a retriever pointed at the test server that doesn't retry, doesn't trip breakers, doesn't cache, asks for every product and doesn't keep connections around (they would show up as go routines)
+func newTestRetriever(server *httptest.Server) *Retriever {
+	httpClient := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}, Timeout: 10 * time.Second}
+	retriever := NewRetriever(noaaclient.WithBaseURL(server.URL), noaaclient.WithHTTPClient(httpClient), noaaclient.WithRetryPolicy(noaaclient.NoRetryPolicy), noaaclient.WithCircuitBreakers(nil))
+	retriever.SetCacheSize(0)
+	retriever.SetCapabilities(nil)
+	retriever.SetDatumSource(nil)
+	return retriever
+}
+
+//checkForLeaks - waits for the go routine count to get back to where it started
+func checkForLeaks(t *testing.T, baseline int) {
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > baseline {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			t.Fatalf("Expected %d go routines but there are %d\n%s", baseline, runtime.NumGoroutine(), buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+//TestRetrieveStationDataFirstError - one product failing cancels the rest and nothing is left running
+func TestRetrieveStationDataFirstError(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("product") == noaaclient.Wind.String() {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error": {"message": "Noaa is having a bad day"}}`))
+			return
+		}
+		//Everything else hangs until it is cancelled
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+
+	request := &Request{StationIDs: []string{"8454000", "8452944", "8447386"}, Window: noaaclient.NewQueryModeWindow(noaaclient.LatestQuery), Datum: noaaclient.MLLW}
+	started := time.Now()
+	_, err := newTestRetriever(server).RetrieveStationData(context.Background(), request)
+	if _, ok := err.(customerrors.InternalServerError); !ok {
+		t.Errorf("Expected the wind error but got %v", err)
+	}
+	if time.Since(started) > 2*time.Second {
+		t.Error("Expected the error to cancel the calls that were hanging")
+	}
+	server.Close()
+	checkForLeaks(t, baseline)
+}
+
+//TestRetrieveStationDataAllProducts - every product comes back for every station and nothing is left running
+func TestRetrieveStationDataAllProducts(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		//Only count the data calls.  The daily means look up the station's time zone
+		if !strings.HasPrefix(r.URL.Path, "/mdapi/") {
+			atomic.AddInt32(&calls, 1)
+		}
+		//No data for anything
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+
+	request := &Request{StationIDs: []string{"8454000", "8452944"}, Window: noaaclient.NewQueryModeWindow(noaaclient.LatestQuery), Datum: noaaclient.MLLW}
+	stations, err := newTestRetriever(server).RetrieveStationData(context.Background(), request)
+	server.Close()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(*stations) != 2 || calls != 2*int32(noaaclient.MaximumLimit) {
+		t.Fatalf("Expected 2 stations from %d calls but got %d stations from %d calls", 2*noaaclient.MaximumLimit, len(*stations), calls)
+	}
+	for stationID, stationData := range *stations {
+		if stationData.StationID != stationID || len(stationData.ProductData) != int(noaaclient.MaximumLimit) {
+			t.Error("Missing products for " + stationID)
+		}
+	}
+	checkForLeaks(t, baseline)
+}
+
+//TestRetrieveStationDataBestEffort - the products that worked come back and the status says what happened to each one
+func TestRetrieveStationDataBestEffort(t *testing.T) {
+	waterLevel, err := ioutil.ReadFile(filepath.Join("..", "noaa-client", "testdata", "water_level.json"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("product") {
+		case noaaclient.Wind.String():
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error": {"message": "Noaa is having a bad day"}}`))
+		case noaaclient.WaterLevel.String():
+			w.Write(waterLevel)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+	retriever := newTestRetriever(server)
+	request := &Request{StationIDs: []string{"8454000"}, Window: noaaclient.NewQueryModeWindow(noaaclient.LatestQuery), Datum: noaaclient.MLLW, FailureMode: BestEffort}
+
+	//Both key on the grpc enum name
+	tests := map[string]func(context.Context, *Request) (*map[string]*sledgconf_demo_proto_v1.Station, error){
+		"concurrent": retriever.RetrieveStationData,
+		"sync":       retriever.RetrieveStationDataSync,
+	}
+	for name, retrieve := range tests {
+		stations, err := retrieve(context.Background(), request)
+		if err != nil {
+			t.Errorf("%s: %s", name, err.Error())
+			continue
+		}
+		stationData := (*stations)["8454000"]
+		if len(stationData.ProductStatus) != int(noaaclient.MaximumLimit) || len(stationData.ProductData) != int(noaaclient.MaximumLimit)-1 {
+			t.Errorf("%s: expected a status for every product and data for all but wind", name)
+			continue
+		}
+		for key, status := range stationData.ProductStatus {
+			if key != status.DataType.String() {
+				t.Errorf("%s: expected the status to be keyed on %s but got %s", name, status.DataType, key)
+			}
+			expected := sledgconf_demo_proto_v1.ProductStatusCode_NoData
+			switch status.DataType {
+			case sledgconf_demo_proto_v1.DataType_Wind:
+				expected = sledgconf_demo_proto_v1.ProductStatusCode_Failed
+				if status.Reason == "" {
+					t.Errorf("%s: expected a reason for the failure", name)
+				}
+			case sledgconf_demo_proto_v1.DataType_WaterLevel:
+				expected = sledgconf_demo_proto_v1.ProductStatusCode_OK
+			}
+			if status.Status != expected {
+				t.Errorf("%s: expected %s for %s but got %s", name, expected, status.DataType, status.Status)
+			}
+		}
+		if _, ok := stationData.ProductData[sledgconf_demo_proto_v1.DataType_WaterLevel.String()]; !ok {
+			t.Errorf("%s: expected the water levels to be keyed on the grpc enum name", name)
+		}
+		if _, err := ObservationsForProduct(stationData, noaaclient.WaterLevel); err != nil {
+			t.Errorf("%s: expected the water levels but got %s", name, err.Error())
+		}
+	}
+
+	//Fail fast is still the default
+	request.FailureMode = FailFast
+	if _, err := retriever.RetrieveStationData(context.Background(), request); err == nil {
+		t.Error("Expected fail fast to return the wind error")
+	}
+}
+
+//TestRetrieveStationDataProducts - only the products that were asked for are pulled
+func TestRetrieveStationDataProducts(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+	retriever := newTestRetriever(server)
+	//Repeats are only pulled once
+	request := &Request{StationIDs: []string{"8454000", "8452944"}, Window: noaaclient.NewQueryModeWindow(noaaclient.LatestQuery), Datum: noaaclient.MLLW, Products: []noaaclient.DataProduct{noaaclient.WaterLevel, noaaclient.Wind, noaaclient.WaterLevel}}
+	for name, retrieve := range map[string]func(context.Context, *Request) (*map[string]*sledgconf_demo_proto_v1.Station, error){"concurrent": retriever.RetrieveStationData, "sync": retriever.RetrieveStationDataSync} {
+		atomic.StoreInt32(&calls, 0)
+		stations, err := retrieve(context.Background(), request)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if calls != 4 {
+			t.Errorf("%s: expected 4 calls but got %d", name, calls)
+		}
+		for stationID, stationData := range *stations {
+			if len(stationData.ProductData) != 2 || len(stationData.ProductStatus) != 2 {
+				t.Errorf("%s: expected 2 products for %s", name, stationID)
+			}
+		}
+	}
+
+	request.Products = []noaaclient.DataProduct{noaaclient.MaximumLimit}
+	if _, ok := request.Validate().(customerrors.InvalidData); !ok {
+		t.Error("Expected a product that doesn't exist to be invalid")
+	}
+}
+
+//TestRetrieveStationDataCoalescing - requests that overlap share the calls that are the same
+func TestRetrieveStationDataCoalescing(t *testing.T) {
+	gate := make(chan struct{})
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		//The daily means look up the station's time zone and that isn't coalesced
+		if strings.HasPrefix(r.URL.Path, "/mdapi/") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		atomic.AddInt32(&calls, 1)
+		<-gate
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+	retriever := newTestRetriever(server)
+	retriever.SetWorkerPool(NewWorkerPool(DefaultGlobalConcurrency, DefaultRequestConcurrency))
+	request := &Request{StationIDs: []string{"8454000"}, Window: noaaclient.NewQueryModeWindow(noaaclient.LatestQuery), Datum: noaaclient.MLLW}
+
+	errs := make(chan error, 2)
+	for index := 0; index < cap(errs); index++ {
+		go func() {
+			_, err := retriever.RetrieveStationData(context.Background(), request)
+			errs <- err
+		}()
+	}
+	//Let everything go once the requests have started sharing
+	deadline := time.Now().Add(2 * time.Second)
+	for retriever.CoalescingStats().Saved == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the requests to share calls")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(gate)
+	for index := 0; index < cap(errs); index++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	stats := retriever.CoalescingStats()
+	if int64(calls) != stats.Calls || stats.Calls+stats.Saved != 2*int64(noaaclient.MaximumLimit) {
+		t.Errorf("Expected every product to be a call or saved but got %d calls and %+v", calls, stats)
+	}
+}
+
+//TestRetrieveStationDataCache - the same window comes out of the cache unless the request bypasses it
+func TestRetrieveStationDataCache(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+	retriever := newTestRetriever(server)
+	retriever.SetCacheSize(productcache.DefaultMaxBytes)
+	beginDate := time.Date(2021, 8, 20, 15, 0, 0, 0, time.UTC)
+	endDate := beginDate.Add(6 * time.Hour)
+	request := &Request{StationIDs: []string{"8454000"}, Window: noaaclient.NewDateRangeWindow(&beginDate, &endDate), Datum: noaaclient.MLLW, Products: []noaaclient.DataProduct{noaaclient.WaterLevel, noaaclient.Wind}}
+
+	for _, test := range []struct {
+		bypass        bool
+		expectedCalls int32
+	}{{false, 2}, {false, 2}, {true, 4}, {false, 4}} {
+		request.BypassCache = test.bypass
+		stations, err := retriever.RetrieveStationData(context.Background(), request)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if len((*stations)["8454000"].ProductStatus) != 2 {
+			t.Error("Expected a status for both products")
+		}
+		if calls != test.expectedCalls {
+			t.Errorf("Expected %d calls but got %d", test.expectedCalls, calls)
+		}
+	}
+	stats := retriever.CacheStats()
+	if stats.Hits != 4 || stats.Misses != 2 || stats.Bypassed != 2 || stats.Entries != 2 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+//TestRetrieveStationDataDatums - every datum comes from the same cached water levels in MLLW and is converted locally
+func TestRetrieveStationDataDatums(t *testing.T) {
+	var lock sync.Mutex
+	var datums []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fileName := filepath.Join("..", "noaa-client", "testdata", "water_level.json")
+		if strings.HasPrefix(r.URL.Path, "/mdapi/") {
+			fileName = filepath.Join("..", "noaa-client", "testdata", "mdapi_datums.json")
+		} else {
+			lock.Lock()
+			datums = append(datums, r.URL.Query().Get("datum"))
+			lock.Unlock()
+		}
+		body, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(body)
+	}))
+	defer server.Close()
+	retriever := newTestRetriever(server)
+	retriever.SetCacheSize(productcache.DefaultMaxBytes)
+	retriever.SetDatumSource(noaaclient.NewNoaaClient(noaaclient.MLLW, noaaclient.Metric.String(), noaaclient.WithBaseURL(server.URL), noaaclient.WithRetryPolicy(noaaclient.NoRetryPolicy), noaaclient.WithCircuitBreakers(nil)))
+	beginDate := time.Date(2021, 8, 20, 15, 0, 0, 0, time.UTC)
+	endDate := beginDate.Add(time.Hour)
+	request := &Request{StationIDs: []string{"8454000"}, Window: noaaclient.NewDateRangeWindow(&beginDate, &endDate), PreferredMetric: noaaclient.Metric.String(), Products: []noaaclient.DataProduct{noaaclient.WaterLevel}}
+
+	for datum, expected := range map[noaaclient.Datum]string{noaaclient.MLLW: "1.234", noaaclient.MHHW: "-0.276", noaaclient.NAVD: "0.349"} {
+		request.Datum = datum
+		stations, err := retriever.RetrieveStationData(context.Background(), request)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		productData := (*stations)["8454000"].ProductData[sledgconf_demo_proto_v1.DataType_WaterLevel.String()]
+		if productData == nil || productData.Data[0].V != expected || productData.Data[2].V != "" {
+			t.Errorf("Expected %s in %s but got %+v", expected, datum, productData)
+		}
+	}
+	if len(datums) != 1 || datums[0] != noaaclient.MLLW.String() {
+		t.Errorf("Expected one call for the water levels in MLLW but got %v", datums)
+	}
+
+	//The station doesn't have the datum
+	request.Datum = noaaclient.IGLD
+	request.FailureMode = BestEffort
+	stations, err := retriever.RetrieveStationData(context.Background(), request)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if status := (*stations)["8454000"].ProductStatus[sledgconf_demo_proto_v1.DataType_WaterLevel.String()]; status.Status != sledgconf_demo_proto_v1.ProductStatusCode_Failed || !strings.Contains(status.Reason, "IGLD") {
+		t.Errorf("Expected the conversion to fail but got %+v", status)
+	}
+}
+
+//TestRetrieveStationDataUnits - the data is fetched once in metric and every unit is converted locally
+func TestRetrieveStationDataUnits(t *testing.T) {
+	var lock sync.Mutex
+	var fetched []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		fetched = append(fetched, r.URL.Query().Get("product")+":"+r.URL.Query().Get("units"))
+		lock.Unlock()
+		body, err := ioutil.ReadFile(filepath.Join("..", "noaa-client", "testdata", r.URL.Query().Get("product")+".json"))
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(body)
+	}))
+	defer server.Close()
+	retriever := newTestRetriever(server)
+	retriever.SetCacheSize(productcache.DefaultMaxBytes)
+	beginDate := time.Date(2021, 8, 20, 15, 0, 0, 0, time.UTC)
+	endDate := beginDate.Add(time.Hour)
+	request := &Request{StationIDs: []string{"8454000"}, Window: noaaclient.NewDateRangeWindow(&beginDate, &endDate), Datum: noaaclient.MLLW, Products: []noaaclient.DataProduct{noaaclient.WaterLevel, noaaclient.Wind}}
+
+	for _, test := range []struct {
+		preferredMetric string
+		units           map[noaaclient.DataProduct]units.Unit
+		waterLevel      string
+		wind            string
+		windUnit        string
+	}{
+		{"Metric", nil, "1.234", "4.22", "m/s"},
+		{"English", nil, "4.049", "8.203", "kn"},
+		{"Metric", map[noaaclient.DataProduct]units.Unit{noaaclient.WaterLevel: units.Feet, noaaclient.Wind: units.MilesPerHour}, "4.049", "9.44", "mph"},
+	} {
+		request.PreferredMetric = test.preferredMetric
+		request.Units = test.units
+		stations, err := retriever.RetrieveStationData(context.Background(), request)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		waterLevel := (*stations)["8454000"].ProductData[sledgconf_demo_proto_v1.DataType_WaterLevel.String()]
+		wind := (*stations)["8454000"].ProductData[sledgconf_demo_proto_v1.DataType_Wind.String()]
+		if waterLevel.Data[0].V != test.waterLevel || wind.Data[0].S != test.wind || wind.Unit != test.windUnit {
+			t.Errorf("Expected %s and %s %s but got %s and %s %s", test.waterLevel, test.wind, test.windUnit, waterLevel.Data[0].V, wind.Data[0].S, wind.Unit)
+		}
+	}
+	if len(fetched) != 2 || !strings.HasSuffix(fetched[0], ":metric") || !strings.HasSuffix(fetched[1], ":metric") {
+		t.Errorf("Expected one metric call for each product but got %v", fetched)
+	}
+
+	//Wind can't be in feet
+	request.Units = map[noaaclient.DataProduct]units.Unit{noaaclient.Wind: units.Feet}
+	if _, err := retriever.RetrieveStationData(context.Background(), request); err == nil {
+		t.Error("Expected wind in feet to be invalid")
+	}
+}
+
+//TestRetrieveStationDataQuality - flagged and preliminary points are left out of the cached data without another call
+func TestRetrieveStationDataQuality(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		body, err := ioutil.ReadFile(filepath.Join("..", "noaa-client", "testdata", "water_level.json"))
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(body)
+	}))
+	defer server.Close()
+	retriever := newTestRetriever(server)
+	retriever.SetCacheSize(productcache.DefaultMaxBytes)
+	beginDate := time.Date(2021, 8, 20, 15, 0, 0, 0, time.UTC)
+	endDate := beginDate.Add(time.Hour)
+	request := &Request{StationIDs: []string{"8454000"}, Window: noaaclient.NewDateRangeWindow(&beginDate, &endDate), Datum: noaaclient.MLLW, Products: []noaaclient.DataProduct{noaaclient.WaterLevel}}
+
+	for _, test := range []struct {
+		filter   noaaclient.QualityFilter
+		points   int
+		expected sledgconf_demo_proto_v1.ProductStatusCode
+	}{
+		{noaaclient.QualityFilter{}, 3, sledgconf_demo_proto_v1.ProductStatusCode_OK},
+		{noaaclient.QualityFilter{ExcludeFlagged: true}, 2, sledgconf_demo_proto_v1.ProductStatusCode_OK},
+		{noaaclient.QualityFilter{ExcludePreliminary: true}, 0, sledgconf_demo_proto_v1.ProductStatusCode_NoData},
+	} {
+		request.QualityFilter = test.filter
+		stations, err := retriever.RetrieveStationData(context.Background(), request)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		key := sledgconf_demo_proto_v1.DataType_WaterLevel.String()
+		productData := (*stations)["8454000"].ProductData[key]
+		if len(productData.Data) != test.points || len(productData.TypedData) != test.points || (*stations)["8454000"].ProductStatus[key].Status != test.expected {
+			t.Errorf("Expected %d points with %+v but got %+v", test.points, test.filter, productData.Data)
+		}
+		if test.points > 0 && productData.TypedData[0].QualityLevel != sledgconf_demo_proto_v1.QualityLevel_Preliminary {
+			t.Errorf("Expected preliminary data but got %s", productData.TypedData[0].QualityLevel)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected one call to Noaa but got %d", calls)
+	}
+}
+
+//TestRetrieveStationDataStore - what is in the store doesn't come from Noaa again even without the cache
+func TestRetrieveStationDataStore(t *testing.T) {
+	waterLevel, err := ioutil.ReadFile(filepath.Join("..", "noaa-client", "testdata", "water_level.json"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Write(waterLevel)
+	}))
+	defer server.Close()
+	store, err := stationstore.Open(filepath.Join(t.TempDir(), "stations.db"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer store.Close()
+	retriever := newTestRetriever(server)
+	retriever.SetStore(store)
+	beginDate := time.Date(2021, 8, 20, 15, 0, 0, 0, time.UTC)
+	endDate := beginDate.Add(6 * time.Hour)
+	request := &Request{StationIDs: []string{"8454000"}, Window: noaaclient.NewDateRangeWindow(&beginDate, &endDate), Datum: noaaclient.MLLW, Products: []noaaclient.DataProduct{noaaclient.WaterLevel}}
+	for index := 0; index < 2; index++ {
+		stations, err := retriever.RetrieveStationData(context.Background(), request)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		observations, err := ObservationsForProduct((*stations)["8454000"], noaaclient.WaterLevel)
+		if err != nil || len(observations) != 3 {
+			t.Errorf("Expected the 3 water levels but got %d and %v", len(observations), err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected 1 call but got %d", calls)
+	}
+}
+
+//TestRetrieveStationDataStaleFallback - when Noaa fails the cached data is served marked as stale.  Offline never calls Noaa and only serves what is stored
+func TestRetrieveStationDataStaleFallback(t *testing.T) {
+	waterLevel, err := ioutil.ReadFile(filepath.Join("..", "noaa-client", "testdata", "water_level.json"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var calls, down int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&down) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write(waterLevel)
+	}))
+	defer server.Close()
+	store, err := stationstore.Open(filepath.Join(t.TempDir(), "stations.db"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer store.Close()
+	retriever := newTestRetriever(server)
+	retriever.SetCacheSize(productcache.DefaultMaxBytes)
+	retriever.SetServingMode(StaleFallback)
+	beginDate := time.Date(2021, 8, 20, 15, 0, 0, 0, time.UTC)
+	endDate := beginDate.Add(6 * time.Hour)
+	request := &Request{StationIDs: []string{"8454000"}, Window: noaaclient.NewDateRangeWindow(&beginDate, &endDate), Datum: noaaclient.MLLW, Products: []noaaclient.DataProduct{noaaclient.WaterLevel}}
+	stations, err := retriever.RetrieveStationData(context.Background(), request)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if productData := (*stations)["8454000"].ProductData[sledgconf_demo_proto_v1.DataType_WaterLevel.String()]; productData.Stale {
+		t.Error("Expected fresh data from Noaa")
+	}
+
+	//Noaa goes down and the cache is bypassed so the call fails
+	atomic.StoreInt32(&down, 1)
+	request.BypassCache = true
+	stations, err = retriever.RetrieveStationData(context.Background(), request)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	stationData := (*stations)["8454000"]
+	productData := stationData.ProductData[sledgconf_demo_proto_v1.DataType_WaterLevel.String()]
+	status := stationData.ProductStatus[sledgconf_demo_proto_v1.DataType_WaterLevel.String()]
+	if calls != 2 || !productData.Stale || productData.AgeInSeconds < 0 || len(productData.Data) != 3 {
+		t.Errorf("Expected the stale water levels after 2 calls but got %d calls and %+v", calls, productData)
+	}
+	if status.Status != sledgconf_demo_proto_v1.ProductStatusCode_OK || status.Reason == "" {
+		t.Errorf("Expected the status to say the data is stale but got %+v", status)
+	}
+	//Without anything on hand the error comes back
+	request.StationIDs = []string{"8452944"}
+	if _, err := retriever.RetrieveStationData(context.Background(), request); err == nil {
+		t.Error("Expected the error without any local data")
+	}
+
+	//Fill the store and then go offline
+	atomic.StoreInt32(&down, 0)
+	online := newTestRetriever(server)
+	online.SetStore(store)
+	if _, err := online.RetrieveStationData(context.Background(), request); err != nil {
+		t.Fatal(err.Error())
+	}
+	offline := newTestRetriever(server)
+	offline.SetStore(store)
+	offline.SetServingMode(Offline)
+	calls = 0
+	stations, err = offline.RetrieveStationData(context.Background(), request)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	productData = (*stations)["8452944"].ProductData[sledgconf_demo_proto_v1.DataType_WaterLevel.String()]
+	if calls != 0 || !productData.Stale || len(productData.Data) != 3 {
+		t.Errorf("Expected the stored water levels without calling Noaa but got %d calls and %+v", calls, productData)
+	}
+	request.StationIDs = []string{"8454000"}
+	_, err = offline.RetrieveStationData(context.Background(), request)
+	if _, ok := err.(customerrors.ServiceUnavailableError); !ok || calls != 0 {
+		t.Errorf("Expected the station that isn't stored to be unavailable but got %d calls and %v", calls, err)
+	}
+}
+
+//TestRetrieveStationDataCapabilities - only the products in the station's metadata are asked for and the metadata is only pulled once
+func TestRetrieveStationDataCapabilities(t *testing.T) {
+	var metadataCalls, dataCalls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		//.../stations/8454000.json is mdapi_station.json and .../stations/8454000/sensors.json is mdapi_sensors.json
+		if strings.HasPrefix(r.URL.Path, "/mdapi/") {
+			atomic.AddInt32(&metadataCalls, 1)
+			fileName := "mdapi_" + strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/mdapi/prod/webapi/stations/8454000"), "/")
+			if fileName == "mdapi_.json" {
+				fileName = "mdapi_station.json"
+			}
+			body, err := ioutil.ReadFile(filepath.Join("..", "noaa-client", "testdata", fileName))
+			if err != nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(body)
+			return
+		}
+		atomic.AddInt32(&dataCalls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": {"message": "Wrong Datum: Datum cannot be null or empty"}}`))
+	}))
+	defer server.Close()
+	retriever := newTestRetriever(server)
+	retriever.SetCapabilities(stationmetadata.NewCache(noaaclient.NewNoaaClient(noaaclient.MLLW, noaaclient.Metric.String(), noaaclient.WithBaseURL(server.URL), noaaclient.WithRetryPolicy(noaaclient.NoRetryPolicy), noaaclient.WithCircuitBreakers(nil)), time.Hour))
+	request := &Request{StationIDs: []string{"8454000"}, Window: noaaclient.NewQueryModeWindow(noaaclient.LatestQuery), Datum: noaaclient.MLLW}
+
+	notAvailable := []noaaclient.DataProduct{noaaclient.AirGap, noaaclient.Visibility, noaaclient.Humidity, noaaclient.DailyMean, noaaclient.Currents, noaaclient.CurrentsPredictions}
+	for _, retrieve := range []func(ctx context.Context, request *Request) (*map[string]*sledgconf_demo_proto_v1.Station, error){retriever.RetrieveStationData, retriever.RetrieveStationDataSync} {
+		atomic.StoreInt32(&dataCalls, 0)
+		stations, err := retrieve(context.Background(), request)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		statuses := (*stations)["8454000"].ProductStatus
+		if len(statuses) != int(noaaclient.MaximumLimit) {
+			t.Errorf("Expected a status for every product but got %d", len(statuses))
+		}
+		for _, dataProduct := range notAvailable {
+			if status := statuses[dataProduct.ConvertToGrpcEnum().String()]; status.Status != sledgconf_demo_proto_v1.ProductStatusCode_NotAvailable {
+				t.Errorf("Expected %s to not be available but got %+v", dataProduct, status)
+			}
+		}
+		//The station has water levels so Noaa turning it down is no data with their message (and doesn't fail the request)
+		if status := statuses[sledgconf_demo_proto_v1.DataType_WaterLevel.String()]; status.Status != sledgconf_demo_proto_v1.ProductStatusCode_NoData || !strings.Contains(status.Reason, "Wrong Datum") {
+			t.Errorf("Expected no water level data with Noaa's message but got %+v", status)
+		}
+		if dataCalls != int32(noaaclient.MaximumLimit)-int32(len(notAvailable)) {
+			t.Errorf("Expected %d calls for data but got %d", int(noaaclient.MaximumLimit)-len(notAvailable), dataCalls)
+		}
+	}
+	//The station, its products and its sensors
+	if metadataCalls != 3 {
+		t.Errorf("Expected the metadata to be pulled once but got %d calls", metadataCalls)
+	}
+
+	//A station without metadata is asked for everything
+	atomic.StoreInt32(&dataCalls, 0)
+	request.StationIDs = []string{"8452944"}
+	stations, err := retriever.RetrieveStationData(context.Background(), request)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if dataCalls != int32(noaaclient.MaximumLimit) {
+		t.Errorf("Expected every product to be asked for but got %d calls", dataCalls)
+	}
+	//Without the metadata there is no telling if the station should have it so there isn't a reason
+	if status := (*stations)["8452944"].ProductStatus[sledgconf_demo_proto_v1.DataType_WaterLevel.String()]; status.Status != sledgconf_demo_proto_v1.ProductStatusCode_NoData || status.Reason != "" {
+		t.Errorf("Expected no water level data but got %+v", status)
+	}
+}
+
+//benchmarkRetrieveStationData - every product for 10 stations against a TLS server so the cost of new connections shows up
+func benchmarkRetrieveStationData(b *testing.B, roundTripper func(server *httptest.Server) http.RoundTripper) {
+	waterLevel, err := ioutil.ReadFile(filepath.Join("..", "noaa-client", "testdata", "water_level.json"))
+	if err != nil {
+		b.Fatal(err.Error())
+	}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("product") == noaaclient.WaterLevel.String() {
+			w.Write(waterLevel)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+	retriever := NewRetriever(noaaclient.WithBaseURL(server.URL), noaaclient.WithRoundTripper(roundTripper(server)), noaaclient.WithRetryPolicy(noaaclient.NoRetryPolicy), noaaclient.WithCircuitBreakers(nil))
+	//Every iteration has to go to the server for every product
+	retriever.SetCacheSize(0)
+	retriever.SetCapabilities(nil)
+	retriever.SetDatumSource(nil)
+	request := &Request{StationIDs: []string{"8454000", "8452944", "8453662", "8452951", "8447412", "8447387", "8447386", "8452314", "8454049", "8447930"}, Window: noaaclient.NewQueryModeWindow(noaaclient.LatestQuery), Datum: noaaclient.MLLW}
+	b.ResetTimer()
+	for index := 0; index < b.N; index++ {
+		_, err := retriever.RetrieveStationData(context.Background(), request)
+		if err != nil {
+			b.Fatal(err.Error())
+		}
+	}
+}
+
+//BenchmarkRetrieveStationDataPooledTransport - the pooled transport keeps its connections between calls
+func BenchmarkRetrieveStationDataPooledTransport(b *testing.B) {
+	benchmarkRetrieveStationData(b, func(server *httptest.Server) http.RoundTripper {
+		config := noaaclient.DefaultTransportConfig
+		config.TLSClientConfig = server.Client().Transport.(*http.Transport).TLSClientConfig
+		return noaaclient.NewTransport(config)
+	})
+}
+
+//BenchmarkRetrieveStationDataNewConnections - how it worked before with a new connection (and TLS handshake) for every call
+func BenchmarkRetrieveStationDataNewConnections(b *testing.B) {
+	benchmarkRetrieveStationData(b, func(server *httptest.Server) http.RoundTripper {
+		return &http.Transport{TLSClientConfig: server.Client().Transport.(*http.Transport).TLSClientConfig, DisableKeepAlives: true}
+	})
+}
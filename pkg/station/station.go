@@ -3,14 +3,133 @@
 package station
 
 import (
-	"sync"
+	"context"
 	"time"
 
 	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
 	sledgconf_demo_proto_v1 "github.com/mornindew/sledgeconf2021/pkg/grpc-service/genProto"
 	noaaclient "github.com/mornindew/sledgeconf2021/pkg/noaa-client"
+	productcache "github.com/mornindew/sledgeconf2021/pkg/product-cache"
+	stationstore "github.com/mornindew/sledgeconf2021/pkg/station-store"
+	"github.com/mornindew/sledgeconf2021/pkg/units"
 )
 
+//Request - everything needed to pull the data for a set of stations
+type Request struct {
+	StationIDs []string
+	//Window - how the time window is addressed (date range, latest, today, recent or a range of hours)
+	Window          *noaaclient.QueryWindow
+	Datum           noaaclient.Datum
+	PreferredMetric string
+	//TimeZone - zone for the times that come back (gmt, lst or lst_ldt).  Defaults to gmt
+	TimeZone noaaclient.TimeZone
+	//FailureMode - whether a product failing fails the whole request.  Defaults to fail fast
+	FailureMode FailureMode
+	//Products - only get these products.  Empty gets all of them
+	Products []noaaclient.DataProduct
+	//BypassCache - skip the cache and go to Noaa.  What comes back still gets cached
+	BypassCache bool
+	//Units - the unit for specific products (e.g. wind in knots).  The rest are in the preferred metric
+	Units map[noaaclient.DataProduct]units.Unit
+	//QualityFilter - leaves out the points that Noaa flagged or hasn't verified.  Keeps everything by default
+	QualityFilter noaaclient.QualityFilter
+}
+
+//unitFor - the unit that the product is sent back in.  False if the product doesn't have one
+func (object *Request) unitFor(dataProduct noaaclient.DataProduct) (units.Unit, bool) {
+	if unit, ok := object.Units[dataProduct]; ok {
+		return unit, true
+	}
+	return dataProduct.Unit(noaaclient.PreferredMeasurementUnit(object.PreferredMetric))
+}
+
+//products - the products to get without any repeats.  All of them if there isn't a filter
+func (object *Request) products() []noaaclient.DataProduct {
+	if len(object.Products) == 0 {
+		allProducts := make([]noaaclient.DataProduct, 0, noaaclient.MaximumLimit)
+		//Sort of tricky loop but it is iterating through the enumeration list
+		for productEnum := noaaclient.DataProduct(0); productEnum < noaaclient.MaximumLimit; productEnum++ {
+			allProducts = append(allProducts, productEnum)
+		}
+		return allProducts
+	}
+	seen := make(map[noaaclient.DataProduct]bool, len(object.Products))
+	products := make([]noaaclient.DataProduct, 0, len(object.Products))
+	for _, product := range object.Products {
+		if !seen[product] {
+			seen[product] = true
+			products = append(products, product)
+		}
+	}
+	return products
+}
+
+//FailureMode - enum for what happens when a product fails
+//
+//	FailFast - the first failure is returned and everything else is thrown away
+//	BestEffort - the products that worked come back and the product status says what failed
+type FailureMode int
+
+const (
+	FailFast FailureMode = iota
+	BestEffort
+)
+
+//String - the failureMode param.  Empty when it isn't one of the failure modes
+func (enum FailureMode) String() string {
+	if enum < FailFast || enum > BestEffort {
+		return ""
+	}
+	return []string{"failFast", "bestEffort"}[enum]
+}
+
+//ConvertStringFailureModeToEnum - Helper Function to convert from string to failure mode.  An empty string is fail fast
+func ConvertStringFailureModeToEnum(val string) (FailureMode, error) {
+	switch val {
+	case "", "failFast":
+		return FailFast, nil
+	case "bestEffort":
+		return BestEffort, nil
+	}
+	//Handle something not matching
+	return -1, customerrors.InvalidData{Msg: "Not a valid failure mode"}
+}
+
+//ConvertGrpcEnumToFailureMode - the protobuf enum is kept in the same order so it is a straight cast
+func ConvertGrpcEnumToFailureMode(val sledgconf_demo_proto_v1.FailureMode) FailureMode {
+	return FailureMode(val)
+}
+
+//Validate - checks that the request has everything it needs
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	InvalidData - incorrect data
+func (object *Request) Validate() error {
+	//Precondition check
+	if len(object.StationIDs) == 0 || object.Window == nil {
+		return customerrors.PreconditionError{Msg: "Missing Mandatory Data"}
+	}
+	if object.FailureMode != FailFast && object.FailureMode != BestEffort {
+		return customerrors.InvalidData{Msg: "Not a valid failure mode", InternalErrorCode: 1170}
+	}
+	for _, product := range object.Products {
+		if product < 0 || product >= noaaclient.MaximumLimit {
+			return customerrors.InvalidData{Msg: "Not a valid data product", InternalErrorCode: 1171}
+		}
+	}
+	for product, unit := range object.Units {
+		if product < 0 || product >= noaaclient.MaximumLimit {
+			return customerrors.InvalidData{Msg: "Not a valid data product", InternalErrorCode: 1171}
+		}
+		productUnit, ok := product.Unit(noaaclient.Metric)
+		if !ok || unit < 0 || unit >= units.MaximumLimit || productUnit.Dimension() != unit.Dimension() {
+			return customerrors.InvalidData{Msg: product.String() + " can't be in " + unit.String(), InternalErrorCode: 1173}
+		}
+	}
+	return object.Window.Validate()
+}
+
 //GetStationDataSync - Function to get the tides and currents Syncronously.  It will returns a map with each station and all available data
 //
 //	Errors:
@@ -22,34 +141,19 @@ func RetrieveAllStationDataSync(stationIDs []string, startDate, endDate *time.Ti
 	if len(stationIDs) == 0 || startDate == nil || endDate == nil {
 		return nil, customerrors.PreconditionError{Msg: "Missing Mandatory Data"}
 	}
-	//check that start date is after end date
-	if !endDate.After(*startDate) {
-		return nil, customerrors.InvalidData{Msg: "The End Date is Not After the Start Date", InternalErrorCode: 1156}
-	}
-	mapToReturnOfAllStations := make(map[string]*sledgconf_demo_proto_v1.Station)
-	//Setup the client to call Noaa
-	client := noaaclient.NewNoaaClient(datum, preferredMetric)
-
-	//Loop through all the station IDs
-	for _, val := range stationIDs {
-		//Construct the station object
-		stationData := &sledgconf_demo_proto_v1.Station{}
-		//Construc the station data
-		stationData.ProductData = make(map[string]*sledgconf_demo_proto_v1.ProductDataValues)
-		//Loop through all the data and aggregate what is available
-		//Sort of tricky loop but it is iterating through the enumeration list
-		for productEnum := noaaclient.DataProduct(0); productEnum < noaaclient.MaximumLimit; productEnum++ {
-			stationProductData, err := client.RetreiveDataVariable(startDate, endDate, productEnum, &val)
-			if err != nil {
-				return nil, err
-			}
-			//Append the product data
-			stationData.ProductData[productEnum.String()] = stationProductData
-		}
-		//Append it to the full station map response
-		mapToReturnOfAllStations[val] = stationData
-	}
-	return &mapToReturnOfAllStations, nil
+	return RetrieveStationDataSync(context.Background(), &Request{StationIDs: stationIDs, Window: noaaclient.NewDateRangeWindow(startDate, endDate), Datum: datum, PreferredMetric: preferredMetric})
+}
+
+//RetrieveStationDataSync - same as RetrieveAllStationDataSync but takes a request so that any of the query modes can be used
+//
+//Cancelling the context (or hitting its deadline) stops the calls to Noaa and returns the context's error
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	InvalidData - incorrect data
+//	InternalServerError - unhandled error
+func RetrieveStationDataSync(ctx context.Context, request *Request) (*map[string]*sledgconf_demo_proto_v1.Station, error) {
+	return defaultRetriever.RetrieveStationDataSync(ctx, request)
 }
 
 //Function to get the tides and currents concurrently.  It will run all the calls in parallel and will be much faster than the sync call.
@@ -63,105 +167,66 @@ func RetrieveAllStationDataConcurrently(stationIDs []string, startDate, endDate
 	if len(stationIDs) == 0 || startDate == nil || endDate == nil {
 		return nil, customerrors.PreconditionError{Msg: "Missing Mandatory Data"}
 	}
-	//check that start date is after end date
-	if !endDate.After(*startDate) {
-		return nil, customerrors.InvalidData{Msg: "The End Date is Not After the Start Date", InternalErrorCode: 1156}
-	}
-	mapToReturnOfAllStations := make(map[string]*sledgconf_demo_proto_v1.Station)
-	//Setup the waitgroup
-	var wg sync.WaitGroup
-	wg.Add(len(stationIDs))
-	//Setup an error and data chan
-	dataChan := make(chan interface{})
-	//Loop through all the station IDs
-	for _, val := range stationIDs {
-		go func(stationID string) {
-			defer wg.Done()
-			//get all the data from each station
-			stationData, err := getAllDataFromStationConcurrently(datum, preferredMetric, startDate, endDate, stationID)
-			if err != nil {
-				dataChan <- err
-				return
-			}
-			//put the station data on the data channel
-			dataChan <- stationData
-		}(val)
-	}
+	return RetrieveStationData(context.Background(), &Request{StationIDs: stationIDs, Window: noaaclient.NewDateRangeWindow(startDate, endDate), Datum: datum, PreferredMetric: preferredMetric})
+}
 
-	//Separate go routine to "wait" and close the error chan
-	go func() {
-		wg.Wait()
-		close(dataChan)
-	}()
-
-	//Loop through all the errors until the err Channel Closes
-	for data := range dataChan {
-		switch data.(type) {
-		case *sledgconf_demo_proto_v1.Station:
-			//Now that we know it is a product data value then we add it to the map
-			castedValue := data.(*sledgconf_demo_proto_v1.Station)
-			mapToReturnOfAllStations[castedValue.StationID] = castedValue
-		case error:
-			err := data.(error)
-			return nil, err
-		default:
-			//Log unhandled chan data
-		}
-	}
-	return &mapToReturnOfAllStations, nil
-}
-
-///INTERNAL FUNCTIONS
-
-//internal function to get all the data from a station
-func getAllDataFromStationConcurrently(datum noaaclient.Datum, preferredMetric string, startDate, endDate *time.Time, stationID string) (*sledgconf_demo_proto_v1.Station, error) {
-	//Setup the waitgroup
-	var wg sync.WaitGroup
-	//Setup an err chan
-	dataChan := make(chan interface{})
-	//Cast the max limit over to an int
-	wg.Add(int(noaaclient.MaximumLimit))
-	stationData := &sledgconf_demo_proto_v1.Station{StationID: stationID}
-	stationData.ProductData = make(map[string]*sledgconf_demo_proto_v1.ProductDataValues)
-	//Sort of tricky loop but it is iterating through the enumeration list
-	for productEnum := noaaclient.DataProduct(0); productEnum < noaaclient.MaximumLimit; productEnum++ {
-		go func(goRoutineProductEnum noaaclient.DataProduct) {
-			//Important defer wg.done - to tell the wg when done
-			defer wg.Done()
-			client := noaaclient.NewNoaaClient(datum, preferredMetric)
-			stationProductData, err := client.RetreiveDataVariable(startDate, endDate, goRoutineProductEnum, &stationID)
-			if err != nil {
-				dataChan <- err
-				//I don't have to always return but in this case I will
-				return
-			}
-			//Set the enum - since it doesn't come from teh webserivce and the chan will need it
-			stationProductData.DataType = goRoutineProductEnum.ConvertToGrpcEnum()
-			//We send the data through the data chan to avoid concurrency issues with map writes
-			dataChan <- stationProductData
-		}(productEnum)
-	}
+//RetrieveStationData - same as RetrieveAllStationDataConcurrently but takes a request so that any of the query modes can be used
+//
+//Cancelling the context (or hitting its deadline) stops the calls to Noaa and returns the context's error
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	InvalidData - incorrect data
+//	InternalServerError - unhandled error
+func RetrieveStationData(ctx context.Context, request *Request) (*map[string]*sledgconf_demo_proto_v1.Station, error) {
+	return defaultRetriever.RetrieveStationData(ctx, request)
+}
 
-	//Separate go routine to "wait" and close teh error chan
-	go func() {
-		wg.Wait()
-		close(dataChan)
-	}()
-
-	//Loop through all the errors until the err Channel Closes
-	for data := range dataChan {
-		//I am switching on the type but also could switch on the error
-		switch data.(type) {
-		case *sledgconf_demo_proto_v1.ProductDataValues:
-			//Now that we know it is a product data value then we add it to the map
-			castedValue := data.(*sledgconf_demo_proto_v1.ProductDataValues)
-			stationData.ProductData[castedValue.DataType.String()] = castedValue
-		case error:
-			err := data.(error)
-			return nil, err
-		default:
-			//TODO: Log or error out on unhandled chan data
-		}
+//CoalescingStats - how many calls the package level functions made to Noaa and how many were saved by sharing a call that was already in flight
+func CoalescingStats() noaaclient.CoalescingStats {
+	return defaultRetriever.CoalescingStats()
+}
+
+//UseStore - the package level functions keep the data in the store so only the time ranges that are missing are fetched from Noaa.  Call it before serving any requests
+func UseStore(store *stationstore.Store) {
+	defaultRetriever.SetStore(store)
+}
+
+//UseWorkerPool - the pool that limits how many products the package level functions fetch at once.  Call it before serving any requests
+func UseWorkerPool(pool *WorkerPool) {
+	defaultRetriever.SetWorkerPool(pool)
+}
+
+//UseServingMode - what the package level functions do when Noaa can't be reached (see ServingMode).  Call it before serving any requests
+func UseServingMode(mode ServingMode) {
+	defaultRetriever.SetServingMode(mode)
+}
+
+//RefreshCapabilities - keeps the station metadata that the package level functions plan their calls with up to date until the context is done.  Blocks so call it in its own go routine
+func RefreshCapabilities(ctx context.Context) {
+	defaultRetriever.RefreshCapabilities(ctx)
+}
+
+//CacheStats - hits, misses and size of the cache that the package level functions use
+func CacheStats() productcache.Stats {
+	return defaultRetriever.CacheStats()
+}
+
+//ObservationsForProduct - returns the typed observations for one product on a station so callers don't have to parse the strings
+//
+//	Errors:
+//	PreconditionError - missing mandatory data
+//	NotFoundError - the station doesn't have the product
+//	BadFormat - the data couldn't be parsed
+func ObservationsForProduct(stationData *sledgconf_demo_proto_v1.Station, dataProduct noaaclient.DataProduct) ([]noaaclient.Observation, error) {
+	//Precondition check
+	if stationData == nil {
+		return nil, customerrors.PreconditionError{Msg: "Missing Mandatory Data"}
+	}
+	productData, ok := stationData.ProductData[dataProduct.ConvertToGrpcEnum().String()]
+	if !ok {
+		return nil, customerrors.NotFoundError{Msg: "No data for " + dataProduct.String()}
 	}
-	return stationData, nil
+	//The times come back in the zone that was requested
+	return noaaclient.ConvertTypedDataToObservations(productData)
 }
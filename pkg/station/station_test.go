@@ -1,12 +1,14 @@
 package station
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strconv"
 	"testing"
 	"time"
 
+	sledgconf_demo_proto_v1 "github.com/mornindew/sledgeconf2021/pkg/grpc-service/genProto"
 	noaaclient "github.com/mornindew/sledgeconf2021/pkg/noaa-client"
 )
 
@@ -115,3 +117,46 @@ func TestGetTidesAndCurrentsConcurrently(t *testing.T) {
 		}
 	}
 }
+
+//TestObservationsForProduct - the typed observations should come back for a product on the station
+func TestObservationsForProduct(t *testing.T) {
+	stationData := &sledgconf_demo_proto_v1.Station{StationID: "8454000", ProductData: map[string]*sledgconf_demo_proto_v1.ProductDataValues{
+		"AirTemperature": {DataType: sledgconf_demo_proto_v1.DataType_AirTemperature, Data: []*sledgconf_demo_proto_v1.Data{{T: "2021-08-20 15:06", V: "24.5", F: "0,0,0"}}},
+	}}
+	observations, err := ObservationsForProduct(stationData, noaaclient.AirTemperature)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(observations) != 1 || observations[0].Value != 24.5 || observations[0].Time.Minute() != 6 {
+		t.Error("Observations were not typed")
+	}
+	_, err = ObservationsForProduct(stationData, noaaclient.Wind)
+	if err == nil {
+		t.Error("Expected a not found error")
+	}
+}
+
+//TestRetrieveStationDataCancelled - a context that is already done shouldn't call Noaa at all
+func TestRetrieveStationDataCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	request := &Request{StationIDs: []string{"8454000"}, Window: noaaclient.NewQueryModeWindow(noaaclient.LatestQuery), Datum: noaaclient.MLLW}
+	_, err := RetrieveStationData(ctx, request)
+	if err != context.Canceled {
+		t.Errorf("Expected the concurrent call to be cancelled but got %v", err)
+	}
+	_, err = RetrieveStationDataSync(ctx, request)
+	if err != context.Canceled {
+		t.Errorf("Expected the sync call to be cancelled but got %v", err)
+	}
+}
+
+//TestModeStrings - modes outside the enums don't panic
+func TestModeStrings(t *testing.T) {
+	if BestEffort.String() != "bestEffort" || FailureMode(-1).String() != "" || FailureMode(2).String() != "" {
+		t.Error("Unexpected failure mode names")
+	}
+	if StaleFallback.String() != "staleFallback" || ServingMode(-1).String() != "" || ServingMode(3).String() != "" {
+		t.Error("Unexpected serving mode names")
+	}
+}
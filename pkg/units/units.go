@@ -0,0 +1,183 @@
+//Package units is a small quantity model for the measurements that Noaa sends back (water levels, temperatures, speeds, pressures and distances) so they can be converted locally
+package units
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
+)
+
+//Dimension - what a unit measures.  Only units with the same dimension can be converted
+type Dimension int
+
+const (
+	Length Dimension = iota
+	Temperature
+	Speed
+	Pressure
+	Conductivity
+	Salinity
+	Ratio
+)
+
+//InvalidDimension - what a unit outside the enum measures
+const InvalidDimension Dimension = -1
+
+//String - the name of the dimension.  Empty when it isn't one of the dimensions
+func (enum Dimension) String() string {
+	if enum < Length || enum > Ratio {
+		return ""
+	}
+	return []string{"length", "temperature", "speed", "pressure", "conductivity", "salinity", "ratio"}[enum]
+}
+
+//Unit - enum for the units of measure.  The string is the symbol (e.g. ft or kn)
+type Unit int
+
+const (
+	Meters Unit = iota
+	Centimeters
+	Feet
+	Inches
+	Kilometers
+	Miles
+	NauticalMiles
+	Celsius
+	Fahrenheit
+	Kelvin
+	MetersPerSecond
+	CentimetersPerSecond
+	Knots
+	MilesPerHour
+	KilometersPerHour
+	FeetPerSecond
+	Millibars
+	Hectopascals
+	Kilopascals
+	InchesOfMercury
+	MillimetersOfMercury
+	MillisiemensPerCentimeter
+	PracticalSalinityUnits
+	Percent
+	MaximumLimit //Must be the last one - makes it very easy to loop though
+)
+
+func (enum Unit) String() string {
+	if enum < 0 || enum >= MaximumLimit {
+		return ""
+	}
+	return definitions[enum].symbol
+}
+
+//Dimension - what the unit measures.  InvalidDimension when it isn't one of the units
+func (enum Unit) Dimension() Dimension {
+	if enum < 0 || enum >= MaximumLimit {
+		return InvalidDimension
+	}
+	return definitions[enum].dimension
+}
+
+//ConvertStringUnitToEnum - Helper Function to convert from string to unit.  Takes the symbol or the name and doesn't care about case (e.g. kn, knots or Knots)
+func ConvertStringUnitToEnum(val string) (Unit, error) {
+	val = strings.TrimSpace(val)
+	for unit := Unit(0); unit < MaximumLimit; unit++ {
+		if strings.EqualFold(val, definitions[unit].symbol) {
+			return unit, nil
+		}
+		for _, name := range definitions[unit].names {
+			if strings.EqualFold(val, name) {
+				return unit, nil
+			}
+		}
+	}
+	//Handle something not matching
+	return -1, customerrors.InvalidData{Msg: "Not a valid unit: " + val}
+}
+
+//Convert - the value in the other unit
+//
+//	Errors:
+//	InvalidData - the units aren't valid or don't measure the same thing (e.g. feet to knots)
+func Convert(value float64, from, to Unit) (float64, error) {
+	if from < 0 || from >= MaximumLimit || to < 0 || to >= MaximumLimit {
+		return 0, customerrors.InvalidData{Msg: "Not a valid unit"}
+	}
+	if from == to {
+		return value, nil
+	}
+	if from.Dimension() != to.Dimension() {
+		return 0, customerrors.InvalidData{Msg: "Can't convert " + from.String() + " to " + to.String() + " since one is a " + from.Dimension().String() + " and the other is a " + to.Dimension().String()}
+	}
+	//Everything goes through the base unit for the dimension (meters, kelvin, meters per second and pascals)
+	base := value*definitions[from].scale + definitions[from].offset
+	return (base - definitions[to].offset) / definitions[to].scale, nil
+}
+
+//Quantity - a value and the unit it is in
+type Quantity struct {
+	Value float64
+	Unit  Unit
+}
+
+//In - the quantity in the other unit
+//
+//	Errors:
+//	InvalidData - the units don't measure the same thing
+func (object Quantity) In(unit Unit) (Quantity, error) {
+	value, err := Convert(object.Value, object.Unit, unit)
+	if err != nil {
+		return Quantity{}, err
+	}
+	return Quantity{Value: value, Unit: unit}, nil
+}
+
+func (object Quantity) String() string {
+	return Format(object.Value) + " " + object.Unit.String()
+}
+
+//Format - the value rounded to 3 decimal places without trailing zeros.  That is as precise as anything Noaa sends
+func Format(value float64) string {
+	//Adding 0 turns a rounded -0 into 0
+	return strconv.FormatFloat(math.Round(value*1000)/1000+0, 'f', -1, 64)
+}
+
+///INTERNAL FUNCTIONS
+
+//definition - how a unit converts to the base unit for its dimension.  base = value * scale + offset
+type definition struct {
+	symbol    string
+	names     []string
+	dimension Dimension
+	scale     float64
+	offset    float64
+}
+
+//definitions - kept in the same order as the enum
+var definitions = [...]definition{
+	Meters:                    {"m", []string{"meter", "meters", "metre", "metres"}, Length, 1, 0},
+	Centimeters:               {"cm", []string{"centimeter", "centimeters"}, Length, 0.01, 0},
+	Feet:                      {"ft", []string{"foot", "feet"}, Length, 0.3048, 0},
+	Inches:                    {"in", []string{"inch", "inches"}, Length, 0.0254, 0},
+	Kilometers:                {"km", []string{"kilometer", "kilometers"}, Length, 1000, 0},
+	Miles:                     {"mi", []string{"mile", "miles"}, Length, 1609.344, 0},
+	NauticalMiles:             {"nmi", []string{"nauticalMile", "nauticalMiles", "nm"}, Length, 1852, 0},
+	Celsius:                   {"degC", []string{"celsius", "C", "°C"}, Temperature, 1, 273.15},
+	Fahrenheit:                {"degF", []string{"fahrenheit", "F", "°F"}, Temperature, 5.0 / 9.0, 273.15 - 32*5.0/9.0},
+	Kelvin:                    {"K", []string{"kelvin"}, Temperature, 1, 0},
+	MetersPerSecond:           {"m/s", []string{"metersPerSecond", "mps"}, Speed, 1, 0},
+	CentimetersPerSecond:      {"cm/s", []string{"centimetersPerSecond"}, Speed, 0.01, 0},
+	Knots:                     {"kn", []string{"knot", "knots", "kt", "kts"}, Speed, 1852.0 / 3600.0, 0},
+	MilesPerHour:              {"mph", []string{"milesPerHour"}, Speed, 0.44704, 0},
+	KilometersPerHour:         {"km/h", []string{"kilometersPerHour", "kph"}, Speed, 1 / 3.6, 0},
+	FeetPerSecond:             {"ft/s", []string{"feetPerSecond", "fps"}, Speed, 0.3048, 0},
+	Millibars:                 {"mb", []string{"millibar", "millibars", "mbar"}, Pressure, 100, 0},
+	Hectopascals:              {"hPa", []string{"hectopascal", "hectopascals"}, Pressure, 100, 0},
+	Kilopascals:               {"kPa", []string{"kilopascal", "kilopascals"}, Pressure, 1000, 0},
+	InchesOfMercury:           {"inHg", []string{"inchesOfMercury"}, Pressure, 3386.389, 0},
+	MillimetersOfMercury:      {"mmHg", []string{"millimetersOfMercury"}, Pressure, 101325.0 / 760.0, 0},
+	MillisiemensPerCentimeter: {"mS/cm", []string{"millisiemensPerCentimeter"}, Conductivity, 1, 0},
+	PracticalSalinityUnits:    {"psu", []string{"practicalSalinityUnits"}, Salinity, 1, 0},
+	Percent:                   {"%", []string{"percent"}, Ratio, 1, 0},
+}
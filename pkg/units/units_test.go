@@ -0,0 +1,99 @@
+package units
+
+import (
+	"math"
+	"testing"
+
+	customerrors "github.com/mornindew/sledgeconf2021/pkg/custom-errors"
+)
+
+//TestConvert - known values for every dimension and back again
+func TestConvert(t *testing.T) {
+	for _, test := range []struct {
+		value    float64
+		from, to Unit
+		expected float64
+	}{
+		{1, Meters, Feet, 3.28084},
+		{12, Inches, Feet, 1},
+		{1, NauticalMiles, Kilometers, 1.852},
+		{10, Kilometers, NauticalMiles, 5.39957},
+		{0, Celsius, Fahrenheit, 32},
+		{100, Celsius, Fahrenheit, 212},
+		{-40, Fahrenheit, Celsius, -40},
+		{0, Celsius, Kelvin, 273.15},
+		{10, MetersPerSecond, Knots, 19.43844},
+		{1, Knots, MilesPerHour, 1.15078},
+		{36, KilometersPerHour, MetersPerSecond, 10},
+		{51.4, CentimetersPerSecond, Knots, 0.99914},
+		{1013.25, Millibars, InchesOfMercury, 29.92126},
+		{1013.25, Hectopascals, Kilopascals, 101.325},
+		{760, MillimetersOfMercury, Millibars, 1013.25},
+	} {
+		converted, err := Convert(test.value, test.from, test.to)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if math.Abs(converted-test.expected) > 0.0001 {
+			t.Errorf("Expected %f %s to be %f %s but got %f", test.value, test.from, test.expected, test.to, converted)
+		}
+		back, _ := Convert(converted, test.to, test.from)
+		if math.Abs(back-test.value) > 0.0000001 {
+			t.Errorf("Expected %f %s back but got %f", test.value, test.from, back)
+		}
+	}
+
+	if _, err := Convert(1, Feet, Knots); !isInvalidData(err) {
+		t.Errorf("Expected invalid data but got %v", err)
+	}
+	if _, err := Convert(1, Feet, MaximumLimit); !isInvalidData(err) {
+		t.Errorf("Expected invalid data but got %v", err)
+	}
+}
+
+//TestUnitEnums - every unit has a symbol that converts back and the names work too
+func TestUnitEnums(t *testing.T) {
+	for unit := Unit(0); unit < MaximumLimit; unit++ {
+		converted, err := ConvertStringUnitToEnum(unit.String())
+		if err != nil || converted != unit {
+			t.Errorf("Expected %s back but got %s %v", unit, converted, err)
+		}
+	}
+	for name, expected := range map[string]Unit{"knots": Knots, "FEET": Feet, " fahrenheit ": Fahrenheit, "mbar": Millibars, "kph": KilometersPerHour} {
+		converted, err := ConvertStringUnitToEnum(name)
+		if err != nil || converted != expected {
+			t.Errorf("Expected %s for %s but got %s %v", expected, name, converted, err)
+		}
+	}
+	invalid, err := ConvertStringUnitToEnum("furlongs")
+	if !isInvalidData(err) {
+		t.Errorf("Expected invalid data but got %v", err)
+	}
+	//Units outside the enum don't panic
+	if invalid.Dimension() != InvalidDimension || MaximumLimit.Dimension() != InvalidDimension || InvalidDimension.String() != "" {
+		t.Error("Expected an invalid dimension")
+	}
+}
+
+//TestQuantity - converting and printing a quantity
+func TestQuantity(t *testing.T) {
+	quantity, err := Quantity{Value: 1.695, Unit: Meters}.In(Feet)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if quantity.String() != "5.561 ft" {
+		t.Errorf("Expected 5.561 ft but got %s", quantity)
+	}
+	if _, err := (Quantity{Value: 1, Unit: Celsius}).In(Percent); err == nil {
+		t.Error("Expected an error")
+	}
+	if Format(2.5) != "2.5" || Format(-0.0004) != "0" {
+		t.Errorf("Unexpected formatting %s %s", Format(2.5), Format(-0.0004))
+	}
+}
+
+//isInvalidData - whether the error is an InvalidData
+func isInvalidData(err error) bool {
+	_, ok := err.(customerrors.InvalidData)
+	return ok
+}
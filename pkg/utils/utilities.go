@@ -12,6 +12,11 @@ func ConvertTimeToyyyyMMdd(val time.Time) string {
 	return val.Format("20060102")
 }
 
+//ConvertTimeToyyyyMMddHHmm will convert to a string with minute precision (e.g. "20210820 15:06")
+func ConvertTimeToyyyyMMddHHmm(val time.Time) string {
+	return val.Format("20060102 15:04")
+}
+
 //MarshalDataToInterface - Utility function to marshall data to struct.  If you don't pass in a pointer then no value will be returned
 func MarshalDataToInterface(data []byte, pointerOfStructToMarshalInto interface{}) error {
 
@@ -12,3 +12,11 @@ func TestTimeUtils(t *testing.T) {
 		t.Error("Incorrect Value")
 	}
 }
+
+func TestMinuteTimeUtils(t *testing.T) {
+	val := time.Date(2021, time.August, 20, 15, 6, 59, 0, time.UTC)
+	stringVal := ConvertTimeToyyyyMMddHHmm(val)
+	if stringVal != "20210820 15:06" {
+		t.Error("Incorrect Value")
+	}
+}